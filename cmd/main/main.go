@@ -1,11 +1,26 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/kgugunava/avito-tech-internship/internal/app"
 )
 
 
 func main() {
+	// go run ./cmd/main migrate status | up | down [steps]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := app.RunMigrate(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	app := app.NewApp()
-	app.Router.Run(app.Cfg.ServerAddress)
-}
\ No newline at end of file
+	if err := app.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
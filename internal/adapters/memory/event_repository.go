@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+)
+
+func (r *PullRequestRepository) AddEvents(ctx context.Context, events []models.PullRequestEvent) error {
+    defer r.store.lock(ctx)()
+
+    r.store.addEvents(events)
+    return nil
+}
+
+func (r *PullRequestRepository) GetEvents(ctx context.Context, prID string) ([]models.PullRequestEvent, error) {
+    defer r.store.lock(ctx)()
+
+    events := []models.PullRequestEvent{}
+    for _, e := range r.store.events {
+        if e.PullRequestId == prID {
+            events = append(events, e)
+        }
+    }
+    return events, nil
+}
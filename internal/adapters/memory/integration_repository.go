@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+type IntegrationRepository struct {
+	store *Store
+}
+
+func NewIntegrationRepository(store *Store) *IntegrationRepository {
+	return &IntegrationRepository{store: store}
+}
+
+func (r *IntegrationRepository) LinkAccount(ctx context.Context, account models.VcsAccount) error {
+    defer r.store.lock(ctx)()
+
+    if _, ok := r.store.users[account.UserId]; !ok {
+        return internal_models.ErrUserNotFound
+    }
+    key := vcsKey{provider: account.Provider, id: account.Login}
+    r.store.touchVcsAccount(key)
+    r.store.vcsAccounts[key] = account.UserId
+
+    return nil
+}
+
+func (r *IntegrationRepository) UnlinkAccount(ctx context.Context, provider, login string) error {
+    defer r.store.lock(ctx)()
+
+    key := vcsKey{provider: provider, id: login}
+    if _, ok := r.store.vcsAccounts[key]; !ok {
+        return internal_models.ErrAccountNotFound
+    }
+    r.store.touchVcsAccount(key)
+    delete(r.store.vcsAccounts, key)
+
+    return nil
+}
+
+func (r *IntegrationRepository) ListAccounts(ctx context.Context, provider, userId string) ([]models.VcsAccount, error) {
+    defer r.store.lock(ctx)()
+
+    accounts := []models.VcsAccount{}
+    for key, accountUserId := range r.store.vcsAccounts {
+        if provider != "" && key.provider != provider {
+            continue
+        }
+        if userId != "" && accountUserId != userId {
+            continue
+        }
+        accounts = append(accounts, models.VcsAccount{Provider: key.provider, Login: key.id, UserId: accountUserId})
+    }
+    sort.Slice(accounts, func(i, j int) bool {
+        if accounts[i].Provider != accounts[j].Provider {
+            return accounts[i].Provider < accounts[j].Provider
+        }
+        return accounts[i].Login < accounts[j].Login
+    })
+
+    return accounts, nil
+}
+
+func (r *IntegrationRepository) ResolveAccount(ctx context.Context, provider, login string) (string, error) {
+    defer r.store.lock(ctx)()
+
+    userId, ok := r.store.vcsAccounts[vcsKey{provider: provider, id: login}]
+    if !ok {
+        return "", internal_models.ErrAccountNotFound
+    }
+    return userId, nil
+}
+
+func (r *IntegrationRepository) ClaimDelivery(ctx context.Context, provider, deliveryId, event string, staleBefore time.Time) (bool, error) {
+    defer r.store.lock(ctx)()
+
+    key := vcsKey{provider: provider, id: deliveryId}
+    if d, ok := r.store.vcsDeliveries[key]; ok {
+        stale := d.status == "processing" && d.receivedAt.Before(staleBefore)
+        if d.status != "failed" && !stale {
+            return false, nil
+        }
+    }
+    r.store.touchVcsDelivery(key)
+    r.store.vcsDeliveries[key] = &vcsDeliveryRecord{event: event, status: "processing", receivedAt: now()}
+
+    return true, nil
+}
+
+func (r *IntegrationRepository) FinishDelivery(ctx context.Context, provider, deliveryId, status, result string) error {
+    defer r.store.lock(ctx)()
+
+    key := vcsKey{provider: provider, id: deliveryId}
+    d, ok := r.store.vcsDeliveries[key]
+    if !ok {
+        return internal_models.ErrDeliveryNotFound
+    }
+    r.store.touchVcsDelivery(key)
+    finishedAt := now()
+    d.status = status
+    d.result = result
+    d.finishedAt = &finishedAt
+
+    return nil
+}
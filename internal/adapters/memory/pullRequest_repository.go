@@ -0,0 +1,494 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+type PullRequestRepository struct {
+	store *Store
+}
+
+func NewPullRequestRepository(store *Store) *PullRequestRepository {
+	return &PullRequestRepository{store: store}
+}
+
+func (r *PullRequestRepository) PRExists(ctx context.Context, prId string) (bool, error) {
+    defer r.store.lock(ctx)()
+
+    _, ok := r.store.pullRequests[prId]
+    return ok, nil
+}
+
+func (r *PullRequestRepository) GetUserTeam(ctx context.Context, userId string) (int, error) {
+    defer r.store.lock(ctx)()
+
+    u, ok := r.store.users[userId]
+    if !ok {
+        return 0, errors.New("author not found")
+    }
+    return u.teamId, nil
+}
+
+func (r *PullRequestRepository) GetTeamID(ctx context.Context, teamName string) (int, error) {
+    defer r.store.lock(ctx)()
+
+    t, err := r.store.teamByName(teamName)
+    if err != nil {
+        return 0, err
+    }
+    return t.id, nil
+}
+
+func (r *PullRequestRepository) IsTeamMember(ctx context.Context, teamId int, userId string) (bool, error) {
+    defer r.store.lock(ctx)()
+
+    return r.store.isMember(teamId, userId), nil
+}
+
+func (r *PullRequestRepository) GetPullRequestTeam(ctx context.Context, prId string) (int, error) {
+    defer r.store.lock(ctx)()
+
+    pr, ok := r.store.pullRequests[prId]
+    if !ok {
+        return 0, internal_models.ErrPullRequestNotFound
+    }
+    return r.store.prTeamId(pr), nil
+}
+
+func (r *PullRequestRepository) GetTeamAssignmentSettings(ctx context.Context, teamId int) (internal_models.TeamAssignmentSettings, error) {
+    defer r.store.lock(ctx)()
+
+    t, ok := r.store.teams[teamId]
+    if !ok {
+        return internal_models.TeamAssignmentSettings{}, internal_models.ErrTeamNotFound
+    }
+    return r.store.settings(t), nil
+}
+
+func (r *PullRequestRepository) GetTeamMembers(ctx context.Context, teamId int) ([]models.TeamMember, error) {
+    defer r.store.lock(ctx)()
+
+    members := []models.TeamMember{}
+    for _, userId := range r.store.teamMembers(teamId) {
+        u := r.store.users[userId]
+        members = append(members, models.TeamMember{
+            UserId:       u.id,
+            Username:     u.username,
+            IsActive:     u.isActive,
+            ReviewWeight: u.reviewWeight,
+        })
+    }
+    return members, nil
+}
+
+func (r *PullRequestRepository) GetUsersActivity(ctx context.Context, userIds []string) (map[string]bool, error) {
+    defer r.store.lock(ctx)()
+
+    activity := make(map[string]bool, len(userIds))
+    for _, userId := range userIds {
+        if u, ok := r.store.users[userId]; ok {
+            activity[userId] = u.isActive
+        }
+    }
+    return activity, nil
+}
+
+func (r *PullRequestRepository) GetTeamCandidates(ctx context.Context, teamId int, exclude []string) ([]internal_models.ReviewerCandidate, error) {
+    defer r.store.lock(ctx)()
+
+    return r.store.candidates(teamId, exclude), nil
+}
+
+// GetReviewerPools возвращает команды в порядке опроса: сама команда, запасные по порядку,
+// затем вышестоящие снизу вверх; каждая команда встречается один раз
+func (r *PullRequestRepository) GetReviewerPools(ctx context.Context, teamId int) ([]internal_models.ReviewerPool, error) {
+    defer r.store.lock(ctx)()
+
+    return r.store.reviewerPools(teamId), nil
+}
+
+func (s *Store) reviewerPools(teamId int) []internal_models.ReviewerPool {
+    pools := []internal_models.ReviewerPool{}
+    t, ok := s.teams[teamId]
+    if !ok {
+        return pools
+    }
+
+    seen := map[int]bool{}
+    add := func(poolTeamId int, source string) {
+        poolTeam, ok := s.teams[poolTeamId]
+        if !ok || seen[poolTeamId] {
+            return
+        }
+        seen[poolTeamId] = true
+        pools = append(pools, internal_models.ReviewerPool{
+            TeamID:   poolTeam.id,
+            TeamName: poolTeam.name,
+            Strategy: poolTeam.strategy,
+            Source:   source,
+        })
+    }
+
+    add(t.id, internal_models.ReviewerSourceTeam)
+    for _, fallbackId := range t.fallbacks {
+        add(fallbackId, internal_models.ReviewerSourceFallback)
+    }
+    visited := map[int]bool{t.id: true}
+    for parentId := t.parentId; parentId != 0 && !visited[parentId]; parentId = s.teams[parentId].parentId {
+        visited[parentId] = true
+        add(parentId, internal_models.ReviewerSourceParent)
+    }
+
+    return pools
+}
+
+func (r *PullRequestRepository) CreatePR(ctx context.Context, req models.PullRequestCreatePostRequest, teamId int, status string) error {
+    defer r.store.lock(ctx)()
+
+    if _, ok := r.store.pullRequests[req.PullRequestId]; ok {
+        return internal_models.ErrPullRequestExists
+    }
+    if _, ok := r.store.users[req.AuthorId]; !ok {
+        return errors.New("author not found")
+    }
+    if _, ok := r.store.teams[teamId]; !ok {
+        return internal_models.ErrTeamNotFound
+    }
+
+    r.store.touchPullRequest(req.PullRequestId)
+    r.store.pullRequests[req.PullRequestId] = &pullRequestRecord{
+        id:        req.PullRequestId,
+        name:      req.PullRequestName,
+        authorId:  req.AuthorId,
+        teamId:    teamId,
+        status:    status,
+        createdAt: now(),
+    }
+    return nil
+}
+
+// LockPullRequest только проверяет, что PR существует: UnitOfWork и так держит хранилище целиком
+func (r *PullRequestRepository) LockPullRequest(ctx context.Context, prID string) error {
+    defer r.store.lock(ctx)()
+
+    if _, ok := r.store.pullRequests[prID]; !ok {
+        return internal_models.ErrPullRequestNotFound
+    }
+    return nil
+}
+
+func (r *PullRequestRepository) AssignReviewers(ctx context.Context, prId string, reviewers []internal_models.SelectedReviewer) error {
+    defer r.store.lock(ctx)()
+
+    pr, ok := r.store.pullRequests[prId]
+    if !ok {
+        return internal_models.ErrPullRequestNotFound
+    }
+
+    return r.store.insertReviewers(pr, reviewers)
+}
+
+// insertReviewers добавляет ревьюверов PR, проверив, что их можно добавить всех
+func (s *Store) insertReviewers(pr *pullRequestRecord, reviewers []internal_models.SelectedReviewer) error {
+    for _, reviewer := range reviewers {
+        if _, ok := s.users[reviewer.UserID]; !ok {
+            return internal_models.ErrUserNotFound
+        }
+        if findReviewer(pr, reviewer.UserID) != nil {
+            return fmt.Errorf("reviewer %s is already assigned to this PR", reviewer.UserID)
+        }
+    }
+
+    s.touchPullRequest(pr.id)
+    for _, reviewer := range reviewers {
+        pr.reviewers = append(pr.reviewers, newReviewerRecord(reviewer, now()))
+    }
+    return nil
+}
+
+func (r *PullRequestRepository) MarkReadyForReview(ctx context.Context, prID string, reviewers []internal_models.SelectedReviewer) error {
+    defer r.store.lock(ctx)()
+
+    pr, ok := r.store.pullRequests[prID]
+    if !ok || pr.status != "DRAFT" {
+        return errors.New("pull request is not a draft")
+    }
+
+    if err := r.store.insertReviewers(pr, reviewers); err != nil {
+        return err
+    }
+    r.store.touchPullRequest(prID)
+    pr.status = "OPEN"
+
+    return nil
+}
+
+func (r *PullRequestRepository) GetByID(ctx context.Context, prID string) (models.PullRequest, error) {
+    defer r.store.lock(ctx)()
+
+    pr, ok := r.store.pullRequests[prID]
+    if !ok {
+        return models.PullRequest{}, internal_models.ErrPullRequestNotFound
+    }
+
+    return r.store.pullRequestModel(pr, true), nil
+}
+
+// pullRequestModel собирает ответ API; withDetails добавляет источники ревьюверов, вердикты и merge override
+func (s *Store) pullRequestModel(pr *pullRequestRecord, withDetails bool) models.PullRequest {
+    result := models.PullRequest{
+        PullRequestId:     pr.id,
+        PullRequestName:   pr.name,
+        AuthorId:          pr.authorId,
+        TeamName:          s.teamName(s.prTeamId(pr)),
+        Status:            pr.status,
+        MergedAt:          pr.mergedAt,
+        ClosedAt:          pr.closedAt,
+        AssignedReviewers: []string{},
+    }
+    createdAt := pr.createdAt
+    result.CreatedAt = &createdAt
+
+    for _, rev := range sortedReviewers(pr) {
+        // ревьювер удалён: остаётся только его вердикт, как reviewer_id IS NULL в postgres
+        if rev.userId == "" {
+            if withDetails && rev.verdict != "" {
+                result.Reviews = append(result.Reviews, models.PullRequestReview{
+                    Verdict:     rev.verdict,
+                    Comment:     rev.comment,
+                    SubmittedAt: rev.verdictAt,
+                })
+            }
+            continue
+        }
+
+        result.AssignedReviewers = append(result.AssignedReviewers, rev.userId)
+        if !withDetails {
+            continue
+        }
+
+        source := models.ReviewerSource{
+            ReviewerId: rev.userId,
+            Source:     internal_models.ReviewerSourceTeam,
+            TeamName:   result.TeamName,
+        }
+        if rev.source != "" {
+            source.Source = rev.source
+        }
+        if name := s.teamName(rev.sourceTeamId); name != "" {
+            source.TeamName = name
+        }
+        result.ReviewerSources = append(result.ReviewerSources, source)
+
+        if rev.verdict != "" {
+            result.Reviews = append(result.Reviews, models.PullRequestReview{
+                ReviewerId:  rev.userId,
+                Verdict:     rev.verdict,
+                Comment:     rev.comment,
+                SubmittedAt: rev.verdictAt,
+            })
+        }
+    }
+
+    if withDetails && pr.override != nil {
+        override := *pr.override
+        result.MergeOverride = &override
+    }
+
+    return result
+}
+
+// ListPullRequests возвращает страницу PR по фильтру; сортировка всегда дополняется pull_request_id,
+// чтобы keyset-курсор был однозначным
+func (r *PullRequestRepository) ListPullRequests(ctx context.Context, filter internal_models.PullRequestFilter) ([]models.PullRequest, error) {
+    sortKey, ok := pullRequestSortKeys[filter.SortBy]
+    if !ok {
+        return nil, fmt.Errorf("unsupported sort column %q", filter.SortBy)
+    }
+
+    var after time.Time
+    if filter.After != nil && filter.SortBy != "pull_request_id" && filter.After.Value != "-infinity" {
+        parsed, err := time.Parse(time.RFC3339Nano, filter.After.Value)
+        if err != nil {
+            return nil, err
+        }
+        after = parsed
+    }
+
+    defer r.store.lock(ctx)()
+
+    statuses := map[string]bool{}
+    for _, status := range filter.Statuses {
+        statuses[status] = true
+    }
+
+    // less сравнивает PR в порядке выдачи с учётом направления
+    less := func(aKey time.Time, aId string, bKey time.Time, bId string) bool {
+        if filter.SortBy != "pull_request_id" && !aKey.Equal(bKey) {
+            return aKey.Before(bKey) != filter.Desc
+        }
+        return (aId < bId) != filter.Desc
+    }
+
+    type entry struct {
+        key time.Time
+        pr  *pullRequestRecord
+    }
+    entries := []entry{}
+    for _, pr := range r.store.pullRequests {
+        if len(statuses) > 0 && !statuses[pr.status] {
+            continue
+        }
+        if filter.AuthorID != "" && pr.authorId != filter.AuthorID {
+            continue
+        }
+        if filter.ReviewerID != "" && findReviewer(pr, filter.ReviewerID) == nil {
+            continue
+        }
+        if filter.TeamName != "" && r.store.teamName(r.store.prTeamId(pr)) != filter.TeamName {
+            continue
+        }
+        if !inRange(&pr.createdAt, filter.CreatedFrom, filter.CreatedTo) || !inRange(pr.mergedAt, filter.MergedFrom, filter.MergedTo) {
+            continue
+        }
+
+        key := sortKey(pr)
+        if filter.After != nil && !less(after, filter.After.ID, key, pr.id) {
+            continue
+        }
+        entries = append(entries, entry{key: key, pr: pr})
+    }
+
+    sort.Slice(entries, func(i, j int) bool {
+        return less(entries[i].key, entries[i].pr.id, entries[j].key, entries[j].pr.id)
+    })
+    if len(entries) > filter.Limit {
+        entries = entries[:filter.Limit]
+    }
+
+    prs := make([]models.PullRequest, 0, len(entries))
+    for _, e := range entries {
+        prs = append(prs, r.store.pullRequestModel(e.pr, false))
+    }
+
+    return prs, nil
+}
+
+// ключи сортировки; отсутствующее время (аналог '-infinity' в postgres) - нулевое time.Time
+var pullRequestSortKeys = map[string]func(pr *pullRequestRecord) time.Time{
+    "created_at": func(pr *pullRequestRecord) time.Time { return pr.createdAt },
+    "merged_at": func(pr *pullRequestRecord) time.Time {
+        if pr.mergedAt == nil {
+            return time.Time{}
+        }
+        return *pr.mergedAt
+    },
+    "pull_request_id": func(pr *pullRequestRecord) time.Time { return time.Time{} },
+}
+
+// inRange проверяет from <= at <= to; при заданных границах отсутствующее время не подходит, как NULL в SQL
+func inRange(at *time.Time, from, to *time.Time) bool {
+    if from == nil && to == nil {
+        return true
+    }
+    if at == nil {
+        return false
+    }
+    if from != nil && at.Before(*from) {
+        return false
+    }
+    if to != nil && at.After(*to) {
+        return false
+    }
+    return true
+}
+
+func (r *PullRequestRepository) UpdateStatus(ctx context.Context, prID, from, to string, at time.Time) error {
+    defer r.store.lock(ctx)()
+
+    pr, ok := r.store.pullRequests[prID]
+    if !ok || pr.status != from {
+        return fmt.Errorf("pull request is no longer %s", from)
+    }
+
+    r.store.touchPullRequest(prID)
+    pr.status = to
+    pr.closedAt = nil
+    if to == "CLOSED" {
+        closedAt := at
+        pr.closedAt = &closedAt
+    }
+
+    return nil
+}
+
+func (r *PullRequestRepository) SetMerged(ctx context.Context, prID string, mergedAt time.Time, override *models.PullRequestMergeOverride) (models.PullRequest, error) {
+    defer r.store.lock(ctx)()
+
+    pr, ok := r.store.pullRequests[prID]
+    if !ok {
+        return models.PullRequest{}, internal_models.ErrPullRequestNotFound
+    }
+
+    if pr.status == "MERGED" {
+        return r.store.pullRequestModel(pr, true), nil
+    }
+
+    r.store.touchPullRequest(prID)
+    pr.status = "MERGED"
+    pr.mergedAt = &mergedAt
+    if override != nil {
+        override.ForcedAt = &mergedAt
+        stored := *override
+        pr.override = &stored
+    }
+
+    return r.store.pullRequestModel(pr, true), nil
+}
+
+func (r *PullRequestRepository) ReplaceReviewer(ctx context.Context, prID, oldReviewer string, newReviewer internal_models.SelectedReviewer) error {
+    defer r.store.lock(ctx)()
+
+    pr, ok := r.store.pullRequests[prID]
+    if !ok {
+        return errors.New("reviewer is not assigned to this PR")
+    }
+    rev := findReviewer(pr, oldReviewer)
+    if rev == nil {
+        return errors.New("reviewer is not assigned to this PR")
+    }
+    if newReviewer.UserID != oldReviewer && findReviewer(pr, newReviewer.UserID) != nil {
+        return fmt.Errorf("reviewer %s is already assigned to this PR", newReviewer.UserID)
+    }
+
+    r.store.touchPullRequest(prID)
+    *rev = *newReviewerRecord(newReviewer, now())
+    return nil
+}
+
+func (r *PullRequestRepository) SubmitReview(ctx context.Context, prID, reviewerID, verdict, comment string, submittedAt time.Time) error {
+    defer r.store.lock(ctx)()
+
+    pr, ok := r.store.pullRequests[prID]
+    if !ok {
+        return errors.New("reviewer is not assigned to this PR")
+    }
+    rev := findReviewer(pr, reviewerID)
+    if rev == nil {
+        return errors.New("reviewer is not assigned to this PR")
+    }
+
+    r.store.touchPullRequest(prID)
+    rev.verdict = verdict
+    rev.comment = comment
+    rev.verdictAt = &submittedAt
+
+    return nil
+}
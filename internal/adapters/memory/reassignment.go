@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+// reassignOpenReviews - аналог postgres.reassignOpenReviews: переназначает OPEN-ревью пользователей userIds
+// во всех PR или, если prIds не nil, только в перечисленных, подбирая замены из пулов команды PR.
+// Вызывается под s.mu
+func (s *Store) reassignOpenReviews(userIds []string, prIds []string, plan internal_models.ReassignmentPlanner, actorId, reason string) []internal_models.ReviewerReassignment {
+    users := make(map[string]bool, len(userIds))
+    for _, id := range userIds {
+        users[id] = true
+    }
+    var onlyPRs map[string]bool
+    if prIds != nil {
+        onlyPRs = make(map[string]bool, len(prIds))
+        for _, id := range prIds {
+            onlyPRs[id] = true
+        }
+    }
+
+    snapshot := internal_models.ReassignmentSnapshot{
+        Pools:      map[int][]internal_models.ReviewerPool{},
+        Candidates: map[int][]internal_models.ReviewerCandidate{},
+    }
+    for _, pr := range s.pullRequests {
+        if pr.status != "OPEN" || (onlyPRs != nil && !onlyPRs[pr.id]) {
+            continue
+        }
+
+        reviewers := make([]string, 0, len(pr.reviewers))
+        for _, rev := range pr.reviewers {
+            if rev.userId != "" {
+                reviewers = append(reviewers, rev.userId)
+            }
+        }
+
+        teamId := s.prTeamId(pr)
+        for _, rev := range pr.reviewers {
+            if !users[rev.userId] {
+                continue
+            }
+            snapshot.Assignments = append(snapshot.Assignments, internal_models.OpenReviewAssignment{
+                PullRequestID: pr.id,
+                ReviewerID:    rev.userId,
+                AuthorID:      pr.authorId,
+                TeamID:        teamId,
+                Reviewers:     reviewers,
+            })
+            if _, seen := snapshot.Pools[teamId]; !seen && teamId != 0 {
+                snapshot.Pools[teamId] = s.reviewerPools(teamId)
+                for _, pool := range snapshot.Pools[teamId] {
+                    if _, loaded := snapshot.Candidates[pool.TeamID]; !loaded {
+                        snapshot.Candidates[pool.TeamID] = s.candidates(pool.TeamID, nil)
+                    }
+                }
+            }
+        }
+    }
+
+    if len(snapshot.Assignments) == 0 {
+        return []internal_models.ReviewerReassignment{}
+    }
+    sort.Slice(snapshot.Assignments, func(i, j int) bool {
+        a, b := snapshot.Assignments[i], snapshot.Assignments[j]
+        if a.PullRequestID != b.PullRequestID {
+            return a.PullRequestID < b.PullRequestID
+        }
+        return a.ReviewerID < b.ReviewerID
+    })
+
+    decisions := plan(snapshot)
+
+    assignedAt := now()
+    events := make([]models.PullRequestEvent, 0, len(decisions))
+    for _, d := range decisions {
+        s.touchPullRequest(d.PullRequestID)
+        pr := s.pullRequests[d.PullRequestID]
+
+        if d.NewReviewerID == "" {
+            for i, rev := range pr.reviewers {
+                if rev.userId == d.OldReviewerID {
+                    pr.reviewers = append(pr.reviewers[:i], pr.reviewers[i+1:]...)
+                    break
+                }
+            }
+            events = append(events, models.PullRequestEvent{
+                PullRequestId: d.PullRequestID,
+                Type:          "unassigned",
+                ActorId:       actorId,
+                ReviewerId:    d.OldReviewerID,
+                Reason:        reason,
+            })
+            continue
+        }
+
+        if rev := findReviewer(pr, d.OldReviewerID); rev != nil {
+            *rev = reviewerRecord{userId: d.NewReviewerID, assignedAt: assignedAt}
+            if d.Source != "" && d.Source != internal_models.ReviewerSourceTeam {
+                rev.source = d.Source
+                rev.sourceTeamId = d.SourceTeamID
+            }
+        }
+        events = append(events, models.PullRequestEvent{
+            PullRequestId: d.PullRequestID,
+            Type:          "reassigned",
+            ActorId:       actorId,
+            OldReviewerId: d.OldReviewerID,
+            NewReviewerId: d.NewReviewerID,
+            Reason:        reason,
+        })
+    }
+
+    s.addEvents(events)
+
+    return decisions
+}
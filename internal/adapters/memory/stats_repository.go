@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+)
+
+type StatsRepository struct {
+	store *Store
+}
+
+func NewStatsRepository(store *Store) *StatsRepository {
+	return &StatsRepository{store: store}
+}
+
+// все выборки ограничены PR, созданными в [from, to]; nil - без ограничения
+
+// pullRequestsCreatedIn возвращает PR, созданные в [from, to], по возрастанию pull_request_id
+func (s *Store) pullRequestsCreatedIn(from, to *time.Time) []*pullRequestRecord {
+    prs := []*pullRequestRecord{}
+    for _, pr := range s.pullRequests {
+        if inRange(&pr.createdAt, from, to) {
+            prs = append(prs, pr)
+        }
+    }
+    sort.Slice(prs, func(i, j int) bool { return prs[i].id < prs[j].id })
+    return prs
+}
+
+func (r *StatsRepository) GetReviewerStats(ctx context.Context, from, to *time.Time) ([]models.ReviewerStats, error) {
+    defer r.store.lock(ctx)()
+
+    byReviewer := map[string]*models.ReviewerStats{}
+    for _, pr := range r.store.pullRequestsCreatedIn(from, to) {
+        for _, rev := range pr.reviewers {
+            if rev.userId == "" {
+                continue
+            }
+            s, ok := byReviewer[rev.userId]
+            if !ok {
+                s = &models.ReviewerStats{UserId: rev.userId}
+                byReviewer[rev.userId] = s
+            }
+            s.Assigned++
+            switch pr.status {
+            case "OPEN":
+                s.Open++
+            case "MERGED":
+                s.Merged++
+            }
+        }
+    }
+
+    stats := make([]models.ReviewerStats, 0, len(byReviewer))
+    for _, s := range byReviewer {
+        stats = append(stats, *s)
+    }
+    sort.Slice(stats, func(i, j int) bool { return stats[i].UserId < stats[j].UserId })
+
+    return stats, nil
+}
+
+func (r *StatsRepository) GetTeamStats(ctx context.Context, from, to *time.Time) ([]models.TeamStats, error) {
+    defer r.store.lock(ctx)()
+
+    // в статистику попадают все команды, в том числе без PR
+    byTeam := map[int]*models.TeamStats{}
+    for id, t := range r.store.teams {
+        byTeam[id] = &models.TeamStats{TeamName: t.name}
+    }
+    for _, pr := range r.store.pullRequestsCreatedIn(from, to) {
+        s, ok := byTeam[r.store.prTeamId(pr)]
+        if !ok {
+            continue
+        }
+        s.PullRequests++
+        switch pr.status {
+        case "OPEN":
+            s.Open++
+        case "MERGED":
+            s.Merged++
+        }
+        s.Assignments += len(pr.reviewers)
+    }
+
+    stats := make([]models.TeamStats, 0, len(byTeam))
+    for _, s := range byTeam {
+        stats = append(stats, *s)
+    }
+    sort.Slice(stats, func(i, j int) bool { return stats[i].TeamName < stats[j].TeamName })
+
+    return stats, nil
+}
+
+func (r *StatsRepository) GetAuthorStats(ctx context.Context, from, to *time.Time) ([]models.AuthorStats, error) {
+    defer r.store.lock(ctx)()
+
+    byAuthor := map[string]*models.AuthorStats{}
+    for _, pr := range r.store.pullRequestsCreatedIn(from, to) {
+        if pr.authorId == "" {
+            continue
+        }
+        s, ok := byAuthor[pr.authorId]
+        if !ok {
+            s = &models.AuthorStats{UserId: pr.authorId}
+            byAuthor[pr.authorId] = s
+        }
+        s.PullRequests++
+        switch pr.status {
+        case "OPEN":
+            s.Open++
+        case "MERGED":
+            s.Merged++
+        }
+    }
+
+    stats := make([]models.AuthorStats, 0, len(byAuthor))
+    for _, s := range byAuthor {
+        stats = append(stats, *s)
+    }
+    sort.Slice(stats, func(i, j int) bool { return stats[i].UserId < stats[j].UserId })
+
+    return stats, nil
+}
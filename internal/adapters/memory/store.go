@@ -0,0 +1,334 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+// Store - общее состояние in-memory хранилища. Все репозитории работают с ним под одним мьютексом,
+// поэтому каждый их метод атомарен так же, как транзакция в адаптере postgres.
+// Методы, которые могут завершиться ошибкой, сначала всё проверяют и только потом меняют состояние.
+type Store struct {
+    mu sync.Mutex
+
+    teams      map[int]*teamRecord
+    nextTeamId int
+
+    users   map[string]*userRecord
+    members map[int]map[string]bool // team_id -> участники команды
+
+    pullRequests map[string]*pullRequestRecord
+
+    events      []models.PullRequestEvent
+    nextEventId int64
+
+    subscriptions      map[int64]*subscriptionRecord
+    nextSubscriptionId int64
+    deliveries         []*deliveryRecord // по возрастанию id
+    nextDeliveryId     int64
+
+    vcsAccounts   map[vcsKey]string // (provider, login) -> user_id
+    vcsDeliveries map[vcsKey]*vcsDeliveryRecord
+
+    undo *undoLog // журнал отката текущего UnitOfWork, nil вне его
+}
+
+type teamRecord struct {
+    id                int
+    name              string
+    strategy          string
+    minReviewers      int
+    maxReviewers      int
+    requiredApprovals int
+    parentId          int   // 0 - нет вышестоящей команды
+    fallbacks         []int // запасные команды в порядке опроса
+}
+
+type userRecord struct {
+    id           string
+    username     string
+    isActive     bool
+    teamId       int // основная команда, 0 - без команды
+    reviewWeight int
+}
+
+type pullRequestRecord struct {
+    id        string
+    name      string
+    authorId  string // пусто - автор удалён
+    teamId    int    // 0 - основная команда автора
+    status    string
+    createdAt time.Time
+    mergedAt  *time.Time
+    closedAt  *time.Time
+    override  *models.PullRequestMergeOverride
+    reviewers []*reviewerRecord
+}
+
+type subscriptionRecord struct {
+    id        int64
+    url       string
+    secret    string
+    events    []string
+    createdAt time.Time
+}
+
+type deliveryRecord struct {
+    id             int64
+    subscriptionId int64
+    event          string
+    payload        []byte
+    status         string
+    attempts       int
+    nextAttemptAt  time.Time
+    lastAttemptAt  *time.Time
+    responseStatus *int
+    lastError      string
+    deliveredAt    *time.Time
+    redeliveryOf   *int64
+    createdAt      time.Time
+}
+
+// vcsKey - логин или id доставки в пределах внешней системы
+type vcsKey struct {
+    provider string
+    id       string
+}
+
+type vcsDeliveryRecord struct {
+    event      string
+    status     string
+    result     string
+    receivedAt time.Time
+    finishedAt *time.Time
+}
+
+type reviewerRecord struct {
+    userId       string
+    assignedAt   time.Time
+    verdict      string
+    comment      string
+    verdictAt    *time.Time
+    source       string // пусто - из команды PR
+    sourceTeamId int
+}
+
+func NewStore() *Store {
+    return &Store{
+        teams:         map[int]*teamRecord{},
+        users:         map[string]*userRecord{},
+        members:       map[int]map[string]bool{},
+        pullRequests:  map[string]*pullRequestRecord{},
+        subscriptions: map[int64]*subscriptionRecord{},
+        vcsAccounts:   map[vcsKey]string{},
+        vcsDeliveries: map[vcsKey]*vcsDeliveryRecord{},
+    }
+}
+
+// now возвращает текущее время с точностью postgres, чтобы курсоры вели себя одинаково в обоих хранилищах
+func now() time.Time {
+    return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func (s *Store) teamByName(teamName string) (*teamRecord, error) {
+    for _, t := range s.teams {
+        if t.name == teamName {
+            return t, nil
+        }
+    }
+    return nil, internal_models.ErrTeamNotFound
+}
+
+func (s *Store) teamName(teamId int) string {
+    if t, ok := s.teams[teamId]; ok {
+        return t.name
+    }
+    return ""
+}
+
+func (s *Store) isMember(teamId int, userId string) bool {
+    return s.members[teamId][userId]
+}
+
+func (s *Store) addMember(teamId int, userId string) {
+    s.touchMembers(teamId)
+    if s.members[teamId] == nil {
+        s.members[teamId] = map[string]bool{}
+    }
+    s.members[teamId][userId] = true
+}
+
+// userTeams возвращает команды пользователя по возрастанию team_id
+func (s *Store) userTeams(userId string) []int {
+    teamIds := []int{}
+    for teamId, members := range s.members {
+        if members[userId] {
+            teamIds = append(teamIds, teamId)
+        }
+    }
+    sort.Ints(teamIds)
+    return teamIds
+}
+
+// teamMembers возвращает участников команды по возрастанию user_id
+func (s *Store) teamMembers(teamId int) []string {
+    userIds := []string{}
+    for userId := range s.members[teamId] {
+        userIds = append(userIds, userId)
+    }
+    sort.Strings(userIds)
+    return userIds
+}
+
+// upsertMember создаёт или обновляет пользователя и добавляет его в команду;
+// основная команда меняется, только если её ещё не было
+func (s *Store) upsertMember(teamId int, m models.TeamMember) {
+    s.touchUser(m.UserId)
+    u, ok := s.users[m.UserId]
+    if !ok {
+        u = &userRecord{id: m.UserId}
+        s.users[m.UserId] = u
+    }
+    u.username = m.Username
+    u.isActive = m.IsActive
+    u.reviewWeight = m.ReviewWeight
+    if u.teamId == 0 {
+        u.teamId = teamId
+    }
+
+    s.addMember(teamId, m.UserId)
+}
+
+// leaveTeam исключает пользователя из команды; если она была основной, основной становится другая его команда
+func (s *Store) leaveTeam(teamId int, userId string) {
+    s.touchMembers(teamId)
+    s.touchUser(userId)
+    delete(s.members[teamId], userId)
+
+    if u, ok := s.users[userId]; ok && u.teamId == teamId {
+        u.teamId = 0
+        if teamIds := s.userTeams(userId); len(teamIds) > 0 {
+            u.teamId = teamIds[0]
+        }
+    }
+}
+
+// prTeamId - команда, из которой назначаются ревьюверы PR: явно указанная или основная команда автора (0 - нет)
+func (s *Store) prTeamId(pr *pullRequestRecord) int {
+    if pr.teamId != 0 {
+        return pr.teamId
+    }
+    if author, ok := s.users[pr.authorId]; ok {
+        return author.teamId
+    }
+    return 0
+}
+
+// teamOpenPullRequests возвращает DRAFT/OPEN PR, ревьюверы которых назначаются из команды teamId
+func (s *Store) teamOpenPullRequests(teamId int) []string {
+    prIds := []string{}
+    for _, pr := range s.pullRequests {
+        if s.prTeamId(pr) == teamId && (pr.status == "DRAFT" || pr.status == "OPEN") {
+            prIds = append(prIds, pr.id)
+        }
+    }
+    sort.Strings(prIds)
+    return prIds
+}
+
+// sortedReviewers возвращает ревьюверов PR в порядке назначения
+func sortedReviewers(pr *pullRequestRecord) []*reviewerRecord {
+    reviewers := append([]*reviewerRecord(nil), pr.reviewers...)
+    sort.SliceStable(reviewers, func(i, j int) bool {
+        if !reviewers[i].assignedAt.Equal(reviewers[j].assignedAt) {
+            return reviewers[i].assignedAt.Before(reviewers[j].assignedAt)
+        }
+        return reviewers[i].userId < reviewers[j].userId
+    })
+    return reviewers
+}
+
+func findReviewer(pr *pullRequestRecord, userId string) *reviewerRecord {
+    for _, rev := range pr.reviewers {
+        if rev.userId == userId {
+            return rev
+        }
+    }
+    return nil
+}
+
+// newReviewerRecord - новое назначение; source и команда хранятся только для ревьюверов не из команды PR
+func newReviewerRecord(r internal_models.SelectedReviewer, assignedAt time.Time) *reviewerRecord {
+    rev := &reviewerRecord{userId: r.UserID, assignedAt: assignedAt}
+    if r.Source != "" && r.Source != internal_models.ReviewerSourceTeam {
+        rev.source = r.Source
+        rev.sourceTeamId = r.TeamID
+    }
+    return rev
+}
+
+// candidates возвращает активных участников команды, кроме exclude, с их текущей загрузкой.
+// Загрузка считается за один проход по PR, а не по проходу на участника
+func (s *Store) candidates(teamId int, exclude []string) []internal_models.ReviewerCandidate {
+    excluded := make(map[string]bool, len(exclude))
+    for _, id := range exclude {
+        excluded[id] = true
+    }
+
+    var candidates []internal_models.ReviewerCandidate
+    index := map[string]int{}
+    for _, userId := range s.teamMembers(teamId) {
+        u := s.users[userId]
+        if !u.isActive || excluded[userId] {
+            continue
+        }
+        index[userId] = len(candidates)
+        candidates = append(candidates, internal_models.ReviewerCandidate{UserID: userId, Weight: u.reviewWeight})
+    }
+    if len(candidates) == 0 {
+        return candidates
+    }
+
+    for _, pr := range s.pullRequests {
+        for _, rev := range pr.reviewers {
+            i, ok := index[rev.userId]
+            if !ok {
+                continue
+            }
+            c := &candidates[i]
+            if pr.status == "OPEN" {
+                c.OpenReviews++
+            }
+            if c.LastAssignedAt == nil || rev.assignedAt.After(*c.LastAssignedAt) {
+                assignedAt := rev.assignedAt
+                c.LastAssignedAt = &assignedAt
+            }
+        }
+    }
+
+    return candidates
+}
+
+func (s *Store) settings(t *teamRecord) internal_models.TeamAssignmentSettings {
+    return internal_models.TeamAssignmentSettings{
+        Strategy:          t.strategy,
+        MinReviewers:      t.minReviewers,
+        MaxReviewers:      t.maxReviewers,
+        RequiredApprovals: t.requiredApprovals,
+    }
+}
+
+func (s *Store) addEvents(events []models.PullRequestEvent) {
+    createdAt := now()
+    for _, e := range events {
+        s.nextEventId++
+        e.Id = s.nextEventId
+        at := createdAt
+        e.CreatedAt = &at
+        s.events = append(s.events, e)
+    }
+}
@@ -0,0 +1,428 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	api_models "github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+type TeamRepository struct {
+	store *Store
+}
+
+func NewTeamRepository(store *Store) *TeamRepository {
+	return &TeamRepository{store: store}
+}
+
+func (r *TeamRepository) IsTeamExists(ctx context.Context, teamName string) (bool, error) {
+    defer r.store.lock(ctx)()
+
+    _, err := r.store.teamByName(teamName)
+    return err == nil, nil
+}
+
+func (r *TeamRepository) CreateTeam(ctx context.Context, team api_models.Team) (api_models.Team, error) {
+    defer r.store.lock(ctx)()
+
+    if _, err := r.store.teamByName(team.TeamName); err == nil {
+        return api_models.Team{}, fmt.Errorf("team %s already exists", team.TeamName)
+    }
+
+    // новая команда ещё не существует, поэтому ссылка на себя проверяется по имени
+    const newTeamId = -1
+    resolve := func(name string) (int, error) {
+        if name == team.TeamName {
+            return newTeamId, nil
+        }
+        t, err := r.store.teamByName(name)
+        if err != nil {
+            return 0, err
+        }
+        return t.id, nil
+    }
+
+    parentId := 0
+    if team.ParentTeamName != "" {
+        id, err := resolve(team.ParentTeamName)
+        if err != nil {
+            return api_models.Team{}, fmt.Errorf("%w: parent team %s", err, team.ParentTeamName)
+        }
+        if id == newTeamId {
+            return api_models.Team{}, internal_models.ErrTeamHierarchyCycle
+        }
+        parentId = id
+    }
+    fallbacks, err := r.store.resolveFallbacks(newTeamId, team.FallbackTeamNames, resolve)
+    if err != nil {
+        return api_models.Team{}, err
+    }
+
+    r.store.nextTeamId++
+    r.store.touchTeam(r.store.nextTeamId)
+    t := &teamRecord{
+        id:                r.store.nextTeamId,
+        name:              team.TeamName,
+        strategy:          team.AssignmentStrategy,
+        minReviewers:      intOrDefault(team.MinReviewers, 0),
+        maxReviewers:      intOrDefault(team.MaxReviewers, 2),
+        requiredApprovals: intOrDefault(team.RequiredApprovals, 0),
+        parentId:          parentId,
+        fallbacks:         fallbacks,
+    }
+    r.store.teams[t.id] = t
+
+    for _, m := range team.Members {
+        r.store.upsertMember(t.id, m)
+    }
+
+    return team, nil
+}
+
+func intOrDefault(v *int, def int) int {
+    if v == nil {
+        return def
+    }
+    return *v
+}
+
+// resolveFallbacks превращает имена запасных команд в team_id, сохраняя порядок и убирая повторы
+func (s *Store) resolveFallbacks(teamId int, names []string, resolve func(name string) (int, error)) ([]int, error) {
+    fallbacks := []int{}
+    seen := map[int]bool{}
+    for _, name := range names {
+        fallbackTeamId, err := resolve(name)
+        if err != nil {
+            return nil, fmt.Errorf("%w: fallback team %s", err, name)
+        }
+        if fallbackTeamId == teamId {
+            return nil, internal_models.ErrInvalidFallbackTeam
+        }
+        if !seen[fallbackTeamId] {
+            seen[fallbackTeamId] = true
+            fallbacks = append(fallbacks, fallbackTeamId)
+        }
+    }
+    return fallbacks, nil
+}
+
+func (s *Store) resolveTeam(name string) (int, error) {
+    t, err := s.teamByName(name)
+    if err != nil {
+        return 0, err
+    }
+    return t.id, nil
+}
+
+func (r *TeamRepository) GetTeamByName(ctx context.Context, teamName string) (api_models.Team, error) {
+    defer r.store.lock(ctx)()
+
+    t, err := r.store.teamByName(teamName)
+    if err != nil {
+        return api_models.Team{}, err
+    }
+
+    team := api_models.Team{
+        TeamName:           t.name,
+        AssignmentStrategy: t.strategy,
+        ParentTeamName:     r.store.teamName(t.parentId),
+        FallbackTeamNames:  []string{},
+        Members:            []api_models.TeamMember{},
+    }
+    minReviewers, maxReviewers, requiredApprovals := t.minReviewers, t.maxReviewers, t.requiredApprovals
+    team.MinReviewers, team.MaxReviewers, team.RequiredApprovals = &minReviewers, &maxReviewers, &requiredApprovals
+
+    for _, fallbackId := range t.fallbacks {
+        team.FallbackTeamNames = append(team.FallbackTeamNames, r.store.teamName(fallbackId))
+    }
+
+    for _, userId := range r.store.teamMembers(t.id) {
+        u := r.store.users[userId]
+        m := api_models.TeamMember{
+            UserId:       u.id,
+            Username:     u.username,
+            IsActive:     u.isActive,
+            ReviewWeight: u.reviewWeight,
+            PrimaryTeam:  r.store.teamName(u.teamId),
+            OtherTeams:   []string{},
+        }
+        for _, otherId := range r.store.userTeams(userId) {
+            if otherId != t.id {
+                m.OtherTeams = append(m.OtherTeams, r.store.teamName(otherId))
+            }
+        }
+        sort.Strings(m.OtherTeams)
+        team.Members = append(team.Members, m)
+    }
+
+    return team, nil
+}
+
+func (r *TeamRepository) UpdateTeamSettings(ctx context.Context, req api_models.TeamUpdateSettingsPostRequest) error {
+    defer r.store.lock(ctx)()
+
+    t, err := r.store.teamByName(req.TeamName)
+    if err != nil {
+        return err
+    }
+
+    parentId := t.parentId
+    if req.ParentTeamName != nil {
+        parentId = 0
+        if *req.ParentTeamName != "" {
+            id, err := r.store.resolveTeam(*req.ParentTeamName)
+            if err != nil {
+                return fmt.Errorf("%w: parent team %s", err, *req.ParentTeamName)
+            }
+            if r.store.isAncestorOrSelf(t.id, id) {
+                return internal_models.ErrTeamHierarchyCycle
+            }
+            parentId = id
+        }
+    }
+
+    fallbacks := t.fallbacks
+    if req.FallbackTeamNames != nil {
+        fallbacks, err = r.store.resolveFallbacks(t.id, *req.FallbackTeamNames, r.store.resolveTeam)
+        if err != nil {
+            return err
+        }
+    }
+
+    r.store.touchTeam(t.id)
+    if req.AssignmentStrategy != nil {
+        t.strategy = *req.AssignmentStrategy
+    }
+    t.minReviewers = intOrDefault(req.MinReviewers, t.minReviewers)
+    t.maxReviewers = intOrDefault(req.MaxReviewers, t.maxReviewers)
+    t.requiredApprovals = intOrDefault(req.RequiredApprovals, t.requiredApprovals)
+    t.parentId = parentId
+    t.fallbacks = fallbacks
+
+    return nil
+}
+
+// isAncestorOrSelf проверяет, что teamId - это startId или одна из его вышестоящих команд
+func (s *Store) isAncestorOrSelf(teamId int, startId int) bool {
+    visited := map[int]bool{}
+    for id := startId; id != 0 && !visited[id]; id = s.teams[id].parentId {
+        if id == teamId {
+            return true
+        }
+        visited[id] = true
+    }
+    return false
+}
+
+// AddMember добавляет пользователя в команду, создавая его при необходимости.
+// Для пользователя без команды она становится основной, иначе - дополнительной
+func (r *TeamRepository) AddMember(ctx context.Context, teamName string, member api_models.TeamMember) error {
+    defer r.store.lock(ctx)()
+
+    t, err := r.store.teamByName(teamName)
+    if err != nil {
+        return err
+    }
+    if r.store.isMember(t.id, member.UserId) {
+        return internal_models.ErrMemberExists
+    }
+
+    r.store.upsertMember(t.id, member)
+    return nil
+}
+
+// RemoveMember исключает пользователя из команды; если передан plan, его OPEN-ревью в PR этой команды
+// переназначаются на оставшихся участников
+func (r *TeamRepository) RemoveMember(ctx context.Context, teamName string, userId string, actorId string, plan internal_models.ReassignmentPlanner) ([]internal_models.ReviewerReassignment, error) {
+    defer r.store.lock(ctx)()
+
+    t, err := r.store.teamByName(teamName)
+    if err != nil {
+        return nil, err
+    }
+    if _, ok := r.store.users[userId]; !ok {
+        return nil, internal_models.ErrUserNotFound
+    }
+    if !r.store.isMember(t.id, userId) {
+        return nil, internal_models.ErrNotTeamMember
+    }
+
+    r.store.leaveTeam(t.id, userId)
+
+    decisions := []internal_models.ReviewerReassignment{}
+    if plan != nil {
+        decisions = r.store.reassignOpenReviews([]string{userId}, r.store.teamOpenPullRequests(t.id), plan, actorId, "member removed from team")
+    }
+
+    return decisions, nil
+}
+
+// MoveMember переводит пользователя из fromTeamName (по умолчанию - основной команды) в toTeamName
+// и возвращает имя прежней команды
+func (r *TeamRepository) MoveMember(ctx context.Context, userId string, fromTeamName string, toTeamName string, actorId string, plan internal_models.ReassignmentPlanner) (string, []internal_models.ReviewerReassignment, error) {
+    defer r.store.lock(ctx)()
+
+    u, ok := r.store.users[userId]
+    if !ok {
+        return "", nil, internal_models.ErrUserNotFound
+    }
+
+    var fromTeamId int
+    if fromTeamName != "" {
+        id, err := r.store.resolveTeam(fromTeamName)
+        if err != nil {
+            return "", nil, err
+        }
+        fromTeamId = id
+    } else {
+        if u.teamId == 0 {
+            return "", nil, internal_models.ErrNotTeamMember
+        }
+        fromTeamId = u.teamId
+        fromTeamName = r.store.teamName(fromTeamId)
+    }
+
+    toTeamId, err := r.store.resolveTeam(toTeamName)
+    if err != nil {
+        return "", nil, err
+    }
+    if r.store.isMember(toTeamId, userId) {
+        return "", nil, internal_models.ErrMemberExists
+    }
+    if !r.store.isMember(fromTeamId, userId) {
+        return "", nil, internal_models.ErrNotTeamMember
+    }
+
+    wasPrimary := u.teamId == fromTeamId
+    r.store.addMember(toTeamId, userId)
+    r.store.leaveTeam(fromTeamId, userId)
+    if wasPrimary {
+        r.store.touchUser(userId)
+        u.teamId = toTeamId
+    }
+
+    decisions := []internal_models.ReviewerReassignment{}
+    if plan != nil {
+        decisions = r.store.reassignOpenReviews([]string{userId}, r.store.teamOpenPullRequests(fromTeamId), plan, actorId, "member moved to another team")
+    }
+
+    return fromTeamName, decisions, nil
+}
+
+func (r *TeamRepository) RenameTeam(ctx context.Context, teamName string, newTeamName string) error {
+    defer r.store.lock(ctx)()
+
+    t, err := r.store.teamByName(teamName)
+    if err != nil {
+        return err
+    }
+    if _, err := r.store.teamByName(newTeamName); err == nil && newTeamName != teamName {
+        return internal_models.ErrTeamExists
+    }
+
+    r.store.touchTeam(t.id)
+    t.name = newTeamName
+    return nil
+}
+
+// DeleteTeam удаляет команду. Участников переводит в moveToTeamName, а если оно пустое - просто исключает;
+// при refuse непустая команда не удаляется. Если передан plan, OPEN-ревью участников в PR команды
+// переназначаются из нового пула
+func (r *TeamRepository) DeleteTeam(ctx context.Context, teamName string, refuse bool, moveToTeamName string, actorId string, plan internal_models.ReassignmentPlanner) (internal_models.TeamDeletion, error) {
+    deletion := internal_models.TeamDeletion{
+        Members:          []string{},
+        OpenPullRequests: []string{},
+        Reassignments:    []internal_models.ReviewerReassignment{},
+    }
+
+    defer r.store.lock(ctx)()
+
+    t, err := r.store.teamByName(teamName)
+    if err != nil {
+        return deletion, err
+    }
+
+    deletion.Members = r.store.teamMembers(t.id)
+    if len(deletion.Members) > 0 && refuse {
+        return deletion, internal_models.ErrTeamNotEmpty
+    }
+
+    deletion.OpenPullRequests = r.store.teamOpenPullRequests(t.id)
+
+    toTeamId := 0
+    if moveToTeamName != "" {
+        toTeamId, err = r.store.resolveTeam(moveToTeamName)
+        if err != nil {
+            return deletion, err
+        }
+        if toTeamId == t.id {
+            return deletion, internal_models.ErrMemberExists
+        }
+    }
+
+    if toTeamId != 0 {
+        for _, userId := range deletion.Members {
+            r.store.addMember(toTeamId, userId)
+            if r.store.users[userId].teamId == t.id {
+                r.store.touchUser(userId)
+                r.store.users[userId].teamId = toTeamId
+            }
+        }
+        // PR, созданные по основной команде автора, тоже явно привязываем к новой команде
+        for _, prId := range deletion.OpenPullRequests {
+            r.store.touchPullRequest(prId)
+            r.store.pullRequests[prId].teamId = toTeamId
+        }
+    }
+
+    for _, userId := range deletion.Members {
+        r.store.leaveTeam(t.id, userId)
+    }
+    r.store.deleteTeam(t.id)
+
+    if plan != nil && len(deletion.Members) > 0 {
+        deletion.Reassignments = r.store.reassignOpenReviews(deletion.Members, deletion.OpenPullRequests, plan, actorId, "team deleted")
+    }
+
+    return deletion, nil
+}
+
+// deleteTeam убирает команду и ссылки на неё, как ON DELETE SET NULL / CASCADE в postgres
+func (s *Store) deleteTeam(teamId int) {
+    s.touchTeam(teamId)
+    s.touchMembers(teamId)
+    delete(s.teams, teamId)
+    delete(s.members, teamId)
+
+    for _, t := range s.teams {
+        fallbacks := t.fallbacks[:0:0]
+        for _, id := range t.fallbacks {
+            if id != teamId {
+                fallbacks = append(fallbacks, id)
+            }
+        }
+        if t.parentId != teamId && len(fallbacks) == len(t.fallbacks) {
+            continue
+        }
+        s.touchTeam(t.id)
+        if t.parentId == teamId {
+            t.parentId = 0
+        }
+        t.fallbacks = fallbacks
+    }
+
+    for _, pr := range s.pullRequests {
+        if pr.teamId == teamId {
+            s.touchPullRequest(pr.id)
+            pr.teamId = 0
+        }
+        for _, rev := range pr.reviewers {
+            if rev.sourceTeamId == teamId {
+                s.touchPullRequest(pr.id)
+                rev.sourceTeamId = 0
+            }
+        }
+    }
+}
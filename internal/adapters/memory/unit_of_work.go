@@ -0,0 +1,272 @@
+package memory
+
+import (
+	"context"
+)
+
+type unitOfWorkKey struct{}
+
+// lock захватывает хранилище и возвращает функцию освобождения. Внутри UnitOfWork этого же
+// хранилища оно уже захвачено, и повторно блокировать его не нужно
+func (s *Store) lock(ctx context.Context) func() {
+    if ctx.Value(unitOfWorkKey{}) == s {
+        return func() {}
+    }
+    s.mu.Lock()
+    return s.mu.Unlock
+}
+
+// UnitOfWork выполняет несколько вызовов репозиториев атомарно: хранилище захватывается
+// на всё время fn, а при ошибке изменённые записи возвращаются к состоянию до начала
+type UnitOfWork struct {
+	store *Store
+}
+
+func NewUnitOfWork(store *Store) *UnitOfWork {
+	return &UnitOfWork{store: store}
+}
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+    if ctx.Value(unitOfWorkKey{}) == u.store {
+        return fn(ctx)
+    }
+
+    u.store.mu.Lock()
+    defer u.store.mu.Unlock()
+
+    u.store.undo = u.store.newUndoLog()
+    defer func() { u.store.undo = nil }()
+
+    if err := fn(context.WithValue(ctx, unitOfWorkKey{}, u.store)); err != nil {
+        u.store.rollback()
+        return err
+    }
+
+    return nil
+}
+
+// undoLog - исходные копии записей, изменённых внутри UnitOfWork. Запись копируется при первом
+// изменении (touch*), поэтому транзакция стоит столько, сколько она затронула, а не весь объём хранилища.
+// nil в журнале - записи до транзакции не было
+type undoLog struct {
+    nextTeamId         int
+    nextEventId        int64
+    nextSubscriptionId int64
+    nextDeliveryId     int64
+    events             int // события только дописываются, достаточно запомнить их число
+    deliveries         int
+    // deliveryList - копия списка доставок, если его не только дописывали (удаление подписки)
+    deliveryList []*deliveryRecord
+
+    teams          map[int]*teamRecord
+    users          map[string]*userRecord
+    members        map[int]map[string]bool
+    pullRequests   map[string]*pullRequestRecord
+    subscriptions  map[int64]*subscriptionRecord
+    deliveryValues map[*deliveryRecord]deliveryRecord
+    vcsAccounts    map[vcsKey]*string
+    vcsDeliveries  map[vcsKey]*vcsDeliveryRecord
+}
+
+func (s *Store) newUndoLog() *undoLog {
+    return &undoLog{
+        nextTeamId:         s.nextTeamId,
+        nextEventId:        s.nextEventId,
+        nextSubscriptionId: s.nextSubscriptionId,
+        nextDeliveryId:     s.nextDeliveryId,
+        events:             len(s.events),
+        deliveries:         len(s.deliveries),
+        teams:              map[int]*teamRecord{},
+        users:              map[string]*userRecord{},
+        members:            map[int]map[string]bool{},
+        pullRequests:       map[string]*pullRequestRecord{},
+        subscriptions:      map[int64]*subscriptionRecord{},
+        deliveryValues:     map[*deliveryRecord]deliveryRecord{},
+        vcsAccounts:        map[vcsKey]*string{},
+        vcsDeliveries:      map[vcsKey]*vcsDeliveryRecord{},
+    }
+}
+
+// touch* запоминают запись перед изменением (в том числе созданием и удалением); вне UnitOfWork ничего не делают
+
+func (s *Store) touchTeam(teamId int) {
+    if s.undo == nil {
+        return
+    }
+    if _, ok := s.undo.teams[teamId]; ok {
+        return
+    }
+    var saved *teamRecord
+    if t, ok := s.teams[teamId]; ok {
+        copied := *t
+        copied.fallbacks = append([]int(nil), t.fallbacks...)
+        saved = &copied
+    }
+    s.undo.teams[teamId] = saved
+}
+
+func (s *Store) touchUser(userId string) {
+    if s.undo == nil {
+        return
+    }
+    if _, ok := s.undo.users[userId]; ok {
+        return
+    }
+    var saved *userRecord
+    if u, ok := s.users[userId]; ok {
+        copied := *u
+        saved = &copied
+    }
+    s.undo.users[userId] = saved
+}
+
+func (s *Store) touchMembers(teamId int) {
+    if s.undo == nil {
+        return
+    }
+    if _, ok := s.undo.members[teamId]; ok {
+        return
+    }
+    var saved map[string]bool
+    if members, ok := s.members[teamId]; ok {
+        saved = make(map[string]bool, len(members))
+        for userId := range members {
+            saved[userId] = true
+        }
+    }
+    s.undo.members[teamId] = saved
+}
+
+func (s *Store) touchPullRequest(prId string) {
+    if s.undo == nil {
+        return
+    }
+    if _, ok := s.undo.pullRequests[prId]; ok {
+        return
+    }
+    var saved *pullRequestRecord
+    if pr, ok := s.pullRequests[prId]; ok {
+        copied := *pr
+        copied.reviewers = make([]*reviewerRecord, 0, len(pr.reviewers))
+        for _, rev := range pr.reviewers {
+            revCopy := *rev
+            copied.reviewers = append(copied.reviewers, &revCopy)
+        }
+        if pr.override != nil {
+            override := *pr.override
+            copied.override = &override
+        }
+        saved = &copied
+    }
+    s.undo.pullRequests[prId] = saved
+}
+
+func (s *Store) touchSubscription(subscriptionId int64) {
+    if s.undo == nil {
+        return
+    }
+    if _, ok := s.undo.subscriptions[subscriptionId]; ok {
+        return
+    }
+    var saved *subscriptionRecord
+    if sub, ok := s.subscriptions[subscriptionId]; ok {
+        copied := *sub
+        saved = &copied
+    }
+    s.undo.subscriptions[subscriptionId] = saved
+}
+
+func (s *Store) touchDelivery(d *deliveryRecord) {
+    if s.undo == nil {
+        return
+    }
+    if _, ok := s.undo.deliveryValues[d]; !ok {
+        s.undo.deliveryValues[d] = *d
+    }
+}
+
+// touchDeliveryList нужен перед тем, как список доставок переписывается, а не только дописывается
+func (s *Store) touchDeliveryList() {
+    if s.undo == nil || s.undo.deliveryList != nil {
+        return
+    }
+    s.undo.deliveryList = append([]*deliveryRecord{}, s.deliveries[:s.undo.deliveries]...)
+}
+
+func (s *Store) touchVcsAccount(key vcsKey) {
+    if s.undo == nil {
+        return
+    }
+    if _, ok := s.undo.vcsAccounts[key]; ok {
+        return
+    }
+    var saved *string
+    if userId, ok := s.vcsAccounts[key]; ok {
+        saved = &userId
+    }
+    s.undo.vcsAccounts[key] = saved
+}
+
+func (s *Store) touchVcsDelivery(key vcsKey) {
+    if s.undo == nil {
+        return
+    }
+    if _, ok := s.undo.vcsDeliveries[key]; ok {
+        return
+    }
+    var saved *vcsDeliveryRecord
+    if d, ok := s.vcsDeliveries[key]; ok {
+        copied := *d
+        saved = &copied
+    }
+    s.undo.vcsDeliveries[key] = saved
+}
+
+// rollback возвращает записи из журнала UnitOfWork
+func (s *Store) rollback() {
+    log := s.undo
+
+    s.nextTeamId = log.nextTeamId
+    s.nextEventId = log.nextEventId
+    s.nextSubscriptionId = log.nextSubscriptionId
+    s.nextDeliveryId = log.nextDeliveryId
+    s.events = s.events[:log.events]
+    if log.deliveryList != nil {
+        s.deliveries = log.deliveryList
+    } else {
+        s.deliveries = s.deliveries[:log.deliveries]
+    }
+
+    restore(s.teams, log.teams)
+    restore(s.users, log.users)
+    restore(s.pullRequests, log.pullRequests)
+    restore(s.subscriptions, log.subscriptions)
+    restore(s.vcsDeliveries, log.vcsDeliveries)
+    for teamId, members := range log.members {
+        if members == nil {
+            delete(s.members, teamId)
+        } else {
+            s.members[teamId] = members
+        }
+    }
+    for key, userId := range log.vcsAccounts {
+        if userId == nil {
+            delete(s.vcsAccounts, key)
+        } else {
+            s.vcsAccounts[key] = *userId
+        }
+    }
+    for d, saved := range log.deliveryValues {
+        *d = saved
+    }
+}
+
+func restore[K comparable, V any](records map[K]*V, saved map[K]*V) {
+    for key, record := range saved {
+        if record == nil {
+            delete(records, key)
+        } else {
+            records[key] = record
+        }
+    }
+}
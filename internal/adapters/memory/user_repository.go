@@ -0,0 +1,288 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+type UserRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{store: store}
+}
+
+func (r *UserRepository) GetReviews(ctx context.Context, userId string) ([]models.PullRequestShort, error) {
+    defer r.store.lock(ctx)()
+
+    var reviews []models.PullRequestShort
+    for _, pr := range r.store.pullRequests {
+        rev := findReviewer(pr, userId)
+        if rev == nil {
+            continue
+        }
+        reviews = append(reviews, models.PullRequestShort{
+            PullRequestId:   pr.id,
+            PullRequestName: pr.name,
+            AuthorId:        pr.authorId,
+            Status:          pr.status,
+            Verdict:         rev.verdict,
+            VerdictAt:       rev.verdictAt,
+        })
+    }
+    sort.Slice(reviews, func(i, j int) bool { return reviews[i].PullRequestId < reviews[j].PullRequestId })
+
+    return reviews, nil
+}
+
+func (r *UserRepository) SetIsActivePost(ctx context.Context, usersSetIsActiveRequest models.UsersSetIsActivePostRequest) (models.User, error) {
+    defer r.store.lock(ctx)()
+
+    u, ok := r.store.users[usersSetIsActiveRequest.UserId]
+    if !ok {
+        return models.User{}, internal_models.ErrUserNotFound
+    }
+    r.store.touchUser(u.id)
+    u.isActive = usersSetIsActiveRequest.IsActive
+
+    return r.store.userModel(u), nil
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, userId string) (models.User, error) {
+    defer r.store.lock(ctx)()
+
+    u, ok := r.store.users[userId]
+    if !ok {
+        return models.User{}, internal_models.ErrUserNotFound
+    }
+
+    return r.store.userModel(u), nil
+}
+
+// userModel - пользователь с названием основной команды и всеми его командами
+func (s *Store) userModel(u *userRecord) models.User {
+    user := models.User{
+        UserId:       u.id,
+        Username:     u.username,
+        TeamName:     s.teamName(u.teamId),
+        IsActive:     u.isActive,
+        ReviewWeight: u.reviewWeight,
+        Teams:        []string{},
+    }
+    for _, teamId := range s.userTeams(u.id) {
+        user.Teams = append(user.Teams, s.teamName(teamId))
+    }
+    sort.Strings(user.Teams)
+
+    return user
+}
+
+// ListUsers возвращает пользователей по фильтру, отсортированных по user_id
+func (r *UserRepository) ListUsers(ctx context.Context, filter internal_models.UserFilter) ([]models.User, error) {
+    defer r.store.lock(ctx)()
+
+    teamId := 0
+    if filter.TeamName != "" {
+        id, err := r.store.resolveTeam(filter.TeamName)
+        if err != nil {
+            return nil, err
+        }
+        teamId = id
+    }
+    prefix := strings.ToLower(filter.NamePrefix)
+
+    userIds := []string{}
+    for userId, u := range r.store.users {
+        if teamId != 0 && !r.store.isMember(teamId, userId) {
+            continue
+        }
+        if filter.IsActive != nil && u.isActive != *filter.IsActive {
+            continue
+        }
+        if !strings.HasPrefix(strings.ToLower(u.username), prefix) {
+            continue
+        }
+        if filter.After != "" && userId <= filter.After {
+            continue
+        }
+        userIds = append(userIds, userId)
+    }
+    sort.Strings(userIds)
+    if len(userIds) > filter.Limit {
+        userIds = userIds[:filter.Limit]
+    }
+
+    users := make([]models.User, 0, len(userIds))
+    for _, userId := range userIds {
+        users = append(users, r.store.userModel(r.store.users[userId]))
+    }
+
+    return users, nil
+}
+
+// UpdateUser меняет переданные (не nil) поля пользователя; основной командой
+// можно сделать только команду, в которой он уже состоит
+func (r *UserRepository) UpdateUser(ctx context.Context, req models.UsersUpdatePostRequest) (models.User, error) {
+    defer r.store.lock(ctx)()
+
+    u, ok := r.store.users[req.UserId]
+    if !ok {
+        return models.User{}, internal_models.ErrUserNotFound
+    }
+
+    teamId := u.teamId
+    if req.TeamName != nil {
+        id, err := r.store.resolveTeam(*req.TeamName)
+        if err != nil {
+            return models.User{}, err
+        }
+        if !r.store.isMember(id, req.UserId) {
+            return models.User{}, internal_models.ErrNotTeamMember
+        }
+        teamId = id
+    }
+
+    r.store.touchUser(u.id)
+    if req.Username != nil {
+        u.username = *req.Username
+    }
+    if req.IsActive != nil {
+        u.isActive = *req.IsActive
+    }
+    u.reviewWeight = intOrDefault(req.ReviewWeight, u.reviewWeight)
+    u.teamId = teamId
+
+    return r.store.userModel(u), nil
+}
+
+// DeleteUser удаляет пользователя. Его DRAFT/OPEN PR либо закрываются (closeAuthored),
+// либо удаление отклоняется с ErrUserHasOpenPullRequests; OPEN-ревью переназначаются.
+// У оставшихся PR автор обнуляется, а команда PR фиксируется
+func (r *UserRepository) DeleteUser(ctx context.Context, userId string, closeAuthored bool, actorId string, plan internal_models.ReassignmentPlanner) ([]string, []internal_models.ReviewerReassignment, error) {
+    defer r.store.lock(ctx)()
+
+    u, ok := r.store.users[userId]
+    if !ok {
+        return nil, nil, internal_models.ErrUserNotFound
+    }
+
+    closed := []string{}
+    for _, pr := range r.store.pullRequests {
+        if pr.authorId == userId && (pr.status == "DRAFT" || pr.status == "OPEN") {
+            closed = append(closed, pr.id)
+        }
+    }
+    sort.Strings(closed)
+
+    if len(closed) > 0 && !closeAuthored {
+        return nil, nil, fmt.Errorf("%w: %s", internal_models.ErrUserHasOpenPullRequests, strings.Join(closed, ", "))
+    }
+
+    closedAt := now()
+    events := make([]models.PullRequestEvent, 0, len(closed))
+    for _, prId := range closed {
+        pr := r.store.pullRequests[prId]
+        events = append(events, models.PullRequestEvent{
+            PullRequestId: prId,
+            Type:          "status_changed",
+            ActorId:       actorId,
+            FromStatus:    pr.status,
+            ToStatus:      "CLOSED",
+            Reason:        "author deleted",
+        })
+        r.store.touchPullRequest(prId)
+        pr.status = "CLOSED"
+        at := closedAt
+        pr.closedAt = &at
+    }
+    r.store.addEvents(events)
+
+    for _, pr := range r.store.pullRequests {
+        if pr.authorId == userId && pr.teamId == 0 {
+            r.store.touchPullRequest(pr.id)
+            pr.teamId = u.teamId
+        }
+    }
+
+    // пользователь выходит из пулов, чтобы не получить заменяемые ревью сам
+    r.store.touchUser(userId)
+    u.isActive = false
+    decisions := r.store.reassignOpenReviews([]string{userId}, nil, plan, actorId, "reviewer deleted")
+
+    for _, teamId := range r.store.userTeams(userId) {
+        r.store.touchMembers(teamId)
+        delete(r.store.members[teamId], userId)
+    }
+    for _, pr := range r.store.pullRequests {
+        rev := findReviewer(pr, userId)
+        if pr.authorId != userId && rev == nil {
+            continue
+        }
+        r.store.touchPullRequest(pr.id)
+        if pr.authorId == userId {
+            pr.authorId = ""
+        }
+        // как ON DELETE SET NULL в postgres: ревью остаётся в истории PR без ревьювера
+        if rev != nil {
+            rev.userId = ""
+        }
+    }
+    for key, accountUserId := range r.store.vcsAccounts {
+        if accountUserId == userId {
+            r.store.touchVcsAccount(key)
+            delete(r.store.vcsAccounts, key)
+        }
+    }
+    delete(r.store.users, userId)
+
+    return closed, decisions, nil
+}
+
+// DeactivateUsers деактивирует пользователей (список userIds или всю команду teamName)
+// и переназначает их OPEN-ревью на активных участников команды PR
+func (r *UserRepository) DeactivateUsers(ctx context.Context, userIds []string, teamName string, actorId string, plan internal_models.ReassignmentPlanner) ([]string, []internal_models.ReviewerReassignment, error) {
+    defer r.store.lock(ctx)()
+
+    var found []string
+    if teamName != "" {
+        teamId, err := r.store.resolveTeam(teamName)
+        if err != nil {
+            return nil, nil, err
+        }
+        found = r.store.teamMembers(teamId)
+    } else {
+        found = []string{}
+        missing := []string{}
+        seen := map[string]bool{}
+        for _, id := range userIds {
+            if seen[id] {
+                continue
+            }
+            seen[id] = true
+            if _, ok := r.store.users[id]; ok {
+                found = append(found, id)
+            } else {
+                missing = append(missing, id)
+            }
+        }
+        if len(missing) > 0 {
+            return nil, nil, fmt.Errorf("%w: %s", internal_models.ErrUserNotFound, strings.Join(missing, ", "))
+        }
+        sort.Strings(found)
+    }
+
+    for _, id := range found {
+        r.store.touchUser(id)
+        r.store.users[id].isActive = false
+    }
+
+    decisions := r.store.reassignOpenReviews(found, nil, plan, actorId, "reviewer deactivated")
+
+    return found, decisions, nil
+}
@@ -0,0 +1,243 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+type WebhookRepository struct {
+	store *Store
+}
+
+func NewWebhookRepository(store *Store) *WebhookRepository {
+	return &WebhookRepository{store: store}
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, url, secret string, events []string) (models.WebhookSubscription, error) {
+    defer r.store.lock(ctx)()
+
+    r.store.nextSubscriptionId++
+    r.store.touchSubscription(r.store.nextSubscriptionId)
+    sub := &subscriptionRecord{
+        id:        r.store.nextSubscriptionId,
+        url:       url,
+        secret:    secret,
+        events:    append([]string(nil), events...),
+        createdAt: now(),
+    }
+    r.store.subscriptions[sub.id] = sub
+
+    return subscriptionModel(sub), nil
+}
+
+func subscriptionModel(sub *subscriptionRecord) models.WebhookSubscription {
+    createdAt := sub.createdAt
+    return models.WebhookSubscription{
+        SubscriptionId: sub.id,
+        Url:            sub.url,
+        Events:         append([]string(nil), sub.events...),
+        CreatedAt:      &createdAt,
+    }
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+    defer r.store.lock(ctx)()
+
+    subscriptions := []models.WebhookSubscription{}
+    for id := int64(1); id <= r.store.nextSubscriptionId; id++ {
+        if sub, ok := r.store.subscriptions[id]; ok {
+            subscriptions = append(subscriptions, subscriptionModel(sub))
+        }
+    }
+
+    return subscriptions, nil
+}
+
+// DeleteSubscription удаляет подписку вместе с её журналом доставок
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, subscriptionId int64) error {
+    defer r.store.lock(ctx)()
+
+    if _, ok := r.store.subscriptions[subscriptionId]; !ok {
+        return internal_models.ErrWebhookNotFound
+    }
+    r.store.touchSubscription(subscriptionId)
+    delete(r.store.subscriptions, subscriptionId)
+
+    r.store.touchDeliveryList()
+    kept := r.store.deliveries[:0]
+    for _, d := range r.store.deliveries {
+        if d.subscriptionId != subscriptionId {
+            kept = append(kept, d)
+        }
+    }
+    r.store.deliveries = kept
+
+    return nil
+}
+
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, event string, payload []byte) error {
+    defer r.store.lock(ctx)()
+
+    for id := int64(1); id <= r.store.nextSubscriptionId; id++ {
+        sub, ok := r.store.subscriptions[id]
+        if !ok || !subscribed(sub, event) {
+            continue
+        }
+        r.store.addDelivery(sub.id, event, payload, nil)
+    }
+
+    return nil
+}
+
+func subscribed(sub *subscriptionRecord, event string) bool {
+    for _, e := range sub.events {
+        if e == event {
+            return true
+        }
+    }
+    return false
+}
+
+func (s *Store) addDelivery(subscriptionId int64, event string, payload []byte, redeliveryOf *int64) *deliveryRecord {
+    s.nextDeliveryId++
+    createdAt := now()
+    d := &deliveryRecord{
+        id:             s.nextDeliveryId,
+        subscriptionId: subscriptionId,
+        event:          event,
+        payload:        append([]byte(nil), payload...),
+        status:         "pending",
+        nextAttemptAt:  createdAt,
+        redeliveryOf:   redeliveryOf,
+        createdAt:      createdAt,
+    }
+    s.deliveries = append(s.deliveries, d)
+    return d
+}
+
+func (s *Store) findDelivery(deliveryId int64) *deliveryRecord {
+    for _, d := range s.deliveries {
+        if d.id == deliveryId {
+            return d
+        }
+    }
+    return nil
+}
+
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, at time.Time, lease time.Duration, limit int) ([]internal_models.WebhookTask, error) {
+    defer r.store.lock(ctx)()
+
+    tasks := []internal_models.WebhookTask{}
+    for _, d := range r.store.deliveries {
+        if len(tasks) == limit {
+            break
+        }
+        if d.status != "pending" || d.nextAttemptAt.After(at) {
+            continue
+        }
+        sub := r.store.subscriptions[d.subscriptionId]
+        r.store.touchDelivery(d)
+        d.nextAttemptAt = at.Add(lease)
+        tasks = append(tasks, internal_models.WebhookTask{
+            DeliveryID:  d.id,
+            URL:         sub.url,
+            Secret:      sub.secret,
+            Event:       d.event,
+            Payload:     append([]byte(nil), d.payload...),
+            Attempts:    d.attempts,
+            LeasedUntil: d.nextAttemptAt,
+        })
+    }
+
+    return tasks, nil
+}
+
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, deliveryId int64, leasedUntil time.Time, attempt internal_models.WebhookAttempt) error {
+    defer r.store.lock(ctx)()
+
+    d := r.store.findDelivery(deliveryId)
+    if d == nil {
+        return internal_models.ErrDeliveryNotFound
+    }
+    if d.status != "pending" || !d.nextAttemptAt.Equal(leasedUntil) {
+        return internal_models.ErrDeliveryLeaseLost
+    }
+
+    r.store.touchDelivery(d)
+    at := attempt.At
+    d.attempts++
+    d.lastAttemptAt = &at
+    d.responseStatus = attempt.ResponseStatus
+    d.lastError = attempt.Error
+    d.deliveredAt = nil
+    switch {
+    case attempt.Delivered:
+        d.status = "delivered"
+        d.deliveredAt = &at
+    case attempt.NextAttemptAt != nil:
+        d.status = "pending"
+        d.nextAttemptAt = *attempt.NextAttemptAt
+    default:
+        d.status = "failed"
+    }
+
+    return nil
+}
+
+// ListDeliveries возвращает доставки по фильтру от новых к старым
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, filter internal_models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+    defer r.store.lock(ctx)()
+
+    deliveries := []models.WebhookDelivery{}
+    for i := len(r.store.deliveries) - 1; i >= 0 && len(deliveries) < filter.Limit; i-- {
+        d := r.store.deliveries[i]
+        if filter.SubscriptionID != 0 && d.subscriptionId != filter.SubscriptionID {
+            continue
+        }
+        if filter.Status != "" && d.status != filter.Status {
+            continue
+        }
+        deliveries = append(deliveries, deliveryModel(d))
+    }
+
+    return deliveries, nil
+}
+
+func (r *WebhookRepository) Redeliver(ctx context.Context, deliveryId int64) (models.WebhookDelivery, error) {
+    defer r.store.lock(ctx)()
+
+    original := r.store.findDelivery(deliveryId)
+    if original == nil {
+        return models.WebhookDelivery{}, internal_models.ErrDeliveryNotFound
+    }
+    id := original.id
+
+    return deliveryModel(r.store.addDelivery(original.subscriptionId, original.event, original.payload, &id)), nil
+}
+
+func deliveryModel(d *deliveryRecord) models.WebhookDelivery {
+    delivery := models.WebhookDelivery{
+        DeliveryId:     d.id,
+        SubscriptionId: d.subscriptionId,
+        Event:          d.event,
+        Payload:        append([]byte(nil), d.payload...),
+        Status:         d.status,
+        Attempts:       d.attempts,
+        ResponseStatus: d.responseStatus,
+        LastError:      d.lastError,
+        LastAttemptAt:  d.lastAttemptAt,
+        DeliveredAt:    d.deliveredAt,
+        RedeliveryOf:   d.redeliveryOf,
+    }
+    createdAt := d.createdAt
+    delivery.CreatedAt = &createdAt
+    if d.status == "pending" {
+        nextAttemptAt := d.nextAttemptAt
+        delivery.NextAttemptAt = &nextAttemptAt
+    }
+
+    return delivery
+}
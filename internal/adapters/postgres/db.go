@@ -77,58 +77,3 @@ func (p *Postgres) CreateDatabase(cfg config.Config) error {
     }
     return nil
 }
-
-func (p *Postgres) CreateDatabaseTables() error {
-    _, err := p.Pool.Exec(context.Background(), `
-        CREATE TABLE IF NOT EXISTS teams (
-            team_id SERIAL PRIMARY KEY,
-            team_name TEXT UNIQUE NOT NULL
-        );
-    `)
-	if err != nil {
-		log.Fatal("Error creating teams table\n", err)
-		return err
-	}
-
-	_, err = p.Pool.Exec(context.Background(), `
-        CREATE TABLE IF NOT EXISTS users (
-            user_id TEXT PRIMARY KEY,
-            username TEXT NOT NULL,
-            team_id INT REFERENCES teams(team_id) ON DELETE SET NULL,
-            is_active BOOLEAN NOT NULL
-        );
-    `)
-	if err != nil {
-		log.Fatal("Error creating users table\n", err)
-		return err
-	}
-
-	_, err = p.Pool.Exec(context.Background(), `
-        CREATE TABLE IF NOT EXISTS pull_requests (
-            pull_request_id TEXT PRIMARY KEY,
-            pull_request_name TEXT NOT NULL,
-            author_id TEXT REFERENCES users(user_id),
-            status TEXT NOT NULL CHECK (status IN ('OPEN', 'MERGED')),
-            created_at TIMESTAMPTZ DEFAULT NOW(),
-            merged_at TIMESTAMPTZ
-        );
-    `)
-	if err != nil {
-		log.Fatal("Error creating pull_requests table\n", err)
-		return err
-	}
-
-	_, err = p.Pool.Exec(context.Background(), `
-        CREATE TABLE IF NOT EXISTS pull_request_reviewers (
-            pull_request_id TEXT REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
-            reviewer_id TEXT REFERENCES users(user_id) ON DELETE CASCADE,
-            PRIMARY KEY (pull_request_id, reviewer_id)
-        );
-    `)
-	if err != nil {
-		log.Fatal("Error creating pull_request_reviewers table\n", err)
-		return err
-	}
-
-	return nil
-}
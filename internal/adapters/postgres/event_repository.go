@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+)
+
+// execer - общее у *pgxpool.Pool и pgx.Tx, чтобы события писались и вне, и внутри транзакции
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func insertEvents(ctx context.Context, db execer, events []models.PullRequestEvent) error {
+    if len(events) == 0 {
+        return nil
+    }
+
+    columns := make([][]string, 9)
+    for _, e := range events {
+        for i, v := range []string{
+            e.PullRequestId, e.Type, e.ActorId, e.ReviewerId, e.OldReviewerId,
+            e.NewReviewerId, e.FromStatus, e.ToStatus, e.Reason,
+        } {
+            columns[i] = append(columns[i], v)
+        }
+    }
+
+    _, err := db.Exec(ctx, `
+        INSERT INTO pr_events (pull_request_id, event_type, actor_id, reviewer_id, old_reviewer_id,
+                               new_reviewer_id, from_status, to_status, reason)
+        SELECT pull_request_id, event_type, NULLIF(actor_id, ''), NULLIF(reviewer_id, ''), NULLIF(old_reviewer_id, ''),
+               NULLIF(new_reviewer_id, ''), NULLIF(from_status, ''), NULLIF(to_status, ''), NULLIF(reason, '')
+        FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[], $7::text[], $8::text[], $9::text[])
+             WITH ORDINALITY AS e(pull_request_id, event_type, actor_id, reviewer_id, old_reviewer_id,
+                                  new_reviewer_id, from_status, to_status, reason, n)
+        ORDER BY n
+    `, columns[0], columns[1], columns[2], columns[3], columns[4], columns[5], columns[6], columns[7], columns[8])
+
+    return err
+}
+
+func (r *PullRequestRepository) AddEvents(ctx context.Context, events []models.PullRequestEvent) error {
+    return insertEvents(ctx, r.db(ctx), events)
+}
+
+func (r *PullRequestRepository) GetEvents(ctx context.Context, prID string) ([]models.PullRequestEvent, error) {
+    rows, err := r.db(ctx).Query(ctx, `
+        SELECT id, pull_request_id, event_type,
+               COALESCE(actor_id, ''), COALESCE(reviewer_id, ''), COALESCE(old_reviewer_id, ''),
+               COALESCE(new_reviewer_id, ''), COALESCE(from_status, ''), COALESCE(to_status, ''),
+               COALESCE(reason, ''), created_at
+        FROM pr_events
+        WHERE pull_request_id = $1
+        ORDER BY id
+    `, prID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    events := []models.PullRequestEvent{}
+    for rows.Next() {
+        var e models.PullRequestEvent
+        if err := rows.Scan(
+            &e.Id, &e.PullRequestId, &e.Type,
+            &e.ActorId, &e.ReviewerId, &e.OldReviewerId,
+            &e.NewReviewerId, &e.FromStatus, &e.ToStatus,
+            &e.Reason, &e.CreatedAt,
+        ); err != nil {
+            return nil, err
+        }
+        events = append(events, e)
+    }
+
+    return events, rows.Err()
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+type IntegrationRepository struct {
+	pool *pgxpool.Pool
+}
+
+var ErrAccountNotFound = internal_models.ErrAccountNotFound
+
+func NewIntegrationRepository(pool *pgxpool.Pool) *IntegrationRepository {
+	return &IntegrationRepository{pool: pool}
+}
+
+func (r *IntegrationRepository) db(ctx context.Context) querier {
+    return conn(ctx, r.pool)
+}
+
+func (r *IntegrationRepository) LinkAccount(ctx context.Context, account models.VcsAccount) error {
+    tag, err := r.db(ctx).Exec(ctx, `
+        INSERT INTO vcs_accounts (provider, login, user_id)
+        SELECT $1, $2, user_id FROM users WHERE user_id = $3
+        ON CONFLICT (provider, login) DO UPDATE SET user_id = EXCLUDED.user_id
+    `, account.Provider, account.Login, account.UserId)
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        return ErrUserNotFound
+    }
+    return nil
+}
+
+func (r *IntegrationRepository) UnlinkAccount(ctx context.Context, provider, login string) error {
+    tag, err := r.db(ctx).Exec(ctx, `DELETE FROM vcs_accounts WHERE provider = $1 AND login = $2`, provider, login)
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        return ErrAccountNotFound
+    }
+    return nil
+}
+
+func (r *IntegrationRepository) ListAccounts(ctx context.Context, provider, userId string) ([]models.VcsAccount, error) {
+    rows, err := r.db(ctx).Query(ctx, `
+        SELECT provider, login, user_id
+        FROM vcs_accounts
+        WHERE ($1 = '' OR provider = $1) AND ($2 = '' OR user_id = $2)
+        ORDER BY provider, login
+    `, provider, userId)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    accounts := []models.VcsAccount{}
+    for rows.Next() {
+        var account models.VcsAccount
+        if err := rows.Scan(&account.Provider, &account.Login, &account.UserId); err != nil {
+            return nil, err
+        }
+        accounts = append(accounts, account)
+    }
+
+    return accounts, rows.Err()
+}
+
+func (r *IntegrationRepository) ResolveAccount(ctx context.Context, provider, login string) (string, error) {
+    var userId string
+    err := r.db(ctx).QueryRow(ctx, `
+        SELECT user_id FROM vcs_accounts WHERE provider = $1 AND login = $2
+    `, provider, login).Scan(&userId)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return "", ErrAccountNotFound
+    }
+    return userId, err
+}
+
+// ClaimDelivery - вставка по первичному ключу: из двух реплик, получивших одну доставку,
+// строку займёт только одна
+func (r *IntegrationRepository) ClaimDelivery(ctx context.Context, provider, deliveryId, event string, staleBefore time.Time) (bool, error) {
+    var claimed bool
+    err := r.db(ctx).QueryRow(ctx, `
+        INSERT INTO vcs_deliveries (provider, delivery_id, event)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (provider, delivery_id) DO UPDATE
+        SET event = EXCLUDED.event, status = 'processing', result = '', received_at = NOW(), finished_at = NULL
+        WHERE vcs_deliveries.status = 'failed'
+           OR (vcs_deliveries.status = 'processing' AND vcs_deliveries.received_at < $4)
+        RETURNING true
+    `, provider, deliveryId, event, staleBefore).Scan(&claimed)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return false, nil
+    }
+    return claimed, err
+}
+
+func (r *IntegrationRepository) FinishDelivery(ctx context.Context, provider, deliveryId, status, result string) error {
+    tag, err := r.db(ctx).Exec(ctx, `
+        UPDATE vcs_deliveries
+        SET status = $3, result = $4, finished_at = NOW()
+        WHERE provider = $1 AND delivery_id = $2
+    `, provider, deliveryId, status, result)
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        return ErrDeliveryNotFound
+    }
+    return nil
+}
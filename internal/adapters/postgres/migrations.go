@@ -0,0 +1,254 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationsLockKey - ключ advisory lock, под которым реплики по очереди применяют миграции
+const migrationsLockKey int64 = 0x6d6967726174696f
+
+// Migration - версия схемы из пары файлов NNNN_name.up.sql / NNNN_name.down.sql
+type Migration struct {
+    Version int64
+    Name    string
+    Up      string
+    Down    string
+}
+
+type MigrationStatus struct {
+    Version   int64
+    Name      string
+    AppliedAt *time.Time // nil - ещё не применена
+}
+
+// loadMigrations читает встроенные миграции, отсортированные по версии
+func loadMigrations() ([]Migration, error) {
+    entries, err := fs.ReadDir(migrationFiles, "migrations")
+    if err != nil {
+        return nil, err
+    }
+
+    byVersion := map[int64]*Migration{}
+    for _, entry := range entries {
+        fileName := entry.Name()
+
+        base, direction := "", ""
+        switch {
+        case strings.HasSuffix(fileName, ".up.sql"):
+            base, direction = strings.TrimSuffix(fileName, ".up.sql"), "up"
+        case strings.HasSuffix(fileName, ".down.sql"):
+            base, direction = strings.TrimSuffix(fileName, ".down.sql"), "down"
+        default:
+            return nil, fmt.Errorf("unexpected migration file %s", fileName)
+        }
+
+        rawVersion, name, ok := strings.Cut(base, "_")
+        if !ok {
+            return nil, fmt.Errorf("migration file %s must be named NNNN_name.%s.sql", fileName, direction)
+        }
+        version, err := strconv.ParseInt(rawVersion, 10, 64)
+        if err != nil {
+            return nil, fmt.Errorf("migration file %s: invalid version: %w", fileName, err)
+        }
+
+        content, err := migrationFiles.ReadFile("migrations/" + fileName)
+        if err != nil {
+            return nil, err
+        }
+
+        m, ok := byVersion[version]
+        if !ok {
+            m = &Migration{Version: version, Name: name}
+            byVersion[version] = m
+        }
+        if m.Name != name {
+            return nil, fmt.Errorf("migration %d has different names: %s and %s", version, m.Name, name)
+        }
+        if direction == "up" {
+            m.Up = string(content)
+        } else {
+            m.Down = string(content)
+        }
+    }
+
+    migrations := make([]Migration, 0, len(byVersion))
+    for _, m := range byVersion {
+        if m.Up == "" || m.Down == "" {
+            return nil, fmt.Errorf("migration %04d_%s must have both up and down files", m.Version, m.Name)
+        }
+        migrations = append(migrations, *m)
+    }
+    sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+    return migrations, nil
+}
+
+// MigrateUp применяет все ещё не применённые миграции по возрастанию версии
+// и возвращает применённые
+func (p *Postgres) MigrateUp(ctx context.Context) ([]Migration, error) {
+    migrations, err := loadMigrations()
+    if err != nil {
+        return nil, err
+    }
+
+    applied := []Migration{}
+    err = p.withMigrationLock(ctx, func(conn *pgxpool.Conn) error {
+        done, err := appliedVersions(ctx, conn)
+        if err != nil {
+            return err
+        }
+
+        for _, m := range migrations {
+            if _, ok := done[m.Version]; ok {
+                continue
+            }
+            err := runMigration(ctx, conn, m.Up,
+                `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+            if err != nil {
+                return fmt.Errorf("migration %04d_%s up: %w", m.Version, m.Name, err)
+            }
+            applied = append(applied, m)
+        }
+        return nil
+    })
+
+    return applied, err
+}
+
+// MigrateDown откатывает steps последних применённых миграций и возвращает откаченные
+func (p *Postgres) MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+    migrations, err := loadMigrations()
+    if err != nil {
+        return nil, err
+    }
+
+    reverted := []Migration{}
+    err = p.withMigrationLock(ctx, func(conn *pgxpool.Conn) error {
+        done, err := appliedVersions(ctx, conn)
+        if err != nil {
+            return err
+        }
+
+        for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+            m := migrations[i]
+            if _, ok := done[m.Version]; !ok {
+                continue
+            }
+            err := runMigration(ctx, conn, m.Down,
+                `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+            if err != nil {
+                return fmt.Errorf("migration %04d_%s down: %w", m.Version, m.Name, err)
+            }
+            reverted = append(reverted, m)
+        }
+        return nil
+    })
+
+    return reverted, err
+}
+
+// MigrationsStatus возвращает все известные миграции с отметкой о применении
+func (p *Postgres) MigrationsStatus(ctx context.Context) ([]MigrationStatus, error) {
+    migrations, err := loadMigrations()
+    if err != nil {
+        return nil, err
+    }
+
+    statuses := make([]MigrationStatus, 0, len(migrations))
+    err = p.withMigrationLock(ctx, func(conn *pgxpool.Conn) error {
+        done, err := appliedVersions(ctx, conn)
+        if err != nil {
+            return err
+        }
+
+        for _, m := range migrations {
+            status := MigrationStatus{Version: m.Version, Name: m.Name}
+            if appliedAt, ok := done[m.Version]; ok {
+                status.AppliedAt = &appliedAt
+            }
+            statuses = append(statuses, status)
+        }
+        return nil
+    })
+
+    return statuses, err
+}
+
+// withMigrationLock выполняет fn на отдельном соединении под сессионным advisory lock,
+// чтобы одновременно запущенные реплики не применяли миграции параллельно
+func (p *Postgres) withMigrationLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+    conn, err := p.Pool.Acquire(ctx)
+    if err != nil {
+        return err
+    }
+    defer conn.Release()
+
+    if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationsLockKey); err != nil {
+        return err
+    }
+    defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationsLockKey)
+
+    _, err = conn.Exec(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version BIGINT PRIMARY KEY,
+            name TEXT NOT NULL,
+            applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        );
+    `)
+    if err != nil {
+        return err
+    }
+
+    return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int64]time.Time, error) {
+    rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    versions := map[int64]time.Time{}
+    for rows.Next() {
+        var version int64
+        var appliedAt time.Time
+        if err := rows.Scan(&version, &appliedAt); err != nil {
+            return nil, err
+        }
+        versions[version] = appliedAt
+    }
+
+    return versions, rows.Err()
+}
+
+// runMigration выполняет SQL миграции и запись в schema_migrations в одной транзакции
+func runMigration(ctx context.Context, conn *pgxpool.Conn, script string, bookkeeping string, args ...any) error {
+    tx, err := conn.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    // без аргументов pgx использует простой протокол, поэтому в скрипте может быть несколько команд
+    if _, err := tx.Exec(ctx, script); err != nil {
+        return err
+    }
+    if _, err := tx.Exec(ctx, bookkeeping, args...); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS teams (
+    team_id SERIAL PRIMARY KEY,
+    team_name TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    user_id TEXT PRIMARY KEY,
+    username TEXT NOT NULL,
+    team_id INT REFERENCES teams(team_id),
+    is_active BOOLEAN NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pull_requests (
+    pull_request_id TEXT PRIMARY KEY,
+    pull_request_name TEXT NOT NULL,
+    author_id TEXT REFERENCES users(user_id),
+    status TEXT NOT NULL CHECK (status IN ('DRAFT', 'OPEN', 'MERGED', 'CLOSED')),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    merged_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS pull_request_reviewers (
+    pull_request_id TEXT REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    reviewer_id TEXT REFERENCES users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (pull_request_id, reviewer_id)
+);
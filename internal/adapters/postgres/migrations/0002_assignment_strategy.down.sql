@@ -0,0 +1,3 @@
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS assigned_at;
+ALTER TABLE users DROP COLUMN IF EXISTS review_weight;
+ALTER TABLE teams DROP COLUMN IF EXISTS assignment_strategy;
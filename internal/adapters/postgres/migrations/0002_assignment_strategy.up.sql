@@ -0,0 +1,3 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS assignment_strategy TEXT NOT NULL DEFAULT 'least_loaded';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS review_weight INT NOT NULL DEFAULT 1 CHECK (review_weight > 0);
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
@@ -0,0 +1,2 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS min_reviewers INT NOT NULL DEFAULT 0 CHECK (min_reviewers >= 0);
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS max_reviewers INT NOT NULL DEFAULT 2 CHECK (max_reviewers >= 1);
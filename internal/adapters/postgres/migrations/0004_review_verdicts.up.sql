@@ -0,0 +1,4 @@
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS verdict TEXT
+    CHECK (verdict IN ('APPROVED', 'CHANGES_REQUESTED', 'COMMENTED'));
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS verdict_comment TEXT;
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS verdict_at TIMESTAMPTZ;
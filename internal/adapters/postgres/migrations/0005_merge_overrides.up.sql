@@ -0,0 +1,10 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS required_approvals INT NOT NULL DEFAULT 0 CHECK (required_approvals >= 0);
+
+CREATE TABLE IF NOT EXISTS merge_overrides (
+    pull_request_id TEXT PRIMARY KEY REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    forced_by TEXT NOT NULL,
+    reason TEXT,
+    approvals INT NOT NULL,
+    required_approvals INT NOT NULL,
+    forced_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- ограничение статуса не сужается: в таблице уже могут быть DRAFT и CLOSED PR
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS closed_at;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS pull_requests_status_idx;
+DROP INDEX IF EXISTS pull_request_reviewers_reviewer_id_idx;
+DROP INDEX IF EXISTS users_team_id_idx;
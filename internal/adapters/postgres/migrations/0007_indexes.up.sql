@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS users_team_id_idx ON users (team_id);
+CREATE INDEX IF NOT EXISTS pull_request_reviewers_reviewer_id_idx ON pull_request_reviewers (reviewer_id);
+CREATE INDEX IF NOT EXISTS pull_requests_status_idx ON pull_requests (status);
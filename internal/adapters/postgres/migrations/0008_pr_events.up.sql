@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS pr_events (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    actor_id TEXT,
+    reviewer_id TEXT,
+    old_reviewer_id TEXT,
+    new_reviewer_id TEXT,
+    from_status TEXT,
+    to_status TEXT,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS pr_events_pull_request_id_idx ON pr_events (pull_request_id, id);
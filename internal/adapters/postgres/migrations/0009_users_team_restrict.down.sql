@@ -0,0 +1,3 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_id_fkey;
+ALTER TABLE users ADD CONSTRAINT users_team_id_fkey
+    FOREIGN KEY (team_id) REFERENCES teams(team_id);
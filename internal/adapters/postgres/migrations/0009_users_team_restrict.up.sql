@@ -0,0 +1,4 @@
+-- команда удаляется только через DeleteTeam, который явно переводит участников
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_id_fkey;
+ALTER TABLE users ADD CONSTRAINT users_team_id_fkey
+    FOREIGN KEY (team_id) REFERENCES teams(team_id) ON DELETE RESTRICT;
@@ -0,0 +1,3 @@
+-- дополнительные членства и явные команды PR теряются, остаются основные команды users.team_id
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS team_id;
+DROP TABLE IF EXISTS team_members;
@@ -0,0 +1,15 @@
+-- users.team_id остаётся основной командой, а все членства (включая основную) хранятся в team_members;
+-- pull_requests.team_id - команда, из которой назначаются ревьюверы (NULL - основная команда автора)
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id INT NOT NULL REFERENCES teams(team_id) ON DELETE RESTRICT,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (team_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS team_members_user_id_idx ON team_members (user_id);
+
+INSERT INTO team_members (team_id, user_id)
+SELECT team_id, user_id FROM users WHERE team_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS team_id INT REFERENCES teams(team_id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS pull_requests_team_id_idx ON pull_requests (team_id);
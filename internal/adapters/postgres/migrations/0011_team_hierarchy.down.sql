@@ -0,0 +1,4 @@
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS source_team_id;
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS source;
+DROP TABLE IF EXISTS team_fallbacks;
+ALTER TABLE teams DROP COLUMN IF EXISTS parent_team_id;
@@ -0,0 +1,14 @@
+-- иерархия команд: ревьюверы добираются из запасных (team_fallbacks) и вышестоящих команд;
+-- source у ревьювера NULL, если он из команды PR
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS parent_team_id INT REFERENCES teams(team_id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS team_fallbacks (
+    team_id INT NOT NULL REFERENCES teams(team_id) ON DELETE CASCADE,
+    fallback_team_id INT NOT NULL REFERENCES teams(team_id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    PRIMARY KEY (team_id, fallback_team_id),
+    CHECK (team_id <> fallback_team_id)
+);
+
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS source TEXT CHECK (source IN ('fallback', 'parent'));
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS source_team_id INT REFERENCES teams(team_id) ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+-- при удалении пользователя его PR остаются в истории без автора
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_author_id_fkey;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_author_id_fkey
+    FOREIGN KEY (author_id) REFERENCES users(user_id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS vcs_deliveries;
+DROP TABLE IF EXISTS vcs_accounts;
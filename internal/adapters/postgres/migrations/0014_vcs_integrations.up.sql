@@ -0,0 +1,20 @@
+-- привязка логинов внешних систем контроля версий к пользователям и журнал входящих вебхуков;
+-- журнал делает обработку повторных доставок идемпотентной
+CREATE TABLE IF NOT EXISTS vcs_accounts (
+    provider TEXT NOT NULL,
+    login TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (provider, login)
+);
+CREATE INDEX IF NOT EXISTS vcs_accounts_user_id_idx ON vcs_accounts (user_id);
+
+CREATE TABLE IF NOT EXISTS vcs_deliveries (
+    provider TEXT NOT NULL,
+    delivery_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'processing' CHECK (status IN ('processing', 'processed', 'ignored', 'failed')),
+    result TEXT NOT NULL DEFAULT '',
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    PRIMARY KEY (provider, delivery_id)
+);
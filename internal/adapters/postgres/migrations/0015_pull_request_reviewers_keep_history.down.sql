@@ -0,0 +1,10 @@
+DELETE FROM pull_request_reviewers WHERE reviewer_id IS NULL;
+
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT IF EXISTS pull_request_reviewers_reviewer_id_fkey;
+ALTER TABLE pull_request_reviewers ADD CONSTRAINT pull_request_reviewers_reviewer_id_fkey
+    FOREIGN KEY (reviewer_id) REFERENCES users(user_id) ON DELETE CASCADE;
+
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT IF EXISTS pull_request_reviewers_pull_request_id_reviewer_id_key;
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT IF EXISTS pull_request_reviewers_pkey;
+ALTER TABLE pull_request_reviewers ADD CONSTRAINT pull_request_reviewers_pkey PRIMARY KEY (pull_request_id, reviewer_id);
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS id;
@@ -0,0 +1,13 @@
+-- при удалении пользователя его ревью остаются в истории PR без ревьювера: вердикты и назначения
+-- по MERGED и CLOSED PR не теряются. reviewer_id становится nullable, поэтому первичный ключ
+-- переносится на суррогатный id, а пара (PR, ревьювер) остаётся уникальной
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS id BIGSERIAL;
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT IF EXISTS pull_request_reviewers_pkey;
+ALTER TABLE pull_request_reviewers ADD CONSTRAINT pull_request_reviewers_pkey PRIMARY KEY (id);
+ALTER TABLE pull_request_reviewers ALTER COLUMN reviewer_id DROP NOT NULL;
+ALTER TABLE pull_request_reviewers ADD CONSTRAINT pull_request_reviewers_pull_request_id_reviewer_id_key
+    UNIQUE (pull_request_id, reviewer_id);
+
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT IF EXISTS pull_request_reviewers_reviewer_id_fkey;
+ALTER TABLE pull_request_reviewers ADD CONSTRAINT pull_request_reviewers_reviewer_id_fkey
+    FOREIGN KEY (reviewer_id) REFERENCES users(user_id) ON DELETE SET NULL;
@@ -0,0 +1,73 @@
+// Package pgtest подключает тесты к Postgres из TEST_DATABASE_URL. Каждый пакет тестов работает
+// в своей схеме, поэтому go test ./... может запускать их параллельно на одной базе.
+// Без TEST_DATABASE_URL тесты, которым нужна база, пропускаются
+package pgtest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/kgugunava/avito-tech-internship/internal/adapters/postgres"
+)
+
+const DatabaseURLEnv = "TEST_DATABASE_URL"
+
+// Open подключается к схеме pgtest_<schema>, применяет миграции и очищает все таблицы
+func Open(tb testing.TB, schema string) *postgres.Postgres {
+    tb.Helper()
+
+    url := os.Getenv(DatabaseURLEnv)
+    if url == "" {
+        tb.Skip(DatabaseURLEnv + " is not set")
+    }
+    ctx := context.Background()
+    schema = "pgtest_" + schema
+
+    cfg, err := pgxpool.ParseConfig(url)
+    if err != nil {
+        tb.Fatalf("parse %s: %v", DatabaseURLEnv, err)
+    }
+    cfg.ConnConfig.RuntimeParams["search_path"] = schema
+
+    pool, err := pgxpool.NewWithConfig(ctx, cfg)
+    if err != nil {
+        tb.Fatalf("connect: %v", err)
+    }
+    tb.Cleanup(pool.Close)
+
+    if _, err := pool.Exec(ctx, `CREATE SCHEMA IF NOT EXISTS `+schema); err != nil {
+        tb.Fatalf("create schema: %v", err)
+    }
+
+    db := &postgres.Postgres{Pool: pool}
+    if _, err := db.MigrateUp(ctx); err != nil {
+        tb.Fatalf("migrate: %v", err)
+    }
+    Reset(tb, db)
+
+    return db
+}
+
+// Reset очищает все таблицы схемы, кроме журнала миграций
+func Reset(tb testing.TB, db *postgres.Postgres) {
+    tb.Helper()
+
+    _, err := db.Pool.Exec(context.Background(), `
+        DO $$
+        DECLARE tables TEXT;
+        BEGIN
+            SELECT string_agg(quote_ident(tablename), ', ') INTO tables
+            FROM pg_tables
+            WHERE schemaname = current_schema() AND tablename <> 'schema_migrations';
+            IF tables IS NOT NULL THEN
+                EXECUTE 'TRUNCATE ' || tables || ' RESTART IDENTITY CASCADE';
+            END IF;
+        END $$
+    `)
+    if err != nil {
+        tb.Fatalf("reset: %v", err)
+    }
+}
@@ -3,25 +3,36 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5"
 
 	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
 )
 
 type PullRequestRepository struct {
 	pool *pgxpool.Pool
 }
 
+var ErrPullRequestNotFound = internal_models.ErrPullRequestNotFound
+var ErrPullRequestExists = internal_models.ErrPullRequestExists
+
 func NewPullRequestRepository(pool *pgxpool.Pool) *PullRequestRepository {
 	return &PullRequestRepository{pool: pool}
 }
 
+// db возвращает транзакцию UnitOfWork из ctx или пул
+func (r *PullRequestRepository) db(ctx context.Context) querier {
+    return conn(ctx, r.pool)
+}
+
 func (r *PullRequestRepository) PRExists(ctx context.Context, prId string) (bool, error) {
 	var exists bool
-    err := r.pool.QueryRow(ctx,
+    err := r.db(ctx).QueryRow(ctx,
         `SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = $1)`,
         prId,
     ).Scan(&exists)
@@ -31,7 +42,7 @@ func (r *PullRequestRepository) PRExists(ctx context.Context, prId string) (bool
 func (r *PullRequestRepository) GetUserTeam(ctx context.Context, userId string) (int, error) {
 	var teamId *int
 
-    err := r.pool.QueryRow(ctx,
+    err := r.db(ctx).QueryRow(ctx,
         `SELECT team_id FROM users WHERE user_id = $1`,
         userId,
     ).Scan(&teamId)
@@ -44,93 +55,393 @@ func (r *PullRequestRepository) GetUserTeam(ctx context.Context, userId string)
         return 0, err
     }
 
+    if teamId == nil {
+        return 0, nil
+    }
+
     return *teamId, nil
 }
 
-func (r *PullRequestRepository) GetTeamReviewers(ctx context.Context, teamId int, userId string) ([]string, error) {
-    rows, err := r.pool.Query(ctx,
-        `SELECT user_id FROM users
-         WHERE team_id = $1 AND user_id != $2 AND is_active = TRUE
-         LIMIT 2`,
-        teamId, userId,
+// GetTeamID возвращает идентификатор команды по имени
+func (r *PullRequestRepository) GetTeamID(ctx context.Context, teamName string) (int, error) {
+    return lookupTeamId(ctx, r.db(ctx), teamName)
+}
+
+// IsTeamMember проверяет, состоит ли пользователь в команде (не обязательно основной)
+func (r *PullRequestRepository) IsTeamMember(ctx context.Context, teamId int, userId string) (bool, error) {
+    var isMember bool
+    err := r.db(ctx).QueryRow(ctx,
+        `SELECT EXISTS(SELECT 1 FROM team_members WHERE team_id = $1 AND user_id = $2)`,
+        teamId,
+        userId,
+    ).Scan(&isMember)
+    return isMember, err
+}
+
+// GetPullRequestTeam возвращает команду, из которой назначаются ревьюверы PR:
+// явно указанную при создании или основную команду автора (0 - команды нет)
+func (r *PullRequestRepository) GetPullRequestTeam(ctx context.Context, prId string) (int, error) {
+    var teamId int
+    err := r.db(ctx).QueryRow(ctx,
+        `SELECT COALESCE(pr.team_id, author.team_id, 0)
+         FROM pull_requests pr
+         LEFT JOIN users author ON author.user_id = pr.author_id
+         WHERE pr.pull_request_id = $1`,
+        prId,
+    ).Scan(&teamId)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return 0, ErrPullRequestNotFound
+    }
+    return teamId, err
+}
+
+func (r *PullRequestRepository) GetTeamAssignmentSettings(ctx context.Context, teamId int) (internal_models.TeamAssignmentSettings, error) {
+    var settings internal_models.TeamAssignmentSettings
+
+    err := r.db(ctx).QueryRow(ctx,
+        `SELECT assignment_strategy, min_reviewers, max_reviewers, required_approvals FROM teams WHERE team_id = $1`,
+        teamId,
+    ).Scan(&settings.Strategy, &settings.MinReviewers, &settings.MaxReviewers, &settings.RequiredApprovals)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return settings, ErrTeamNotFound
+    }
+
+    return settings, err
+}
+
+func (r *PullRequestRepository) GetTeamMembers(ctx context.Context, teamId int) ([]models.TeamMember, error) {
+    rows, err := r.db(ctx).Query(ctx,
+        `SELECT u.user_id, u.username, u.is_active, u.review_weight
+         FROM team_members tm
+         JOIN users u ON u.user_id = tm.user_id
+         WHERE tm.team_id = $1`,
+        teamId,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    members := []models.TeamMember{}
+    for rows.Next() {
+        var m models.TeamMember
+        if err := rows.Scan(&m.UserId, &m.Username, &m.IsActive, &m.ReviewWeight); err != nil {
+            return nil, err
+        }
+        members = append(members, m)
+    }
+
+    return members, rows.Err()
+}
+
+func (r *PullRequestRepository) GetUsersActivity(ctx context.Context, userIds []string) (map[string]bool, error) {
+    rows, err := r.db(ctx).Query(ctx,
+        `SELECT user_id, is_active FROM users WHERE user_id = ANY($1)`,
+        userIds,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    activity := make(map[string]bool, len(userIds))
+    for rows.Next() {
+        var userId string
+        var isActive bool
+        if err := rows.Scan(&userId, &isActive); err != nil {
+            return nil, err
+        }
+        activity[userId] = isActive
+    }
+
+    return activity, rows.Err()
+}
+
+// GetTeamCandidates возвращает активных участников команды, кроме exclude,
+// вместе с их текущей загрузкой (число OPEN PR на ревью) и временем последнего назначения
+func (r *PullRequestRepository) GetTeamCandidates(ctx context.Context, teamId int, exclude []string) ([]internal_models.ReviewerCandidate, error) {
+    rows, err := r.db(ctx).Query(ctx,
+        `SELECT u.user_id,
+                u.review_weight,
+                COUNT(pr.pull_request_id) AS open_reviews,
+                MAX(rev.assigned_at) AS last_assigned_at
+         FROM team_members tm
+         JOIN users u ON u.user_id = tm.user_id
+         LEFT JOIN pull_request_reviewers rev ON rev.reviewer_id = u.user_id
+         LEFT JOIN pull_requests pr ON pr.pull_request_id = rev.pull_request_id AND pr.status = 'OPEN'
+         WHERE tm.team_id = $1 AND u.is_active = TRUE AND NOT (u.user_id = ANY($2))
+         GROUP BY u.user_id, u.review_weight`,
+        teamId, exclude,
     )
     if err != nil {
         return nil, err
     }
     defer rows.Close()
 
-    var reviewers []string
+    var candidates []internal_models.ReviewerCandidate
     for rows.Next() {
-        var u string
-        rows.Scan(&u)
-        reviewers = append(reviewers, u)
+        var c internal_models.ReviewerCandidate
+        if err := rows.Scan(&c.UserID, &c.Weight, &c.OpenReviews, &c.LastAssignedAt); err != nil {
+            return nil, err
+        }
+        candidates = append(candidates, c)
     }
 
-    return reviewers, nil
+    return candidates, rows.Err()
 }
 
-func (r *PullRequestRepository) CreatePR(ctx context.Context, req models.PullRequestCreatePostRequest) error {
-    _, err := r.pool.Exec(ctx,
-        `INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status)
-         VALUES ($1, $2, $3, 'OPEN')`,
+// CreatePR добавляет PR или возвращает ErrPullRequestExists, если такой id уже есть.
+// Параллельная вставка того же id ждёт коммита первой транзакции, поэтому дубликатов не бывает
+func (r *PullRequestRepository) CreatePR(ctx context.Context, req models.PullRequestCreatePostRequest, teamId int, status string) error {
+    result, err := r.db(ctx).Exec(ctx,
+        `INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, team_id, status)
+         VALUES ($1, $2, $3, $4, $5)
+         ON CONFLICT (pull_request_id) DO NOTHING`,
         req.PullRequestId,
         req.PullRequestName,
         req.AuthorId,
+        teamId,
+        status,
     )
+    if err != nil {
+        return err
+    }
+    if result.RowsAffected() == 0 {
+        return ErrPullRequestExists
+    }
+    return nil
+}
+
+// LockPullRequest блокирует строку PR до конца транзакции UnitOfWork, чтобы параллельные
+// изменения того же PR выполнялись по очереди и видели результат друг друга
+func (r *PullRequestRepository) LockPullRequest(ctx context.Context, prID string) error {
+    var locked string
+    err := r.db(ctx).QueryRow(ctx,
+        `SELECT pull_request_id FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE`,
+        prID,
+    ).Scan(&locked)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return ErrPullRequestNotFound
+    }
     return err
 }
 
-func (r *PullRequestRepository) AssignReviewers(ctx context.Context, prId string, reviewers []string) error {
-    for _, rID := range reviewers {
-        _, err := r.pool.Exec(ctx,
-            `INSERT INTO pull_request_reviewers (pull_request_id, reviewer_id) VALUES ($1, $2)`,
-            prId, rID,
-        )
-        if err != nil {
+func (r *PullRequestRepository) AssignReviewers(ctx context.Context, prId string, reviewers []internal_models.SelectedReviewer) error {
+    for _, reviewer := range reviewers {
+        if err := insertReviewer(ctx, r.db(ctx), prId, reviewer); err != nil {
             return err
         }
     }
     return nil
 }
 
+func insertReviewer(ctx context.Context, db execer, prId string, reviewer internal_models.SelectedReviewer) error {
+    source, sourceTeamId := reviewerSourceColumns(reviewer)
+    _, err := db.Exec(ctx,
+        `INSERT INTO pull_request_reviewers (pull_request_id, reviewer_id, source, source_team_id) VALUES ($1, $2, $3, $4)`,
+        prId, reviewer.UserID, source, sourceTeamId,
+    )
+    return err
+}
+
+// reviewerSourceColumns возвращает source и source_team_id; для ревьювера из команды PR оба NULL
+func reviewerSourceColumns(reviewer internal_models.SelectedReviewer) (*string, *int) {
+    if reviewer.Source == "" || reviewer.Source == internal_models.ReviewerSourceTeam {
+        return nil, nil
+    }
+    return &reviewer.Source, &reviewer.TeamID
+}
+
+// GetReviewerPools возвращает команды, из которых подбираются ревьюверы для команды teamId, в порядке опроса:
+// сама команда, её запасные команды по порядку, затем вышестоящие команды снизу вверх
+func (r *PullRequestRepository) GetReviewerPools(ctx context.Context, teamId int) ([]internal_models.ReviewerPool, error) {
+    return reviewerPools(ctx, r.db(ctx), teamId)
+}
+
+// reviewerPools - запрос GetReviewerPools; его же использует массовое переназначение внутри своей транзакции
+func reviewerPools(ctx context.Context, db querier, teamId int) ([]internal_models.ReviewerPool, error) {
+    rows, err := db.Query(ctx, `
+        WITH RECURSIVE ancestors AS (
+            SELECT t.parent_team_id AS team_id, 1 AS depth, ARRAY[t.team_id] AS path
+            FROM teams t
+            WHERE t.team_id = $1 AND t.parent_team_id IS NOT NULL
+          UNION ALL
+            SELECT t.parent_team_id, a.depth + 1, a.path || t.team_id
+            FROM ancestors a
+            JOIN teams t ON t.team_id = a.team_id
+            WHERE t.parent_team_id IS NOT NULL AND NOT (t.parent_team_id = ANY(a.path || t.team_id))
+        )
+        SELECT p.team_id, t.team_name, t.assignment_strategy, p.source
+        FROM (
+            SELECT $1::int AS team_id, 'team' AS source, 0 AS grp, 0 AS n
+            UNION ALL
+            SELECT fallback_team_id, 'fallback', 1, position FROM team_fallbacks WHERE team_id = $1
+            UNION ALL
+            SELECT team_id, 'parent', 2, depth FROM ancestors
+        ) p
+        JOIN teams t ON t.team_id = p.team_id
+        ORDER BY p.grp, p.n
+    `, teamId)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    pools := []internal_models.ReviewerPool{}
+    seen := map[int]bool{}
+    for rows.Next() {
+        var pool internal_models.ReviewerPool
+        if err := rows.Scan(&pool.TeamID, &pool.TeamName, &pool.Strategy, &pool.Source); err != nil {
+            return nil, err
+        }
+        if seen[pool.TeamID] {
+            continue
+        }
+        seen[pool.TeamID] = true
+        pools = append(pools, pool)
+    }
+
+    return pools, rows.Err()
+}
+
+// MarkReadyForReview переводит DRAFT в OPEN и назначает ревьюверов одной транзакцией
+func (r *PullRequestRepository) MarkReadyForReview(ctx context.Context, prID string, reviewers []internal_models.SelectedReviewer) error {
+    tx, err := r.db(ctx).Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    result, err := tx.Exec(ctx,
+        `UPDATE pull_requests SET status = 'OPEN' WHERE pull_request_id = $1 AND status = 'DRAFT'`,
+        prID,
+    )
+    if err != nil {
+        return err
+    }
+    if result.RowsAffected() == 0 {
+        return errors.New("pull request is not a draft")
+    }
+
+    for _, reviewer := range reviewers {
+        if err := insertReviewer(ctx, tx, prID, reviewer); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit(ctx)
+}
+
 func (r *PullRequestRepository) GetByID(ctx context.Context, prID string) (models.PullRequest, error) {
     var pr models.PullRequest
 
     query := `
-        SELECT pull_request_id,
-               pull_request_name,
-               author_id,
-               status,
-               merged_at
-        FROM pull_requests
-        WHERE pull_request_id = $1
+        SELECT pr.pull_request_id,
+               pr.pull_request_name,
+               COALESCE(pr.author_id, ''),
+               COALESCE(t.team_name, ''),
+               pr.status,
+               pr.created_at,
+               pr.merged_at,
+               pr.closed_at,
+               mo.forced_by,
+               mo.reason,
+               mo.approvals,
+               mo.required_approvals,
+               mo.forced_at
+        FROM pull_requests pr
+        LEFT JOIN users author ON author.user_id = pr.author_id
+        LEFT JOIN teams t ON t.team_id = COALESCE(pr.team_id, author.team_id)
+        LEFT JOIN merge_overrides mo ON mo.pull_request_id = pr.pull_request_id
+        WHERE pr.pull_request_id = $1
     `
 
-    err := r.pool.QueryRow(ctx, query, prID).Scan(
+    var forcedBy, forceReason *string
+    var approvals, requiredApprovals *int
+    var forcedAt *time.Time
+
+    err := r.db(ctx).QueryRow(ctx, query, prID).Scan(
         &pr.PullRequestId,
         &pr.PullRequestName,
         &pr.AuthorId,
+        &pr.TeamName,
         &pr.Status,
+        &pr.CreatedAt,
         &pr.MergedAt,
+        &pr.ClosedAt,
+        &forcedBy,
+        &forceReason,
+        &approvals,
+        &requiredApprovals,
+        &forcedAt,
     )
+    if errors.Is(err, pgx.ErrNoRows) {
+        return pr, ErrPullRequestNotFound
+    }
     if err != nil {
-        return pr, errors.New("pull request not found")
+        return pr, err
     }
 
-    reviewersRows, err := r.pool.Query(ctx,
-        `SELECT reviewer_id FROM pull_request_reviewers WHERE pull_request_id = $1`,
-        prID,
+    if forcedBy != nil {
+        pr.MergeOverride = &models.PullRequestMergeOverride{
+            ForcedBy:          *forcedBy,
+            Approvals:         *approvals,
+            RequiredApprovals: *requiredApprovals,
+            ForcedAt:          forcedAt,
+        }
+        if forceReason != nil {
+            pr.MergeOverride.Reason = *forceReason
+        }
+    }
+
+    reviewersRows, err := r.db(ctx).Query(ctx,
+        `SELECT rev.reviewer_id, rev.verdict, rev.verdict_comment, rev.verdict_at,
+                COALESCE(rev.source, 'team'), COALESCE(st.team_name, $2)
+         FROM pull_request_reviewers rev
+         LEFT JOIN teams st ON st.team_id = rev.source_team_id
+         WHERE rev.pull_request_id = $1
+         ORDER BY rev.assigned_at, rev.reviewer_id`,
+        prID, pr.TeamName,
     )
     if err != nil {
         return pr, err
     }
     defer reviewersRows.Close()
 
-    var reviewers []string
+    reviewers := []string{}
     for reviewersRows.Next() {
+        var reviewerId *string
+        var verdict, comment *string
+        var verdictAt *time.Time
+        var source models.ReviewerSource
+        if err := reviewersRows.Scan(&reviewerId, &verdict, &comment, &verdictAt, &source.Source, &source.TeamName); err != nil {
+            return pr, err
+        }
+
+        // ревьювер удалён: назначения уже нет, но его вердикт остаётся в истории без reviewer_id
         var id string
-        reviewersRows.Scan(&id)
-        reviewers = append(reviewers, id)
+        if reviewerId != nil {
+            id = *reviewerId
+            reviewers = append(reviewers, id)
+
+            source.ReviewerId = id
+            pr.ReviewerSources = append(pr.ReviewerSources, source)
+        }
+
+        if verdict != nil {
+            review := models.PullRequestReview{
+                ReviewerId:  id,
+                Verdict:     *verdict,
+                SubmittedAt: verdictAt,
+            }
+            if comment != nil {
+                review.Comment = *comment
+            }
+            pr.Reviews = append(pr.Reviews, review)
+        }
+    }
+    if err := reviewersRows.Err(); err != nil {
+        return pr, err
     }
 
     pr.AssignedReviewers = reviewers
@@ -138,7 +449,147 @@ func (r *PullRequestRepository) GetByID(ctx context.Context, prID string) (model
     return pr, nil
 }
 
-func (r *PullRequestRepository) SetMerged(ctx context.Context, prID string, mergedAt time.Time) (models.PullRequest, error) {
+var pullRequestSortColumns = map[string]string{
+    "created_at":      `COALESCE(pr.created_at, '-infinity'::timestamptz)`,
+    "merged_at":       `COALESCE(pr.merged_at, '-infinity'::timestamptz)`,
+    "pull_request_id": `pr.pull_request_id`,
+}
+
+// ListPullRequests возвращает страницу PR по фильтру; сортировка всегда дополняется pull_request_id,
+// чтобы keyset-курсор был однозначным
+func (r *PullRequestRepository) ListPullRequests(ctx context.Context, filter internal_models.PullRequestFilter) ([]models.PullRequest, error) {
+    sortExpr, ok := pullRequestSortColumns[filter.SortBy]
+    if !ok {
+        return nil, fmt.Errorf("unsupported sort column %q", filter.SortBy)
+    }
+
+    var conditions []string
+    var args []any
+    addArg := func(v any) string {
+        args = append(args, v)
+        return fmt.Sprintf("$%d", len(args))
+    }
+
+    if len(filter.Statuses) > 0 {
+        conditions = append(conditions, "pr.status = ANY("+addArg(filter.Statuses)+")")
+    }
+    if filter.AuthorID != "" {
+        conditions = append(conditions, "pr.author_id = "+addArg(filter.AuthorID))
+    }
+    if filter.ReviewerID != "" {
+        conditions = append(conditions, `EXISTS (SELECT 1 FROM pull_request_reviewers rev
+            WHERE rev.pull_request_id = pr.pull_request_id AND rev.reviewer_id = `+addArg(filter.ReviewerID)+")")
+    }
+    if filter.TeamName != "" {
+        conditions = append(conditions, "t.team_name = "+addArg(filter.TeamName))
+    }
+    if filter.CreatedFrom != nil {
+        conditions = append(conditions, "pr.created_at >= "+addArg(*filter.CreatedFrom))
+    }
+    if filter.CreatedTo != nil {
+        conditions = append(conditions, "pr.created_at <= "+addArg(*filter.CreatedTo))
+    }
+    if filter.MergedFrom != nil {
+        conditions = append(conditions, "pr.merged_at >= "+addArg(*filter.MergedFrom))
+    }
+    if filter.MergedTo != nil {
+        conditions = append(conditions, "pr.merged_at <= "+addArg(*filter.MergedTo))
+    }
+
+    direction, cmp := "ASC", ">"
+    if filter.Desc {
+        direction, cmp = "DESC", "<"
+    }
+
+    if filter.After != nil {
+        if filter.SortBy == "pull_request_id" {
+            conditions = append(conditions, "pr.pull_request_id "+cmp+" "+addArg(filter.After.ID))
+        } else {
+            conditions = append(conditions, fmt.Sprintf("(%s, pr.pull_request_id) %s (%s::timestamptz, %s)",
+                sortExpr, cmp, addArg(filter.After.Value), addArg(filter.After.ID)))
+        }
+    }
+
+    where := ""
+    if len(conditions) > 0 {
+        where = "WHERE " + strings.Join(conditions, " AND ")
+    }
+
+    orderBy := fmt.Sprintf("%s %s, pr.pull_request_id %s", sortExpr, direction, direction)
+    if filter.SortBy == "pull_request_id" {
+        orderBy = "pr.pull_request_id " + direction
+    }
+
+    query := fmt.Sprintf(`
+        SELECT pr.pull_request_id,
+               pr.pull_request_name,
+               COALESCE(pr.author_id, ''),
+               COALESCE(t.team_name, ''),
+               pr.status,
+               pr.created_at,
+               pr.merged_at,
+               pr.closed_at,
+               ARRAY(SELECT rev.reviewer_id
+                     FROM pull_request_reviewers rev
+                     WHERE rev.pull_request_id = pr.pull_request_id AND rev.reviewer_id IS NOT NULL
+                     ORDER BY rev.assigned_at, rev.reviewer_id)
+        FROM pull_requests pr
+        LEFT JOIN users author ON author.user_id = pr.author_id
+        LEFT JOIN teams t ON t.team_id = COALESCE(pr.team_id, author.team_id)
+        %s
+        ORDER BY %s
+        LIMIT %s
+    `, where, orderBy, addArg(filter.Limit))
+
+    rows, err := r.db(ctx).Query(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    prs := []models.PullRequest{}
+    for rows.Next() {
+        var pr models.PullRequest
+        if err := rows.Scan(
+            &pr.PullRequestId,
+            &pr.PullRequestName,
+            &pr.AuthorId,
+            &pr.TeamName,
+            &pr.Status,
+            &pr.CreatedAt,
+            &pr.MergedAt,
+            &pr.ClosedAt,
+            &pr.AssignedReviewers,
+        ); err != nil {
+            return nil, err
+        }
+        prs = append(prs, pr)
+    }
+
+    return prs, rows.Err()
+}
+
+// UpdateStatus переводит PR из from в to; closed_at выставляется при закрытии и сбрасывается при переоткрытии
+func (r *PullRequestRepository) UpdateStatus(ctx context.Context, prID, from, to string, at time.Time) error {
+    result, err := r.db(ctx).Exec(ctx,
+        `UPDATE pull_requests
+         SET status = $3,
+             closed_at = CASE WHEN $3 = 'CLOSED' THEN $4::timestamptz END
+         WHERE pull_request_id = $1 AND status = $2`,
+        prID, from, to, at,
+    )
+    if err != nil {
+        return err
+    }
+    if result.RowsAffected() == 0 {
+        return fmt.Errorf("pull request is no longer %s", from)
+    }
+
+    return nil
+}
+
+// SetMerged помечает PR как MERGED; override != nil означает принудительный merge и сохраняется для аудита
+func (r *PullRequestRepository) SetMerged(ctx context.Context, prID string, mergedAt time.Time, override *models.PullRequestMergeOverride) (models.PullRequest, error) {
     pr, err := r.GetByID(ctx, prID)
     if err != nil {
         return pr, err
@@ -148,6 +599,12 @@ func (r *PullRequestRepository) SetMerged(ctx context.Context, prID string, merg
         return pr, nil
     }
 
+    tx, err := r.db(ctx).Begin(ctx)
+    if err != nil {
+        return pr, err
+    }
+    defer tx.Rollback(ctx)
+
      query := `
         UPDATE pull_requests
         SET status = 'MERGED',
@@ -155,44 +612,67 @@ func (r *PullRequestRepository) SetMerged(ctx context.Context, prID string, merg
         WHERE pull_request_id = $2
     `
 
-    _, err = r.pool.Exec(ctx, query, mergedAt, prID)
+    _, err = tx.Exec(ctx, query, mergedAt, prID)
     if err != nil {
         return pr, err
     }
 
+    if override != nil {
+        _, err = tx.Exec(ctx,
+            `INSERT INTO merge_overrides (pull_request_id, forced_by, reason, approvals, required_approvals, forced_at)
+             VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)`,
+            prID, override.ForcedBy, override.Reason, override.Approvals, override.RequiredApprovals, mergedAt,
+        )
+        if err != nil {
+            return pr, err
+        }
+        override.ForcedAt = &mergedAt
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return pr, err
+    }
+
     pr.Status = "MERGED"
     pr.MergedAt = &mergedAt
+    pr.MergeOverride = override
 
     return pr, nil
 }
 
-func (r *PullRequestRepository) FindReplacement(ctx context.Context, oldReviewerID string, teamName int) (string, error) {
-    var newUserId string
-
+func (r *PullRequestRepository) ReplaceReviewer(ctx context.Context, prID, oldReviewer string, newReviewer internal_models.SelectedReviewer) error {
     query := `
-        SELECT user_id
-        FROM users
-        WHERE team_id = $1
-          AND user_id <> $2
-          AND is_active = TRUE
-        LIMIT 1
+        UPDATE pull_request_reviewers
+        SET reviewer_id = $1,
+            assigned_at = NOW(),
+            verdict = NULL,
+            verdict_comment = NULL,
+            verdict_at = NULL,
+            source = $4,
+            source_team_id = $5
+        WHERE pull_request_id = $2 AND reviewer_id = $3
     `
-
-    err := r.pool.QueryRow(ctx, query, teamName, oldReviewerID).Scan(&newUserId)
+    source, sourceTeamId := reviewerSourceColumns(newReviewer)
+    result, err := r.db(ctx).Exec(ctx, query, newReviewer.UserID, prID, oldReviewer, source, sourceTeamId)
     if err != nil {
-        return "", errors.New("no active replacement candidate in team")
+        return err
+    }
+    if result.RowsAffected() == 0 {
+        return errors.New("reviewer is not assigned to this PR")
     }
 
-    return newUserId, nil
+    return nil
 }
 
-func (r *PullRequestRepository) ReplaceReviewer(ctx context.Context, prID, oldReviewer, newReviewer string) error {
-    query := `
-        UPDATE pull_request_reviewers
-        SET reviewer_id = $1
-        WHERE pull_request_id = $2 AND reviewer_id = $3
-    `
-    result, err := r.pool.Exec(ctx, query, newReviewer, prID, oldReviewer)
+func (r *PullRequestRepository) SubmitReview(ctx context.Context, prID, reviewerID, verdict, comment string, submittedAt time.Time) error {
+    result, err := r.db(ctx).Exec(ctx,
+        `UPDATE pull_request_reviewers
+         SET verdict = $1,
+             verdict_comment = NULLIF($2, ''),
+             verdict_at = $3
+         WHERE pull_request_id = $4 AND reviewer_id = $5`,
+        verdict, comment, submittedAt, prID, reviewerID,
+    )
     if err != nil {
         return err
     }
@@ -201,4 +681,4 @@ func (r *PullRequestRepository) ReplaceReviewer(ctx context.Context, prID, oldRe
     }
 
     return nil
-}
\ No newline at end of file
+}
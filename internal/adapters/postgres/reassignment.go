@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+type ReassignmentPlanner = internal_models.ReassignmentPlanner
+
+// reassignOpenReviews переназначает OPEN-ревью пользователей userIds в рамках транзакции tx:
+// во всех PR или, если задан prIds, только в перечисленных.
+// Замены берутся из пулов команды PR (явно указанной при создании или основной команды автора):
+// самой команды, её запасных и вышестоящих команд, как при обычном назначении; источник замены
+// сохраняется в source/source_team_id. Сами userIds планировщик в замену не выбирает.
+// Каждое изменение попадает в историю PR с автором действия actorId и причиной reason.
+func reassignOpenReviews(ctx context.Context, tx pgx.Tx, userIds []string, prIds []string, plan ReassignmentPlanner, actorId, reason string) ([]internal_models.ReviewerReassignment, error) {
+    // блокируем затронутые PR, чтобы параллельные merge/reassign не меняли их до коммита
+    _, err := tx.Exec(ctx,
+        `SELECT pr.pull_request_id
+         FROM pull_requests pr
+         WHERE pr.status = 'OPEN'
+           AND ($2::text[] IS NULL OR pr.pull_request_id = ANY($2))
+           AND EXISTS (SELECT 1 FROM pull_request_reviewers rev
+                       WHERE rev.pull_request_id = pr.pull_request_id AND rev.reviewer_id = ANY($1))
+         ORDER BY pr.pull_request_id
+         FOR UPDATE OF pr`,
+        userIds, prIds,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err := tx.Query(ctx,
+        `SELECT rev.pull_request_id,
+                rev.reviewer_id,
+                pr.author_id,
+                COALESCE(pr.team_id, author.team_id, 0),
+                ARRAY(SELECT all_rev.reviewer_id FROM pull_request_reviewers all_rev
+                      WHERE all_rev.pull_request_id = pr.pull_request_id AND all_rev.reviewer_id IS NOT NULL)
+         FROM pull_request_reviewers rev
+         JOIN pull_requests pr ON pr.pull_request_id = rev.pull_request_id
+         LEFT JOIN users author ON author.user_id = pr.author_id
+         WHERE rev.reviewer_id = ANY($1) AND pr.status = 'OPEN'
+           AND ($2::text[] IS NULL OR pr.pull_request_id = ANY($2))
+         ORDER BY rev.pull_request_id, rev.reviewer_id`,
+        userIds, prIds,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    snapshot := internal_models.ReassignmentSnapshot{
+        Pools:      map[int][]internal_models.ReviewerPool{},
+        Candidates: map[int][]internal_models.ReviewerCandidate{},
+    }
+    teamIds := []int{}
+    for rows.Next() {
+        var a internal_models.OpenReviewAssignment
+        if err := rows.Scan(&a.PullRequestID, &a.ReviewerID, &a.AuthorID, &a.TeamID, &a.Reviewers); err != nil {
+            rows.Close()
+            return nil, err
+        }
+        snapshot.Assignments = append(snapshot.Assignments, a)
+        if _, seen := snapshot.Pools[a.TeamID]; !seen && a.TeamID != 0 {
+            snapshot.Pools[a.TeamID] = nil
+            teamIds = append(teamIds, a.TeamID)
+        }
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    if len(snapshot.Assignments) == 0 {
+        return []internal_models.ReviewerReassignment{}, nil
+    }
+
+    poolTeamIds := []int{}
+    seenPools := map[int]bool{}
+    for _, teamId := range teamIds {
+        pools, err := reviewerPools(ctx, tx, teamId)
+        if err != nil {
+            return nil, err
+        }
+        snapshot.Pools[teamId] = pools
+        for _, pool := range pools {
+            if !seenPools[pool.TeamID] {
+                seenPools[pool.TeamID] = true
+                poolTeamIds = append(poolTeamIds, pool.TeamID)
+            }
+        }
+    }
+
+    candidateRows, err := tx.Query(ctx,
+        `SELECT tm.team_id,
+                u.user_id,
+                u.review_weight,
+                COUNT(pr.pull_request_id) AS open_reviews,
+                MAX(rev.assigned_at) AS last_assigned_at
+         FROM team_members tm
+         JOIN users u ON u.user_id = tm.user_id
+         LEFT JOIN pull_request_reviewers rev ON rev.reviewer_id = u.user_id
+         LEFT JOIN pull_requests pr ON pr.pull_request_id = rev.pull_request_id AND pr.status = 'OPEN'
+         WHERE tm.team_id = ANY($1) AND u.is_active = TRUE
+         GROUP BY tm.team_id, u.user_id, u.review_weight`,
+        poolTeamIds,
+    )
+    if err != nil {
+        return nil, err
+    }
+    for candidateRows.Next() {
+        var teamId int
+        var c internal_models.ReviewerCandidate
+        if err := candidateRows.Scan(&teamId, &c.UserID, &c.Weight, &c.OpenReviews, &c.LastAssignedAt); err != nil {
+            candidateRows.Close()
+            return nil, err
+        }
+        snapshot.Candidates[teamId] = append(snapshot.Candidates[teamId], c)
+    }
+    candidateRows.Close()
+    if err := candidateRows.Err(); err != nil {
+        return nil, err
+    }
+
+    decisions := plan(snapshot)
+
+    var replacedPRs, replacedOld, replacedNew, removedPRs, removedOld []string
+    var replacedSources []*string
+    var replacedSourceTeams []*int
+    events := make([]models.PullRequestEvent, 0, len(decisions))
+    for _, d := range decisions {
+        if d.NewReviewerID == "" {
+            removedPRs = append(removedPRs, d.PullRequestID)
+            removedOld = append(removedOld, d.OldReviewerID)
+            events = append(events, models.PullRequestEvent{
+                PullRequestId: d.PullRequestID,
+                Type:          "unassigned",
+                ActorId:       actorId,
+                ReviewerId:    d.OldReviewerID,
+                Reason:        reason,
+            })
+            continue
+        }
+        replacedPRs = append(replacedPRs, d.PullRequestID)
+        replacedOld = append(replacedOld, d.OldReviewerID)
+        replacedNew = append(replacedNew, d.NewReviewerID)
+        source, sourceTeamId := reviewerSourceColumns(internal_models.SelectedReviewer{
+            UserID: d.NewReviewerID,
+            TeamID: d.SourceTeamID,
+            Source: d.Source,
+        })
+        replacedSources = append(replacedSources, source)
+        replacedSourceTeams = append(replacedSourceTeams, sourceTeamId)
+        events = append(events, models.PullRequestEvent{
+            PullRequestId: d.PullRequestID,
+            Type:          "reassigned",
+            ActorId:       actorId,
+            OldReviewerId: d.OldReviewerID,
+            NewReviewerId: d.NewReviewerID,
+            Reason:        reason,
+        })
+    }
+
+    if len(replacedPRs) > 0 {
+        _, err = tx.Exec(ctx,
+            `UPDATE pull_request_reviewers rev
+             SET reviewer_id = v.new_reviewer_id,
+                 assigned_at = NOW(),
+                 verdict = NULL,
+                 verdict_comment = NULL,
+                 verdict_at = NULL,
+                 source = v.source,
+                 source_team_id = v.source_team_id
+             FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::int[])
+                  AS v(pull_request_id, old_reviewer_id, new_reviewer_id, source, source_team_id)
+             WHERE rev.pull_request_id = v.pull_request_id AND rev.reviewer_id = v.old_reviewer_id`,
+            replacedPRs, replacedOld, replacedNew, replacedSources, replacedSourceTeams,
+        )
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if len(removedPRs) > 0 {
+        _, err = tx.Exec(ctx,
+            `DELETE FROM pull_request_reviewers rev
+             USING unnest($1::text[], $2::text[]) AS v(pull_request_id, reviewer_id)
+             WHERE rev.pull_request_id = v.pull_request_id AND rev.reviewer_id = v.reviewer_id`,
+            removedPRs, removedOld,
+        )
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if err := insertEvents(ctx, tx, events); err != nil {
+        return nil, err
+    }
+
+    return decisions, nil
+}
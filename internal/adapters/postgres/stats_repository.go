@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+)
+
+type StatsRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewStatsRepository(pool *pgxpool.Pool) *StatsRepository {
+	return &StatsRepository{pool: pool}
+}
+
+// все выборки ограничены PR, созданными в [from, to]; nil - без ограничения
+
+func (r *StatsRepository) GetReviewerStats(ctx context.Context, from, to *time.Time) ([]models.ReviewerStats, error) {
+    rows, err := r.pool.Query(ctx, `
+        SELECT rev.reviewer_id,
+               COUNT(*),
+               COUNT(*) FILTER (WHERE pr.status = 'OPEN'),
+               COUNT(*) FILTER (WHERE pr.status = 'MERGED')
+        FROM pull_request_reviewers rev
+        JOIN pull_requests pr ON pr.pull_request_id = rev.pull_request_id
+        WHERE rev.reviewer_id IS NOT NULL
+          AND ($1::timestamptz IS NULL OR pr.created_at >= $1)
+          AND ($2::timestamptz IS NULL OR pr.created_at <= $2)
+        GROUP BY rev.reviewer_id
+        ORDER BY rev.reviewer_id
+    `, from, to)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    stats := []models.ReviewerStats{}
+    for rows.Next() {
+        var s models.ReviewerStats
+        if err := rows.Scan(&s.UserId, &s.Assigned, &s.Open, &s.Merged); err != nil {
+            return nil, err
+        }
+        stats = append(stats, s)
+    }
+
+    return stats, rows.Err()
+}
+
+func (r *StatsRepository) GetTeamStats(ctx context.Context, from, to *time.Time) ([]models.TeamStats, error) {
+    rows, err := r.pool.Query(ctx, `
+        SELECT t.team_name,
+               COUNT(DISTINCT pr.pull_request_id),
+               COUNT(DISTINCT pr.pull_request_id) FILTER (WHERE pr.status = 'OPEN'),
+               COUNT(DISTINCT pr.pull_request_id) FILTER (WHERE pr.status = 'MERGED'),
+               COUNT(rev.id)
+        FROM teams t
+        LEFT JOIN (pull_requests pr LEFT JOIN users author ON author.user_id = pr.author_id)
+             ON COALESCE(pr.team_id, author.team_id) = t.team_id
+             AND ($1::timestamptz IS NULL OR pr.created_at >= $1)
+             AND ($2::timestamptz IS NULL OR pr.created_at <= $2)
+        LEFT JOIN pull_request_reviewers rev ON rev.pull_request_id = pr.pull_request_id
+        GROUP BY t.team_name
+        ORDER BY t.team_name
+    `, from, to)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    stats := []models.TeamStats{}
+    for rows.Next() {
+        var s models.TeamStats
+        if err := rows.Scan(&s.TeamName, &s.PullRequests, &s.Open, &s.Merged, &s.Assignments); err != nil {
+            return nil, err
+        }
+        stats = append(stats, s)
+    }
+
+    return stats, rows.Err()
+}
+
+func (r *StatsRepository) GetAuthorStats(ctx context.Context, from, to *time.Time) ([]models.AuthorStats, error) {
+    rows, err := r.pool.Query(ctx, `
+        SELECT pr.author_id,
+               COUNT(*),
+               COUNT(*) FILTER (WHERE pr.status = 'OPEN'),
+               COUNT(*) FILTER (WHERE pr.status = 'MERGED')
+        FROM pull_requests pr
+        WHERE pr.author_id IS NOT NULL
+          AND ($1::timestamptz IS NULL OR pr.created_at >= $1)
+          AND ($2::timestamptz IS NULL OR pr.created_at <= $2)
+        GROUP BY pr.author_id
+        ORDER BY pr.author_id
+    `, from, to)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    stats := []models.AuthorStats{}
+    for rows.Next() {
+        var s models.AuthorStats
+        if err := rows.Scan(&s.UserId, &s.PullRequests, &s.Open, &s.Merged); err != nil {
+            return nil, err
+        }
+        stats = append(stats, s)
+    }
+
+    return stats, rows.Err()
+}
@@ -3,17 +3,30 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	api_models "github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
 )
 
 type TeamRepository struct {
 	pool *pgxpool.Pool
 }
 
-var ErrTeamNotFound = errors.New("team not found")
+var ErrTeamNotFound = internal_models.ErrTeamNotFound
+var ErrTeamExists = internal_models.ErrTeamExists
+
+// uniqueViolation - SQLSTATE нарушения уникального ограничения
+const uniqueViolation = "23505"
+var ErrMemberExists = internal_models.ErrMemberExists
+var ErrNotTeamMember = internal_models.ErrNotTeamMember
+var ErrTeamNotEmpty = internal_models.ErrTeamNotEmpty
+var ErrTeamHierarchyCycle = internal_models.ErrTeamHierarchyCycle
+var ErrInvalidFallbackTeam = internal_models.ErrInvalidFallbackTeam
 
 func NewTeamRepository(pool *pgxpool.Pool) *TeamRepository {
 	return &TeamRepository{pool: pool}
@@ -45,22 +58,28 @@ func (r *TeamRepository) CreateTeam(ctx context.Context, team api_models.Team) (
     defer tx.Rollback(ctx)
 
     err = tx.QueryRow(ctx,
-        `INSERT INTO teams (team_name) VALUES ($1) RETURNING team_id`,
-        team.TeamName,
+        `INSERT INTO teams (team_name, assignment_strategy, min_reviewers, max_reviewers, required_approvals)
+         VALUES ($1, $2, $3, $4, $5)
+         RETURNING team_id`,
+        team.TeamName, team.AssignmentStrategy, team.MinReviewers, team.MaxReviewers, team.RequiredApprovals,
     ).Scan(&teamId)
     if err != nil {
         return api_models.Team{}, err
     }
 
     for _, m := range team.Members {
-        _, err := tx.Exec(ctx,
-            `INSERT INTO users (user_id, username, is_active, team_id)
-             VALUES ($1, $2, $3, $4)
-             ON CONFLICT (user_id)
-             DO UPDATE SET username = EXCLUDED.username, is_active = EXCLUDED.is_active, team_id = EXCLUDED.team_id`,
-            m.UserId, m.Username, m.IsActive, teamId,
-        )
-        if err != nil {
+        if err := upsertMember(ctx, tx, int(teamId), m); err != nil {
+            return api_models.Team{}, err
+        }
+    }
+
+    if team.ParentTeamName != "" {
+        if err := setParentTeam(ctx, tx, int(teamId), team.ParentTeamName); err != nil {
+            return api_models.Team{}, err
+        }
+    }
+    if len(team.FallbackTeamNames) > 0 {
+        if err := setFallbackTeams(ctx, tx, int(teamId), team.FallbackTeamNames); err != nil {
             return api_models.Team{}, err
         }
     }
@@ -77,20 +96,37 @@ func (r *TeamRepository) GetTeamByName(ctx context.Context, teamName string) (ap
 	var teamId int
 
     err := r.pool.QueryRow(ctx,
-        `SELECT team_id, team_name 
-         FROM teams 
-         WHERE team_name = $1`,
+        `SELECT t.team_id, t.team_name, t.assignment_strategy, t.min_reviewers, t.max_reviewers, t.required_approvals,
+                COALESCE(parent.team_name, ''),
+                ARRAY(SELECT fb.team_name
+                      FROM team_fallbacks tf
+                      JOIN teams fb ON fb.team_id = tf.fallback_team_id
+                      WHERE tf.team_id = t.team_id
+                      ORDER BY tf.position)
+         FROM teams t
+         LEFT JOIN teams parent ON parent.team_id = t.parent_team_id
+         WHERE t.team_name = $1`,
         teamName,
-    ).Scan(&teamId, &team.TeamName)
+    ).Scan(&teamId, &team.TeamName, &team.AssignmentStrategy, &team.MinReviewers, &team.MaxReviewers, &team.RequiredApprovals,
+        &team.ParentTeamName, &team.FallbackTeamNames)
 
     if err != nil {
         return api_models.Team{}, err
     }
 
     rows, err := r.pool.Query(ctx,
-        `SELECT user_id, username, is_active
-         FROM users
-         WHERE team_id = $1`,
+        `SELECT u.user_id, u.username, u.is_active, u.review_weight,
+                COALESCE(primary_team.team_name, ''),
+                ARRAY(SELECT other.team_name
+                      FROM team_members other_tm
+                      JOIN teams other ON other.team_id = other_tm.team_id
+                      WHERE other_tm.user_id = u.user_id AND other_tm.team_id <> $1
+                      ORDER BY other.team_name)
+         FROM team_members tm
+         JOIN users u ON u.user_id = tm.user_id
+         LEFT JOIN teams primary_team ON primary_team.team_id = u.team_id
+         WHERE tm.team_id = $1
+         ORDER BY u.user_id`,
         teamId,
     )
     if err != nil {
@@ -102,7 +138,7 @@ func (r *TeamRepository) GetTeamByName(ctx context.Context, teamName string) (ap
 
     for rows.Next() {
         var m api_models.TeamMember
-        if err := rows.Scan(&m.UserId, &m.Username, &m.IsActive); err != nil {
+        if err := rows.Scan(&m.UserId, &m.Username, &m.IsActive, &m.ReviewWeight, &m.PrimaryTeam, &m.OtherTeams); err != nil {
             return api_models.Team{}, err
         }
         members = append(members, m)
@@ -111,4 +147,500 @@ func (r *TeamRepository) GetTeamByName(ctx context.Context, teamName string) (ap
     team.Members = members
 
     return team, nil
-}
\ No newline at end of file
+}
+
+func (r *TeamRepository) UpdateTeamSettings(ctx context.Context, req api_models.TeamUpdateSettingsPostRequest) error {
+    tx, err := r.pool.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    var teamId int
+    err = tx.QueryRow(ctx,
+        `UPDATE teams
+         SET assignment_strategy = COALESCE($2, assignment_strategy),
+             min_reviewers = COALESCE($3, min_reviewers),
+             max_reviewers = COALESCE($4, max_reviewers),
+             required_approvals = COALESCE($5, required_approvals)
+         WHERE team_name = $1
+         RETURNING team_id`,
+        req.TeamName, req.AssignmentStrategy, req.MinReviewers, req.MaxReviewers, req.RequiredApprovals,
+    ).Scan(&teamId)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return ErrTeamNotFound
+    }
+    if err != nil {
+        return err
+    }
+
+    if req.ParentTeamName != nil {
+        if err := setParentTeam(ctx, tx, teamId, *req.ParentTeamName); err != nil {
+            return err
+        }
+    }
+    if req.FallbackTeamNames != nil {
+        if err := setFallbackTeams(ctx, tx, teamId, *req.FallbackTeamNames); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit(ctx)
+}
+
+// setParentTeam назначает команде вышестоящую (пустое имя - убрать) и не допускает циклов в иерархии
+func setParentTeam(ctx context.Context, tx pgx.Tx, teamId int, parentTeamName string) error {
+    var parentTeamId *int
+    if parentTeamName != "" {
+        id, err := lookupTeamId(ctx, tx, parentTeamName)
+        if err != nil {
+            return fmt.Errorf("%w: parent team %s", err, parentTeamName)
+        }
+
+        var cycle bool
+        err = tx.QueryRow(ctx, `
+            WITH RECURSIVE ancestors AS (
+                SELECT team_id, parent_team_id FROM teams WHERE team_id = $1
+              UNION
+                SELECT t.team_id, t.parent_team_id
+                FROM teams t
+                JOIN ancestors a ON t.team_id = a.parent_team_id
+            )
+            SELECT EXISTS(SELECT 1 FROM ancestors WHERE team_id = $2)
+        `, id, teamId).Scan(&cycle)
+        if err != nil {
+            return err
+        }
+        if cycle {
+            return ErrTeamHierarchyCycle
+        }
+        parentTeamId = &id
+    }
+
+    _, err := tx.Exec(ctx, `UPDATE teams SET parent_team_id = $2 WHERE team_id = $1`, teamId, parentTeamId)
+    return err
+}
+
+// setFallbackTeams заменяет список запасных команд; порядок в списке - порядок опроса
+func setFallbackTeams(ctx context.Context, tx pgx.Tx, teamId int, fallbackTeamNames []string) error {
+    _, err := tx.Exec(ctx, `DELETE FROM team_fallbacks WHERE team_id = $1`, teamId)
+    if err != nil {
+        return err
+    }
+
+    for i, name := range fallbackTeamNames {
+        fallbackTeamId, err := lookupTeamId(ctx, tx, name)
+        if err != nil {
+            return fmt.Errorf("%w: fallback team %s", err, name)
+        }
+        if fallbackTeamId == teamId {
+            return ErrInvalidFallbackTeam
+        }
+
+        _, err = tx.Exec(ctx,
+            `INSERT INTO team_fallbacks (team_id, fallback_team_id, position) VALUES ($1, $2, $3)
+             ON CONFLICT (team_id, fallback_team_id) DO NOTHING`,
+            teamId, fallbackTeamId, i,
+        )
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// AddMember добавляет пользователя в команду, создавая его при необходимости.
+// Для пользователя без команды она становится основной, иначе - дополнительной
+func (r *TeamRepository) AddMember(ctx context.Context, teamName string, member api_models.TeamMember) error {
+    tx, err := r.pool.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    teamId, err := lookupTeamId(ctx, tx, teamName)
+    if err != nil {
+        return err
+    }
+
+    _, err = lockUserTeam(ctx, tx, member.UserId)
+    if err != nil && !errors.Is(err, ErrUserNotFound) {
+        return err
+    }
+
+    isMember, err := isTeamMember(ctx, tx, teamId, member.UserId)
+    if err != nil {
+        return err
+    }
+    if isMember {
+        return ErrMemberExists
+    }
+
+    if err := upsertMember(ctx, tx, teamId, member); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// RemoveMember исключает пользователя из команды. Если это была его основная команда,
+// основной становится другая из оставшихся. Если передан plan, его OPEN-ревью в PR этой команды
+// в той же транзакции переназначаются на оставшихся участников
+func (r *TeamRepository) RemoveMember(ctx context.Context, teamName string, userId string, actorId string, plan ReassignmentPlanner) ([]internal_models.ReviewerReassignment, error) {
+    tx, err := r.pool.Begin(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    teamId, err := lookupTeamId(ctx, tx, teamName)
+    if err != nil {
+        return nil, err
+    }
+
+    if _, err := lockUserTeam(ctx, tx, userId); err != nil {
+        return nil, err
+    }
+
+    if err := leaveTeam(ctx, tx, teamId, userId); err != nil {
+        return nil, err
+    }
+
+    decisions := []internal_models.ReviewerReassignment{}
+    if plan != nil {
+        prIds, err := teamOpenPullRequests(ctx, tx, teamId)
+        if err != nil {
+            return nil, err
+        }
+        decisions, err = reassignOpenReviews(ctx, tx, []string{userId}, prIds, plan, actorId, "member removed from team")
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, err
+    }
+
+    return decisions, nil
+}
+
+// MoveMember переводит пользователя из fromTeamName (по умолчанию - основной команды) в toTeamName
+// и возвращает имя прежней команды. Если прежняя команда была основной, основной становится новая.
+// Если передан plan, OPEN-ревью пользователя в PR прежней команды переназначаются внутри неё
+func (r *TeamRepository) MoveMember(ctx context.Context, userId string, fromTeamName string, toTeamName string, actorId string, plan ReassignmentPlanner) (string, []internal_models.ReviewerReassignment, error) {
+    tx, err := r.pool.Begin(ctx)
+    if err != nil {
+        return "", nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    primaryTeamId, err := lockUserTeam(ctx, tx, userId)
+    if err != nil {
+        return "", nil, err
+    }
+
+    var fromTeamId int
+    if fromTeamName != "" {
+        fromTeamId, err = lookupTeamId(ctx, tx, fromTeamName)
+        if err != nil {
+            return "", nil, err
+        }
+    } else {
+        if primaryTeamId == nil {
+            return "", nil, ErrNotTeamMember
+        }
+        fromTeamId = *primaryTeamId
+        err = tx.QueryRow(ctx, `SELECT team_name FROM teams WHERE team_id = $1`, fromTeamId).Scan(&fromTeamName)
+        if err != nil {
+            return "", nil, err
+        }
+    }
+
+    toTeamId, err := lookupTeamId(ctx, tx, toTeamName)
+    if err != nil {
+        return "", nil, err
+    }
+
+    isMember, err := isTeamMember(ctx, tx, toTeamId, userId)
+    if err != nil {
+        return "", nil, err
+    }
+    if isMember {
+        return "", nil, ErrMemberExists
+    }
+
+    _, err = tx.Exec(ctx, `INSERT INTO team_members (team_id, user_id) VALUES ($1, $2)`, toTeamId, userId)
+    if err != nil {
+        return "", nil, err
+    }
+
+    if err := leaveTeam(ctx, tx, fromTeamId, userId); err != nil {
+        return "", nil, err
+    }
+
+    if primaryTeamId != nil && *primaryTeamId == fromTeamId {
+        _, err = tx.Exec(ctx, `UPDATE users SET team_id = $2 WHERE user_id = $1`, userId, toTeamId)
+        if err != nil {
+            return "", nil, err
+        }
+    }
+
+    decisions := []internal_models.ReviewerReassignment{}
+    if plan != nil {
+        prIds, err := teamOpenPullRequests(ctx, tx, fromTeamId)
+        if err != nil {
+            return "", nil, err
+        }
+        decisions, err = reassignOpenReviews(ctx, tx, []string{userId}, prIds, plan, actorId, "member moved to another team")
+        if err != nil {
+            return "", nil, err
+        }
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return "", nil, err
+    }
+
+    return fromTeamName, decisions, nil
+}
+
+// RenameTeam меняет имя команды; пользователи и PR ссылаются на team_id, поэтому больше ничего не меняется.
+// Занятое имя отсекает уникальный индекс teams.team_name: проверка заранее пропустила бы параллельное переименование
+func (r *TeamRepository) RenameTeam(ctx context.Context, teamName string, newTeamName string) error {
+    tx, err := r.pool.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    teamId, err := lookupTeamId(ctx, tx, teamName)
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(ctx, `UPDATE teams SET team_name = $2 WHERE team_id = $1`, teamId, newTeamName)
+    var pgErr *pgconn.PgError
+    if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+        return ErrTeamExists
+    }
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// DeleteTeam удаляет команду. Участников переводит в moveToTeamName, а если оно пустое - просто исключает
+// (основной становится другая их команда, если есть); при refuse непустая команда не удаляется.
+// PR команды переходят в moveToTeamName, иначе ревьюверы для них берутся из основной команды автора.
+// Если передан plan, OPEN-ревью участников в этих PR переназначаются из нового пула
+func (r *TeamRepository) DeleteTeam(ctx context.Context, teamName string, refuse bool, moveToTeamName string, actorId string, plan ReassignmentPlanner) (internal_models.TeamDeletion, error) {
+    deletion := internal_models.TeamDeletion{
+        Members:          []string{},
+        OpenPullRequests: []string{},
+        Reassignments:    []internal_models.ReviewerReassignment{},
+    }
+
+    tx, err := r.pool.Begin(ctx)
+    if err != nil {
+        return deletion, err
+    }
+    defer tx.Rollback(ctx)
+
+    var teamId int
+    err = tx.QueryRow(ctx, `SELECT team_id FROM teams WHERE team_name = $1 FOR UPDATE`, teamName).Scan(&teamId)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return deletion, ErrTeamNotFound
+    }
+    if err != nil {
+        return deletion, err
+    }
+
+    rows, err := tx.Query(ctx,
+        `SELECT u.user_id
+         FROM team_members tm
+         JOIN users u ON u.user_id = tm.user_id
+         WHERE tm.team_id = $1
+         ORDER BY u.user_id
+         FOR UPDATE OF u`,
+        teamId,
+    )
+    if err != nil {
+        return deletion, err
+    }
+    deletion.Members, err = pgx.CollectRows(rows, pgx.RowTo[string])
+    if err != nil {
+        return deletion, err
+    }
+
+    if len(deletion.Members) > 0 && refuse {
+        return deletion, ErrTeamNotEmpty
+    }
+
+    deletion.OpenPullRequests, err = teamOpenPullRequests(ctx, tx, teamId)
+    if err != nil {
+        return deletion, err
+    }
+
+    if moveToTeamName != "" {
+        toTeamId, err := lookupTeamId(ctx, tx, moveToTeamName)
+        if err != nil {
+            return deletion, err
+        }
+        if toTeamId == teamId {
+            return deletion, ErrMemberExists
+        }
+
+        _, err = tx.Exec(ctx,
+            `INSERT INTO team_members (team_id, user_id)
+             SELECT $2, user_id FROM team_members WHERE team_id = $1
+             ON CONFLICT DO NOTHING`,
+            teamId, toTeamId,
+        )
+        if err != nil {
+            return deletion, err
+        }
+
+        _, err = tx.Exec(ctx, `UPDATE users SET team_id = $2 WHERE team_id = $1`, teamId, toTeamId)
+        if err != nil {
+            return deletion, err
+        }
+
+        // PR, созданные по основной команде автора, тоже явно привязываем к новой команде
+        _, err = tx.Exec(ctx,
+            `UPDATE pull_requests SET team_id = $2
+             WHERE pull_request_id = ANY($1)`,
+            deletion.OpenPullRequests, toTeamId,
+        )
+        if err != nil {
+            return deletion, err
+        }
+    }
+
+    _, err = tx.Exec(ctx, `DELETE FROM team_members WHERE team_id = $1`, teamId)
+    if err != nil {
+        return deletion, err
+    }
+
+    _, err = tx.Exec(ctx,
+        `UPDATE users u
+         SET team_id = (SELECT MIN(tm.team_id) FROM team_members tm WHERE tm.user_id = u.user_id)
+         WHERE u.team_id = $1`,
+        teamId,
+    )
+    if err != nil {
+        return deletion, err
+    }
+
+    // pull_requests.team_id обнуляется по ON DELETE SET NULL
+    _, err = tx.Exec(ctx, `DELETE FROM teams WHERE team_id = $1`, teamId)
+    if err != nil {
+        return deletion, err
+    }
+
+    if plan != nil && len(deletion.Members) > 0 {
+        deletion.Reassignments, err = reassignOpenReviews(ctx, tx, deletion.Members, deletion.OpenPullRequests, plan, actorId, "team deleted")
+        if err != nil {
+            return deletion, err
+        }
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return deletion, err
+    }
+
+    return deletion, nil
+}
+
+// rowQuerier - общее у *pgxpool.Pool и pgx.Tx для запросов одной строки
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func lookupTeamId(ctx context.Context, db rowQuerier, teamName string) (int, error) {
+    var teamId int
+    err := db.QueryRow(ctx, `SELECT team_id FROM teams WHERE team_name = $1`, teamName).Scan(&teamId)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return 0, ErrTeamNotFound
+    }
+    return teamId, err
+}
+
+// lockUserTeam блокирует строку пользователя до конца транзакции и возвращает его основную команду (nil - без команды)
+func lockUserTeam(ctx context.Context, tx pgx.Tx, userId string) (*int, error) {
+    var teamId *int
+    err := tx.QueryRow(ctx, `SELECT team_id FROM users WHERE user_id = $1 FOR UPDATE`, userId).Scan(&teamId)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, ErrUserNotFound
+    }
+    return teamId, err
+}
+
+func isTeamMember(ctx context.Context, tx pgx.Tx, teamId int, userId string) (bool, error) {
+    var exists bool
+    err := tx.QueryRow(ctx,
+        `SELECT EXISTS(SELECT 1 FROM team_members WHERE team_id = $1 AND user_id = $2)`,
+        teamId, userId,
+    ).Scan(&exists)
+    return exists, err
+}
+
+// upsertMember создаёт или обновляет пользователя и добавляет его в команду;
+// основная команда меняется, только если её ещё не было
+func upsertMember(ctx context.Context, tx pgx.Tx, teamId int, m api_models.TeamMember) error {
+    _, err := tx.Exec(ctx,
+        `INSERT INTO users (user_id, username, is_active, team_id, review_weight)
+         VALUES ($1, $2, $3, $4, $5)
+         ON CONFLICT (user_id)
+         DO UPDATE SET username = EXCLUDED.username, is_active = EXCLUDED.is_active,
+                       team_id = COALESCE(users.team_id, EXCLUDED.team_id),
+                       review_weight = EXCLUDED.review_weight`,
+        m.UserId, m.Username, m.IsActive, teamId, m.ReviewWeight,
+    )
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(ctx,
+        `INSERT INTO team_members (team_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+        teamId, m.UserId,
+    )
+    return err
+}
+
+// leaveTeam исключает пользователя из команды; если она была основной, основной становится другая его команда
+func leaveTeam(ctx context.Context, tx pgx.Tx, teamId int, userId string) error {
+    result, err := tx.Exec(ctx, `DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`, teamId, userId)
+    if err != nil {
+        return err
+    }
+    if result.RowsAffected() == 0 {
+        return ErrNotTeamMember
+    }
+
+    _, err = tx.Exec(ctx,
+        `UPDATE users
+         SET team_id = (SELECT MIN(team_id) FROM team_members WHERE user_id = $2)
+         WHERE user_id = $2 AND team_id = $1`,
+        teamId, userId,
+    )
+    return err
+}
+
+// teamOpenPullRequests возвращает DRAFT/OPEN PR, ревьюверы которых назначаются из команды teamId
+func teamOpenPullRequests(ctx context.Context, tx pgx.Tx, teamId int) ([]string, error) {
+    rows, err := tx.Query(ctx,
+        `SELECT pr.pull_request_id
+         FROM pull_requests pr
+         LEFT JOIN users author ON author.user_id = pr.author_id
+         WHERE COALESCE(pr.team_id, author.team_id) = $1 AND pr.status IN ('DRAFT', 'OPEN')
+         ORDER BY pr.pull_request_id`,
+        teamId,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return pgx.CollectRows(rows, pgx.RowTo[string])
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// querier - общее у *pgxpool.Pool и pgx.Tx. Begin внутри транзакции создаёт savepoint,
+// поэтому методы репозиториев со своей транзакцией работают и внутри UnitOfWork
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type txKey struct{}
+
+// UnitOfWork выполняет несколько вызовов репозиториев в одной транзакции:
+// транзакция передаётся через ctx, и репозитории берут её вместо пула
+type UnitOfWork struct {
+	pool *pgxpool.Pool
+}
+
+func NewUnitOfWork(pool *pgxpool.Pool) *UnitOfWork {
+	return &UnitOfWork{pool: pool}
+}
+
+// Do выполняет fn в транзакции и фиксирует её, если fn не вернула ошибку.
+// Вложенный Do присоединяется к внешней транзакции
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+    if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+        return fn(ctx)
+    }
+
+    tx, err := u.pool.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// conn возвращает транзакцию из ctx, если вызов идёт внутри UnitOfWork, иначе пул
+func conn(ctx context.Context, pool *pgxpool.Pool) querier {
+    if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+        return tx
+    }
+    return pool
+}
@@ -2,23 +2,46 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
 )
 
 type UserRepository struct {
 	pool *pgxpool.Pool
 }
 
+var ErrUserNotFound = internal_models.ErrUserNotFound
+var ErrUserHasOpenPullRequests = internal_models.ErrUserHasOpenPullRequests
+
+// userSelect выбирает пользователя с названием основной команды и всеми его командами
+const userSelect = `
+        SELECT u.user_id,
+               u.username,
+               COALESCE(t.team_name, ''),
+               u.is_active,
+               u.review_weight,
+               ARRAY(SELECT mt.team_name FROM team_members tm
+                     JOIN teams mt ON mt.team_id = tm.team_id
+                     WHERE tm.user_id = u.user_id
+                     ORDER BY mt.team_name)
+        FROM users u
+        LEFT JOIN teams t ON t.team_id = u.team_id
+`
+
 func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 	return &UserRepository{pool: pool}
 }
 
 func (r *UserRepository) GetReviews(ctx context.Context, userId string) ([]models.PullRequestShort, error) {
 	query := `
-        SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status
+        SELECT pr.pull_request_id, pr.pull_request_name, COALESCE(pr.author_id, ''), pr.status, rev.verdict, rev.verdict_at
         FROM pull_requests pr
         JOIN pull_request_reviewers rev ON pr.pull_request_id = rev.pull_request_id
         WHERE rev.reviewer_id = $1
@@ -33,9 +56,13 @@ func (r *UserRepository) GetReviews(ctx context.Context, userId string) ([]model
 	var reviews []models.PullRequestShort
 	for rows.Next() {
 		var pr models.PullRequestShort
-		if err := rows.Scan(&pr.PullRequestId, &pr.PullRequestName, &pr.AuthorId, &pr.Status); err != nil {
+		var verdict *string
+		if err := rows.Scan(&pr.PullRequestId, &pr.PullRequestName, &pr.AuthorId, &pr.Status, &verdict, &pr.VerdictAt); err != nil {
 			return nil, err
 		}
+		if verdict != nil {
+			pr.Verdict = *verdict
+		}
 		reviews = append(reviews, pr)
 	}
 
@@ -43,56 +70,324 @@ func (r *UserRepository) GetReviews(ctx context.Context, userId string) ([]model
 }
 
 func (r *UserRepository) SetIsActivePost(ctx context.Context, usersSetIsActiveRequest models.UsersSetIsActivePostRequest) (models.User, error) {
-	var user models.User
-
-	query := `
-        UPDATE users
-        SET is_active = $1
-        WHERE user_id = $2
-        RETURNING user_id, username, team_id, is_active;
-    `
-
-    err := r.pool.QueryRow(
-        ctx,
-        query,
+    result, err := r.pool.Exec(ctx,
+        `UPDATE users SET is_active = $1 WHERE user_id = $2`,
         usersSetIsActiveRequest.IsActive,
         usersSetIsActiveRequest.UserId,
-    ).Scan(
-        &user.UserId,
-        &user.Username,
-        &user.TeamName,
-        &user.IsActive,
     )
-
     if err != nil {
         return models.User{}, err
     }
+    if result.RowsAffected() == 0 {
+        return models.User{}, ErrUserNotFound
+    }
 
-    return user, nil
+    return r.GetUserByID(ctx, usersSetIsActiveRequest.UserId)
 }
 
 func (r *UserRepository) GetUserByID(ctx context.Context, userId string) (models.User, error) {
-    var user models.User
+    user, err := scanUser(r.pool.QueryRow(ctx, userSelect+`WHERE u.user_id = $1`, userId))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return models.User{}, ErrUserNotFound
+    }
 
-    query := `
-        SELECT user_id, username, team_id, is_active
-        FROM users
-        WHERE user_id = $1
-    `
-    row := r.pool.QueryRow(ctx, query, userId)
+    return user, err
+}
+
+// ListUsers возвращает пользователей по фильтру, отсортированных по user_id
+func (r *UserRepository) ListUsers(ctx context.Context, filter internal_models.UserFilter) ([]models.User, error) {
+    conditions := []string{}
+    args := []any{}
+    addArg := func(v any) string {
+        args = append(args, v)
+        return fmt.Sprintf("$%d", len(args))
+    }
+
+    if filter.TeamName != "" {
+        teamId, err := lookupTeamId(ctx, r.pool, filter.TeamName)
+        if err != nil {
+            return nil, err
+        }
+        conditions = append(conditions,
+            "EXISTS (SELECT 1 FROM team_members tm WHERE tm.user_id = u.user_id AND tm.team_id = "+addArg(teamId)+")")
+    }
+    if filter.IsActive != nil {
+        conditions = append(conditions, "u.is_active = "+addArg(*filter.IsActive))
+    }
+    if filter.NamePrefix != "" {
+        conditions = append(conditions, "u.username ILIKE "+addArg(likeEscaper.Replace(filter.NamePrefix)+"%"))
+    }
+    if filter.After != "" {
+        conditions = append(conditions, "u.user_id > "+addArg(filter.After))
+    }
+
+    where := ""
+    if len(conditions) > 0 {
+        where = "WHERE " + strings.Join(conditions, " AND ")
+    }
+
+    rows, err := r.pool.Query(ctx,
+        fmt.Sprintf("%s%s\n        ORDER BY u.user_id\n        LIMIT %s", userSelect, where, addArg(filter.Limit)),
+        args...,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    users := []models.User{}
+    for rows.Next() {
+        user, err := scanUser(rows)
+        if err != nil {
+            return nil, err
+        }
+        users = append(users, user)
+    }
+
+    return users, rows.Err()
+}
 
-    var teamID *int
+// likeEscaper экранирует спецсимволы LIKE, чтобы префикс имени сравнивался буквально
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// UpdateUser меняет переданные (не nil) поля пользователя; основной командой
+// можно сделать только команду, в которой он уже состоит
+func (r *UserRepository) UpdateUser(ctx context.Context, req models.UsersUpdatePostRequest) (models.User, error) {
+    tx, err := r.pool.Begin(ctx)
+    if err != nil {
+        return models.User{}, err
+    }
+    defer tx.Rollback(ctx)
+
+    if _, err := lockUserTeam(ctx, tx, req.UserId); err != nil {
+        return models.User{}, err
+    }
 
+    var teamId *int
+    if req.TeamName != nil {
+        id, err := lookupTeamId(ctx, tx, *req.TeamName)
+        if err != nil {
+            return models.User{}, err
+        }
+        member, err := isTeamMember(ctx, tx, id, req.UserId)
+        if err != nil {
+            return models.User{}, err
+        }
+        if !member {
+            return models.User{}, ErrNotTeamMember
+        }
+        teamId = &id
+    }
+
+    _, err = tx.Exec(ctx,
+        `UPDATE users
+         SET username = COALESCE($2, username),
+             is_active = COALESCE($3, is_active),
+             review_weight = COALESCE($4, review_weight),
+             team_id = COALESCE($5, team_id)
+         WHERE user_id = $1`,
+        req.UserId, req.Username, req.IsActive, req.ReviewWeight, teamId,
+    )
+    if err != nil {
+        return models.User{}, err
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return models.User{}, err
+    }
+
+    return r.GetUserByID(ctx, req.UserId)
+}
+
+// DeleteUser удаляет пользователя. Его DRAFT/OPEN PR либо закрываются (closeAuthored),
+// либо удаление отклоняется с ErrUserHasOpenPullRequests; OPEN-ревью переназначаются.
+// У оставшихся PR автор обнуляется, а команда PR фиксируется, чтобы не потерять её вместе с автором.
+// Возвращает закрытые PR и принятые решения о переназначении.
+func (r *UserRepository) DeleteUser(ctx context.Context, userId string, closeAuthored bool, actorId string, plan ReassignmentPlanner) ([]string, []internal_models.ReviewerReassignment, error) {
+    tx, err := r.pool.Begin(ctx)
+    if err != nil {
+        return nil, nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    if _, err := lockUserTeam(ctx, tx, userId); err != nil {
+        return nil, nil, err
+    }
+
+    rows, err := tx.Query(ctx,
+        `SELECT pull_request_id, status
+         FROM pull_requests
+         WHERE author_id = $1 AND status IN ('DRAFT', 'OPEN')
+         ORDER BY pull_request_id
+         FOR UPDATE`,
+        userId,
+    )
+    if err != nil {
+        return nil, nil, err
+    }
+
+    closed := []string{}
+    events := []models.PullRequestEvent{}
+    for rows.Next() {
+        var prId, status string
+        if err := rows.Scan(&prId, &status); err != nil {
+            rows.Close()
+            return nil, nil, err
+        }
+        closed = append(closed, prId)
+        events = append(events, models.PullRequestEvent{
+            PullRequestId: prId,
+            Type:          "status_changed",
+            ActorId:       actorId,
+            FromStatus:    status,
+            ToStatus:      "CLOSED",
+            Reason:        "author deleted",
+        })
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, nil, err
+    }
+
+    if len(closed) > 0 {
+        if !closeAuthored {
+            return nil, nil, fmt.Errorf("%w: %s", ErrUserHasOpenPullRequests, strings.Join(closed, ", "))
+        }
+
+        _, err = tx.Exec(ctx,
+            `UPDATE pull_requests SET status = 'CLOSED', closed_at = NOW() WHERE pull_request_id = ANY($1)`,
+            closed,
+        )
+        if err != nil {
+            return nil, nil, err
+        }
+        if err := insertEvents(ctx, tx, events); err != nil {
+            return nil, nil, err
+        }
+    }
+
+    _, err = tx.Exec(ctx,
+        `UPDATE pull_requests pr
+         SET team_id = u.team_id
+         FROM users u
+         WHERE u.user_id = pr.author_id AND pr.author_id = $1 AND pr.team_id IS NULL`,
+        userId,
+    )
+    if err != nil {
+        return nil, nil, err
+    }
+
+    // пользователь выходит из пулов, чтобы не получить заменяемые ревью сам
+    _, err = tx.Exec(ctx, `UPDATE users SET is_active = FALSE WHERE user_id = $1`, userId)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    decisions, err := reassignOpenReviews(ctx, tx, []string{userId}, nil, plan, actorId, "reviewer deleted")
+    if err != nil {
+        return nil, nil, err
+    }
+
+    // членства удаляются каскадно; ревью в MERGED и CLOSED PR остаются в истории: reviewer_id, как и author_id,
+    // обнуляется по ON DELETE SET NULL
+    _, err = tx.Exec(ctx, `DELETE FROM users WHERE user_id = $1`, userId)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, nil, err
+    }
+
+    return closed, decisions, nil
+}
+
+func scanUser(row pgx.Row) (models.User, error) {
+    var user models.User
     err := row.Scan(
         &user.UserId,
         &user.Username,
-        &teamID,
+        &user.TeamName,
         &user.IsActive,
+        &user.ReviewWeight,
+        &user.Teams,
     )
+    return user, err
+}
 
+// DeactivateUsers деактивирует пользователей (список userIds или всю команду teamName)
+// и в той же транзакции переназначает их OPEN-ревью на активных участников команды PR
+func (r *UserRepository) DeactivateUsers(ctx context.Context, userIds []string, teamName string, actorId string, plan ReassignmentPlanner) ([]string, []internal_models.ReviewerReassignment, error) {
+    tx, err := r.pool.Begin(ctx)
     if err != nil {
-        return models.User{}, err
+        return nil, nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    var rows pgx.Rows
+    if teamName != "" {
+        var teamId int
+        err = tx.QueryRow(ctx, `SELECT team_id FROM teams WHERE team_name = $1`, teamName).Scan(&teamId)
+        if errors.Is(err, pgx.ErrNoRows) {
+            return nil, nil, ErrTeamNotFound
+        }
+        if err != nil {
+            return nil, nil, err
+        }
+
+        rows, err = tx.Query(ctx,
+            `SELECT u.user_id
+             FROM team_members tm
+             JOIN users u ON u.user_id = tm.user_id
+             WHERE tm.team_id = $1
+             ORDER BY u.user_id
+             FOR UPDATE OF u`,
+            teamId,
+        )
+    } else {
+        rows, err = tx.Query(ctx,
+            `SELECT user_id FROM users WHERE user_id = ANY($1) ORDER BY user_id FOR UPDATE`,
+            userIds,
+        )
+    }
+    if err != nil {
+        return nil, nil, err
+    }
+
+    found, err := pgx.CollectRows(rows, pgx.RowTo[string])
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if teamName == "" && len(found) != len(userIds) {
+        known := make(map[string]bool, len(found))
+        for _, id := range found {
+            known[id] = true
+        }
+        missing := []string{}
+        for _, id := range userIds {
+            if !known[id] {
+                missing = append(missing, id)
+            }
+        }
+        if len(missing) > 0 {
+            return nil, nil, fmt.Errorf("%w: %s", ErrUserNotFound, strings.Join(missing, ", "))
+        }
+    }
+
+    _, err = tx.Exec(ctx, `UPDATE users SET is_active = FALSE WHERE user_id = ANY($1)`, found)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    decisions, err := reassignOpenReviews(ctx, tx, found, nil, plan, actorId, "reviewer deactivated")
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, nil, err
     }
 
-    return user, nil
+    return found, decisions, nil
 }
@@ -0,0 +1,270 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+type WebhookRepository struct {
+	pool *pgxpool.Pool
+}
+
+var ErrWebhookNotFound = internal_models.ErrWebhookNotFound
+var ErrDeliveryNotFound = internal_models.ErrDeliveryNotFound
+var ErrDeliveryLeaseLost = internal_models.ErrDeliveryLeaseLost
+
+const deliverySelect = `
+        SELECT id, subscription_id, event_type, payload, status, attempts,
+               response_status, COALESCE(last_error, ''),
+               CASE WHEN status = 'pending' THEN next_attempt_at END,
+               last_attempt_at, delivered_at, redelivery_of, created_at
+        FROM webhook_deliveries
+`
+
+func NewWebhookRepository(pool *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{pool: pool}
+}
+
+// db - транзакция UnitOfWork, если она есть: доставки ставятся в очередь вместе с событием PR
+func (r *WebhookRepository) db(ctx context.Context) querier {
+    return conn(ctx, r.pool)
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, url, secret string, events []string) (models.WebhookSubscription, error) {
+    subscription := models.WebhookSubscription{Url: url, Events: events}
+    var createdAt time.Time
+
+    err := r.db(ctx).QueryRow(ctx, `
+        INSERT INTO webhook_subscriptions (url, secret, events)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at
+    `, url, secret, events).Scan(&subscription.SubscriptionId, &createdAt)
+    if err != nil {
+        return models.WebhookSubscription{}, err
+    }
+    subscription.CreatedAt = &createdAt
+
+    return subscription, nil
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+    rows, err := r.db(ctx).Query(ctx, `
+        SELECT id, url, events, created_at
+        FROM webhook_subscriptions
+        ORDER BY id
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    subscriptions := []models.WebhookSubscription{}
+    for rows.Next() {
+        var subscription models.WebhookSubscription
+        var createdAt time.Time
+        if err := rows.Scan(&subscription.SubscriptionId, &subscription.Url, &subscription.Events, &createdAt); err != nil {
+            return nil, err
+        }
+        subscription.CreatedAt = &createdAt
+        subscriptions = append(subscriptions, subscription)
+    }
+
+    return subscriptions, rows.Err()
+}
+
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, subscriptionId int64) error {
+    tag, err := r.db(ctx).Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, subscriptionId)
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        return ErrWebhookNotFound
+    }
+    return nil
+}
+
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, event string, payload []byte) error {
+    _, err := r.db(ctx).Exec(ctx, `
+        INSERT INTO webhook_deliveries (subscription_id, event_type, payload)
+        SELECT id, $1, $2 FROM webhook_subscriptions WHERE $1 = ANY(events)
+    `, event, payload)
+    return err
+}
+
+// ClaimDueDeliveries откладывает взятые доставки на lease: если реплика упадёт, не записав
+// результат, доставку после lease возьмёт другая. SKIP LOCKED не даёт репликам ждать друг друга
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]internal_models.WebhookTask, error) {
+    rows, err := r.db(ctx).Query(ctx, `
+        WITH due AS (
+            SELECT id FROM webhook_deliveries
+            WHERE status = 'pending' AND next_attempt_at <= $1
+            ORDER BY next_attempt_at, id
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE webhook_deliveries d
+        SET next_attempt_at = $2
+        FROM due, webhook_subscriptions s
+        WHERE d.id = due.id AND s.id = d.subscription_id
+        RETURNING d.id, s.url, s.secret, d.event_type, d.payload, d.attempts, d.next_attempt_at
+    `, now, now.Add(lease), limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    tasks := []internal_models.WebhookTask{}
+    for rows.Next() {
+        var task internal_models.WebhookTask
+        if err := rows.Scan(&task.DeliveryID, &task.URL, &task.Secret, &task.Event, &task.Payload, &task.Attempts, &task.LeasedUntil); err != nil {
+            return nil, err
+        }
+        tasks = append(tasks, task)
+    }
+
+    return tasks, rows.Err()
+}
+
+// RecordAttempt записывает попытку, пока доставка закреплена за диспетчером: next_attempt_at
+// совпадает с арендой, выданной ClaimDueDeliveries. Иначе её уже взяли заново - ErrDeliveryLeaseLost
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, deliveryId int64, leasedUntil time.Time, attempt internal_models.WebhookAttempt) error {
+    status := "failed"
+    var nextAttemptAt, deliveredAt *time.Time
+    switch {
+    case attempt.Delivered:
+        status = "delivered"
+        deliveredAt = &attempt.At
+    case attempt.NextAttemptAt != nil:
+        status = "pending"
+        nextAttemptAt = attempt.NextAttemptAt
+    }
+
+    var lastError *string
+    if attempt.Error != "" {
+        lastError = &attempt.Error
+    }
+
+    tag, err := r.db(ctx).Exec(ctx, `
+        UPDATE webhook_deliveries
+        SET status = $2,
+            attempts = attempts + 1,
+            last_attempt_at = $3,
+            response_status = $4,
+            last_error = $5,
+            next_attempt_at = COALESCE($6, next_attempt_at),
+            delivered_at = $7
+        WHERE id = $1 AND status = 'pending' AND next_attempt_at = $8
+    `, deliveryId, status, attempt.At, attempt.ResponseStatus, lastError, nextAttemptAt, deliveredAt, leasedUntil)
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() > 0 {
+        return nil
+    }
+
+    var exists bool
+    err = r.db(ctx).QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM webhook_deliveries WHERE id = $1)`, deliveryId).Scan(&exists)
+    if err != nil {
+        return err
+    }
+    if !exists {
+        return ErrDeliveryNotFound
+    }
+    return ErrDeliveryLeaseLost
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, filter internal_models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+    conditions := []string{}
+    args := []any{}
+    addArg := func(v any) string {
+        args = append(args, v)
+        return fmt.Sprintf("$%d", len(args))
+    }
+
+    if filter.SubscriptionID != 0 {
+        conditions = append(conditions, "subscription_id = "+addArg(filter.SubscriptionID))
+    }
+    if filter.Status != "" {
+        conditions = append(conditions, "status = "+addArg(filter.Status))
+    }
+
+    where := ""
+    if len(conditions) > 0 {
+        where = "WHERE " + strings.Join(conditions, " AND ")
+    }
+
+    rows, err := r.db(ctx).Query(ctx,
+        fmt.Sprintf("%s%s\n        ORDER BY id DESC\n        LIMIT %s", deliverySelect, where, addArg(filter.Limit)),
+        args...,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    deliveries := []models.WebhookDelivery{}
+    for rows.Next() {
+        delivery, err := scanDelivery(rows)
+        if err != nil {
+            return nil, err
+        }
+        deliveries = append(deliveries, delivery)
+    }
+
+    return deliveries, rows.Err()
+}
+
+func (r *WebhookRepository) Redeliver(ctx context.Context, deliveryId int64) (models.WebhookDelivery, error) {
+    var id int64
+    err := r.db(ctx).QueryRow(ctx, `
+        INSERT INTO webhook_deliveries (subscription_id, event_type, payload, redelivery_of)
+        SELECT subscription_id, event_type, payload, id FROM webhook_deliveries WHERE id = $1
+        RETURNING id
+    `, deliveryId).Scan(&id)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return models.WebhookDelivery{}, ErrDeliveryNotFound
+    }
+    if err != nil {
+        return models.WebhookDelivery{}, err
+    }
+
+    return scanDelivery(r.db(ctx).QueryRow(ctx, deliverySelect+"        WHERE id = $1", id))
+}
+
+func scanDelivery(row pgx.Row) (models.WebhookDelivery, error) {
+    var delivery models.WebhookDelivery
+    var payload []byte
+    var createdAt time.Time
+
+    err := row.Scan(
+        &delivery.DeliveryId,
+        &delivery.SubscriptionId,
+        &delivery.Event,
+        &payload,
+        &delivery.Status,
+        &delivery.Attempts,
+        &delivery.ResponseStatus,
+        &delivery.LastError,
+        &delivery.NextAttemptAt,
+        &delivery.LastAttemptAt,
+        &delivery.DeliveredAt,
+        &delivery.RedeliveryOf,
+        &createdAt,
+    )
+    if err != nil {
+        return models.WebhookDelivery{}, err
+    }
+    delivery.Payload = payload
+    delivery.CreatedAt = &createdAt
+
+    return delivery, nil
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/service"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// допустимое расхождение часов сервиса и издателя токена
+const clockSkew = 30 * time.Second
+
+// Claims - поля токена, которые использует сервис. sub - user_id пользователя,
+// role - admin или user (по умолчанию user)
+type Claims struct {
+	Subject   string `json:"sub"`
+	Role      string `json:"role"`
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+// JWTVerifier проверяет bearer-токены: HS256 с общим секретом и/или RS256 с публичным ключом.
+// Принимается только алгоритм, для которого задан ключ
+type JWTVerifier struct {
+	secret    []byte
+	publicKey *rsa.PublicKey
+	now       func() time.Time
+}
+
+func NewJWTVerifier(secret string, publicKey *rsa.PublicKey) *JWTVerifier {
+	return &JWTVerifier{
+		secret:    []byte(secret),
+		publicKey: publicKey,
+		now:       time.Now,
+	}
+}
+
+// LoadRSAPublicKey читает публичный ключ RS256 из PEM-файла (PKIX или PKCS#1)
+func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+
+    block, _ := pem.Decode(data)
+    if block == nil {
+        return nil, fmt.Errorf("%s: no PEM block found", path)
+    }
+
+    if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+        return key, nil
+    }
+    key, err := x509.ParsePKIXPublicKey(block.Bytes)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+    rsaKey, ok := key.(*rsa.PublicKey)
+    if !ok {
+        return nil, fmt.Errorf("%s: not an RSA public key", path)
+    }
+
+    return rsaKey, nil
+}
+
+// Verify проверяет подпись и сроки действия токена и возвращает его claims
+func (v *JWTVerifier) Verify(token string) (Claims, error) {
+    parts := strings.Split(token, ".")
+    if len(parts) != 3 {
+        return Claims{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+    }
+
+    var header struct {
+        Alg string `json:"alg"`
+    }
+    if err := decodeSegment(parts[0], &header); err != nil {
+        return Claims{}, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+    }
+
+    signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+    if err != nil {
+        return Claims{}, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+    }
+    if err := v.verifySignature(header.Alg, parts[0]+"."+parts[1], signature); err != nil {
+        return Claims{}, err
+    }
+
+    var claims Claims
+    if err := decodeSegment(parts[1], &claims); err != nil {
+        return Claims{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+    }
+
+    now := v.now()
+    if claims.ExpiresAt == nil {
+        return Claims{}, fmt.Errorf("%w: exp is required", ErrInvalidToken)
+    }
+    if now.After(time.Unix(*claims.ExpiresAt, 0).Add(clockSkew)) {
+        return Claims{}, ErrTokenExpired
+    }
+    if claims.NotBefore != nil && now.Add(clockSkew).Before(time.Unix(*claims.NotBefore, 0)) {
+        return Claims{}, fmt.Errorf("%w: token is not valid yet", ErrInvalidToken)
+    }
+    if claims.Subject == "" {
+        return Claims{}, fmt.Errorf("%w: sub is required", ErrInvalidToken)
+    }
+    if claims.Role == "" {
+        claims.Role = service.RoleUser
+    }
+
+    return claims, nil
+}
+
+func (v *JWTVerifier) verifySignature(alg, signingInput string, signature []byte) error {
+    switch {
+    case alg == "HS256" && len(v.secret) > 0:
+        mac := hmac.New(sha256.New, v.secret)
+        mac.Write([]byte(signingInput))
+        if !hmac.Equal(signature, mac.Sum(nil)) {
+            return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+        }
+        return nil
+    case alg == "RS256" && v.publicKey != nil:
+        digest := sha256.Sum256([]byte(signingInput))
+        if err := rsa.VerifyPKCS1v15(v.publicKey, crypto.SHA256, digest[:], signature); err != nil {
+            return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+        }
+        return nil
+    }
+
+    // в том числе alg=none и попытка подписать HS256 публичным ключом
+    return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, alg)
+}
+
+func decodeSegment(segment string, v any) error {
+    data, err := base64.RawURLEncoding.DecodeString(segment)
+    if err != nil {
+        return err
+    }
+    return json.Unmarshal(data, v)
+}
@@ -0,0 +1,220 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	"github.com/kgugunava/avito-tech-internship/internal/service"
+)
+
+// maxWebhookBodySize - предел тела входящего вебхука; GitHub и GitLab не присылают payload больше 25 МБ
+const maxWebhookBodySize = 25 << 20
+
+type IntegrationsAPI struct {
+	integrationService *service.IntegrationService
+}
+
+func NewIntegrationsAPI(integrationService *service.IntegrationService) *IntegrationsAPI {
+	return &IntegrationsAPI{
+		integrationService: integrationService,
+	}
+}
+
+// Post /integrations/github/webhook
+// Принять вебхук GitHub (событие pull_request); запрос подписывается секретом вебхука, а не токеном
+func (api *IntegrationsAPI) IntegrationsGithubWebhookPost(c *gin.Context) {
+	body, ok := readWebhookBody(c)
+	if !ok {
+		return
+	}
+
+	webhookResponse, errResponse := api.integrationService.HandleGitHub(
+		c.Request.Context(),
+		c.GetHeader(service.GitHubEventHeader),
+		c.GetHeader(service.GitHubDeliveryHeader),
+		c.GetHeader(service.GitHubSignatureHeader),
+		body,
+	)
+
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "UNAUTHORIZED" {
+		c.JSON(401, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTEGRATION_DISABLED" {
+		c.JSON(503, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, webhookResponse)
+}
+
+// Post /integrations/gitlab/webhook
+// Принять вебхук GitLab (Merge Request Hook); запрос подтверждается секретным токеном X-Gitlab-Token
+func (api *IntegrationsAPI) IntegrationsGitlabWebhookPost(c *gin.Context) {
+	body, ok := readWebhookBody(c)
+	if !ok {
+		return
+	}
+
+	webhookResponse, errResponse := api.integrationService.HandleGitLab(
+		c.Request.Context(),
+		c.GetHeader(service.GitLabEventHeader),
+		c.GetHeader(service.GitLabDeliveryHeader),
+		c.GetHeader(service.GitLabTokenHeader),
+		body,
+	)
+
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "UNAUTHORIZED" {
+		c.JSON(401, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTEGRATION_DISABLED" {
+		c.JSON(503, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, webhookResponse)
+}
+
+// readWebhookBody читает тело вебхука целиком: подпись считается по нему как есть, до разбора.
+// Тело больше maxWebhookBodySize отклоняется с 413, не дочитываясь
+func readWebhookBody(c *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(413, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "PAYLOAD_TOO_LARGE",
+				Message: fmt.Sprintf("webhook body exceeds %d bytes", tooLarge.Limit),
+			},
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return nil, false
+	}
+	return body, true
+}
+
+// Post /integrations/accounts/link
+// Привязать логин внешней системы к пользователю (повторная привязка переносит логин)
+func (api *IntegrationsAPI) IntegrationsAccountsLinkPost(c *gin.Context) {
+	var linkRequest models.IntegrationsAccountsLinkPostRequest
+
+	if err := c.ShouldBindJSON(&linkRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	account, errResponse := api.integrationService.LinkAccount(c.Request.Context(), linkRequest)
+
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "USER_NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, account)
+}
+
+// Post /integrations/accounts/unlink
+// Отвязать логин внешней системы
+func (api *IntegrationsAPI) IntegrationsAccountsUnlinkPost(c *gin.Context) {
+	var unlinkRequest models.IntegrationsAccountsUnlinkPostRequest
+
+	if err := c.ShouldBindJSON(&unlinkRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	errResponse := api.integrationService.UnlinkAccount(c.Request.Context(), unlinkRequest)
+
+	if errResponse.Error.Code == "NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.Status(204)
+}
+
+// Get /integrations/accounts/list
+// Привязанные логины внешних систем
+func (api *IntegrationsAPI) IntegrationsAccountsListGet(c *gin.Context) {
+	var listRequest models.IntegrationsAccountsListGetRequest
+
+	if err := c.ShouldBindQuery(&listRequest); err != nil {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	listResponse, errResponse := api.integrationService.ListAccounts(c.Request.Context(), listRequest)
+
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, listResponse)
+}
@@ -27,7 +27,7 @@ func NewPullRequestAPI(pullRequestService *service.PullRequestService) *PullRequ
 }
 
 // Post /pullRequest/create
-// Создать PR и автоматически назначить до 2 ревьюверов из команды автора 
+// Создать PR и автоматически назначить ревьюверов из команды автора (до max_reviewers команды или reviewers_count) 
 func (api *PullRequestsAPI) PullRequestCreatePost(c *gin.Context) {
 	var pullRequestCreatePostRequest models.PullRequestCreatePostRequest
 
@@ -47,18 +47,115 @@ func (api *PullRequestsAPI) PullRequestCreatePost(c *gin.Context) {
 		c.JSON(404, errResponse)
 		return
 	}
-	if errResponse.Error.Code == "PR_EXISTS" {
+	if errResponse.Error.Code == "INVALID_REVIEWERS_COUNT" || errResponse.Error.Code == "NOT_TEAM_MEMBER" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "PR_EXISTS" || errResponse.Error.Code == "NOT_ENOUGH_REVIEWERS" {
 		c.JSON(409, errResponse)
 		return
 	}
+	if errResponse.Error.Code == "FORBIDDEN" {
+		c.JSON(403, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
 
 	c.JSON(201, models.PullRequestCreatePost201Response{
 		Pr: prResponse,
 	})
 }
 
+// Get /pullRequest/get
+// Получить PR со всеми данными 
+func (api *PullRequestsAPI) PullRequestGetGet(c *gin.Context) {
+	pullRequestId := c.Query("pull_request_id")
+	if pullRequestId == "" {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: "pull_request_id is required",
+			},
+		})
+		return
+	}
+
+	prResponse, errResponse := api.pullRequestService.Get(c.Request.Context(), pullRequestId)
+
+	if errResponse.Error.Code == "NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, prResponse)
+}
+
+// Get /pullRequest/history
+// История событий PR в хронологическом порядке 
+func (api *PullRequestsAPI) PullRequestHistoryGet(c *gin.Context) {
+	pullRequestId := c.Query("pull_request_id")
+	if pullRequestId == "" {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: "pull_request_id is required",
+			},
+		})
+		return
+	}
+
+	historyResponse, errResponse := api.pullRequestService.History(c.Request.Context(), pullRequestId)
+
+	if errResponse.Error.Code == "NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, historyResponse)
+}
+
+// Get /pullRequest/list
+// Список PR с фильтрами, сортировкой и пагинацией по курсору 
+func (api *PullRequestsAPI) PullRequestListGet(c *gin.Context) {
+	var pullRequestListGetRequest models.PullRequestListGetRequest
+
+	if err := c.ShouldBindQuery(&pullRequestListGetRequest); err != nil {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	listResponse, errResponse := api.pullRequestService.List(c.Request.Context(), pullRequestListGetRequest)
+
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, listResponse)
+}
+
 // Post /pullRequest/merge
-// Пометить PR как MERGED (идемпотентная операция) 
+// Пометить PR как MERGED (идемпотентная операция), если набрано нужное число апрувов 
 func (api *PullRequestsAPI) PullRequestMergePost(c *gin.Context) {
 	var pullRequestMergePostRequest models.PullRequestMergePostRequest
 
@@ -74,16 +171,34 @@ func (api *PullRequestsAPI) PullRequestMergePost(c *gin.Context) {
 
 	prResponse, errResponse := api.pullRequestService.Merge(c.Request.Context(), pullRequestMergePostRequest)
 
-	if errResponse.Error.Code == "NOT FOUND" {
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "NOT_FOUND" {
 		c.JSON(404, errResponse)
 		return
 	}
+	if errResponse.Error.Code == "NOT_APPROVED" || errResponse.Error.Code == "PR_DRAFT" || errResponse.Error.Code == "PR_CLOSED" {
+		c.JSON(409, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "FORBIDDEN" {
+		c.JSON(403, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
 
-	c.JSON(200, prResponse)
+	c.JSON(200, models.PullRequestCreatePost201Response{
+		Pr: prResponse,
+	})
 }
 
 // Post /pullRequest/reassign
-// Переназначить конкретного ревьювера на другого из его команды 
+// Переназначить конкретного ревьювера на другого из его команды (кроме автора и уже назначенных) 
 func (api *PullRequestsAPI) PullRequestReassignPost(c *gin.Context) {
 	var pullRequestReassignPostRequest models.PullRequestReassignPostRequest
 
@@ -104,14 +219,187 @@ func (api *PullRequestsAPI) PullRequestReassignPost(c *gin.Context) {
 		return
 	}
 
-	if errResponse.Error.Code == "PR_MERGED" || errResponse.Error.Code == "NOT_ASSIGNED" || errResponse.Error.Code == "NO_CANDIDATE" {
+	if errResponse.Error.Code == "PR_MERGED" || errResponse.Error.Code == "PR_CLOSED" ||
+		errResponse.Error.Code == "NOT_ASSIGNED" || errResponse.Error.Code == "NO_CANDIDATE" {
 		c.JSON(409, errResponse)
 		return
 	}
 
+	if errResponse.Error.Code == "FORBIDDEN" {
+		c.JSON(403, errResponse)
+		return
+	}
+
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
 	c.JSON(200, models.PullRequestReassignPost200Response{
 		Pr: prResponse,
 		ReplacedBy: newReviewer,
 	})
 }
 
+
+// Post /pullRequest/review
+// Оставить вердикт ревьювера по PR (APPROVED / CHANGES_REQUESTED / COMMENTED)
+func (api *PullRequestsAPI) PullRequestReviewPost(c *gin.Context) {
+	var pullRequestReviewPostRequest models.PullRequestReviewPostRequest
+
+	if err := c.ShouldBindJSON(&pullRequestReviewPostRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	prResponse, errResponse := api.pullRequestService.SubmitReview(c.Request.Context(), pullRequestReviewPostRequest)
+
+	if errResponse.Error.Code == "INVALID_VERDICT" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "PR_MERGED" || errResponse.Error.Code == "PR_CLOSED" || errResponse.Error.Code == "NOT_ASSIGNED" {
+		c.JSON(409, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "FORBIDDEN" {
+		c.JSON(403, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, models.PullRequestCreatePost201Response{
+		Pr: prResponse,
+	})
+}
+
+// Post /pullRequest/readyForReview
+// Перевести черновик (DRAFT) в OPEN и назначить ревьюверов
+func (api *PullRequestsAPI) PullRequestReadyForReviewPost(c *gin.Context) {
+	var pullRequestReadyForReviewPostRequest models.PullRequestReadyForReviewPostRequest
+
+	if err := c.ShouldBindJSON(&pullRequestReadyForReviewPostRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	prResponse, errResponse := api.pullRequestService.ReadyForReview(c.Request.Context(), pullRequestReadyForReviewPostRequest)
+
+	if errResponse.Error.Code == "INVALID_REVIEWERS_COUNT" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "NOT_FOUND" || errResponse.Error.Code == "AUTHOR_NOT_FOUND" || errResponse.Error.Code == "TEAM_NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "PR_NOT_DRAFT" || errResponse.Error.Code == "NOT_ENOUGH_REVIEWERS" {
+		c.JSON(409, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "FORBIDDEN" {
+		c.JSON(403, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, models.PullRequestCreatePost201Response{
+		Pr: prResponse,
+	})
+}
+
+// Post /pullRequest/close
+// Закрыть PR без merge (идемпотентная операция)
+func (api *PullRequestsAPI) PullRequestClosePost(c *gin.Context) {
+	var pullRequestClosePostRequest models.PullRequestClosePostRequest
+
+	if err := c.ShouldBindJSON(&pullRequestClosePostRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	prResponse, errResponse := api.pullRequestService.Close(c.Request.Context(), pullRequestClosePostRequest)
+
+	if errResponse.Error.Code == "NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "PR_MERGED" {
+		c.JSON(409, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "FORBIDDEN" {
+		c.JSON(403, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, models.PullRequestCreatePost201Response{
+		Pr: prResponse,
+	})
+}
+
+// Post /pullRequest/reopen
+// Переоткрыть закрытый PR, при необходимости заменив неактивных ревьюверов
+func (api *PullRequestsAPI) PullRequestReopenPost(c *gin.Context) {
+	var pullRequestReopenPostRequest models.PullRequestReopenPostRequest
+
+	if err := c.ShouldBindJSON(&pullRequestReopenPostRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	reopenResponse, errResponse := api.pullRequestService.Reopen(c.Request.Context(), pullRequestReopenPostRequest)
+
+	if errResponse.Error.Code == "NOT_FOUND" || errResponse.Error.Code == "AUTHOR_NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "PR_NOT_CLOSED" {
+		c.JSON(409, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "FORBIDDEN" {
+		c.JSON(403, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, reopenResponse)
+}
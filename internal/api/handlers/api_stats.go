@@ -0,0 +1,43 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kgugunava/avito-tech-internship/internal/service"
+)
+
+type StatsAPI struct {
+	statsService *service.StatsService
+}
+
+func NewStatsAPI(statsService *service.StatsService) *StatsAPI {
+	return &StatsAPI{
+		statsService: statsService,
+	}
+}
+
+// Get /stats
+// Статистика назначений по ревьюверам, командам и авторам 
+func (api *StatsAPI) StatsGet(c *gin.Context) {
+	statsResponse, errResponse := api.statsService.GetStats(c.Request.Context(), c.Query("from"), c.Query("to"))
+
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, statsResponse)
+}
@@ -10,12 +10,14 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
 	"github.com/kgugunava/avito-tech-internship/internal/service"
 )
 
@@ -61,6 +63,27 @@ func (api *TeamsAPI) TeamAddPost(c *gin.Context) {
 	}
 
 	createdTeam, err := api.teamService.CreateNewTeam(c.Request.Context(), team)
+	if errors.Is(err, service.ErrUnknownStrategy) || errors.Is(err, service.ErrInvalidReviewWeight) ||
+		errors.Is(err, service.ErrInvalidReviewersRange) || errors.Is(err, service.ErrInvalidRequiredApprovals) ||
+		errors.Is(err, internal_models.ErrTeamHierarchyCycle) || errors.Is(err, internal_models.ErrInvalidFallbackTeam) {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code:    "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+	// родительская или запасная команда не найдена
+	if errors.Is(err, internal_models.ErrTeamNotFound) {
+		c.JSON(404, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code:    "TEAM_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(500, models.ErrorResponse{
 			Error: models.ErrorResponseError{
@@ -104,3 +127,209 @@ func (api *TeamsAPI) TeamGetGet(c *gin.Context) {
 	c.JSON(200, team)
 }
 
+
+// Post /team/updateSettings
+// Изменить настройки команды (стратегия назначения, количество ревьюверов, апрувы для merge,
+// родительская и запасные команды)
+func (api *TeamsAPI) TeamUpdateSettingsPost(c *gin.Context) {
+	var req models.TeamUpdateSettingsPostRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	team, err := api.teamService.UpdateTeamSettings(c.Request.Context(), req)
+	if errors.Is(err, service.ErrUnknownStrategy) || errors.Is(err, service.ErrInvalidReviewersRange) ||
+		errors.Is(err, service.ErrInvalidRequiredApprovals) || errors.Is(err, internal_models.ErrTeamHierarchyCycle) ||
+		errors.Is(err, internal_models.ErrInvalidFallbackTeam) {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code:    "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+	if errors.Is(err, internal_models.ErrTeamNotFound) {
+		c.JSON(404, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code:    "TEAM_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(200, models.TeamAddPost201Response{
+		Team: team,
+	})
+}
+
+// Post /team/addMember
+// Добавить пользователя в команду (создаёт пользователя, если его нет)
+func (api *TeamsAPI) TeamAddMemberPost(c *gin.Context) {
+	var req models.TeamAddMemberPostRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	team, err := api.teamService.AddMember(c.Request.Context(), req)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(200, models.TeamAddPost201Response{
+		Team: team,
+	})
+}
+
+// Post /team/removeMember
+// Исключить пользователя из команды, по желанию переназначив его OPEN-ревью
+func (api *TeamsAPI) TeamRemoveMemberPost(c *gin.Context) {
+	var req models.TeamRemoveMemberPostRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	response, err := api.teamService.RemoveMember(c.Request.Context(), req)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+// Post /team/moveMember
+// Перевести пользователя в другую команду, сохранив или переназначив его OPEN-ревью в старой команде
+func (api *TeamsAPI) TeamMoveMemberPost(c *gin.Context) {
+	var req models.TeamMoveMemberPostRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	response, err := api.teamService.MoveMember(c.Request.Context(), req)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+// Post /team/rename
+// Переименовать команду
+func (api *TeamsAPI) TeamRenamePost(c *gin.Context) {
+	var req models.TeamRenamePostRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	team, err := api.teamService.RenameTeam(c.Request.Context(), req)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(200, models.TeamAddPost201Response{
+		Team: team,
+	})
+}
+
+// Post /team/delete
+// Удалить команду, явно указав, что делать с участниками и их OPEN-ревью
+func (api *TeamsAPI) TeamDeletePost(c *gin.Context) {
+	var req models.TeamDeletePostRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	response, err := api.teamService.DeleteTeam(c.Request.Context(), req)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+// writeTeamError переводит ошибки изменения команды и её состава в ответ API
+func writeTeamError(c *gin.Context, err error) {
+	status, code := 500, "INTERNAL_ERROR"
+	switch {
+	case errors.Is(err, service.ErrInvalidReviewWeight), errors.Is(err, service.ErrInvalidOpenReviewsMode),
+		errors.Is(err, service.ErrInvalidMembersPolicy), errors.Is(err, service.ErrInvalidTeamName),
+		errors.Is(err, internal_models.ErrTeamHierarchyCycle), errors.Is(err, internal_models.ErrInvalidFallbackTeam):
+		status, code = 400, "INVALID_REQUEST"
+	case errors.Is(err, service.ErrTeamExists):
+		status, code = 400, "TEAM_EXISTS"
+	case errors.Is(err, internal_models.ErrTeamNotFound):
+		status, code = 404, "TEAM_NOT_FOUND"
+	case errors.Is(err, internal_models.ErrUserNotFound):
+		status, code = 404, "USER_NOT_FOUND"
+	case errors.Is(err, internal_models.ErrNotTeamMember):
+		status, code = 404, "NOT_TEAM_MEMBER"
+	case errors.Is(err, internal_models.ErrMemberExists):
+		status, code = 409, "MEMBER_EXISTS"
+	case errors.Is(err, internal_models.ErrTeamNotEmpty):
+		status, code = 409, "TEAM_NOT_EMPTY"
+	}
+
+	c.JSON(status, models.ErrorResponse{
+		Error: models.ErrorResponseError{
+			Code:    code,
+			Message: err.Error(),
+		},
+	})
+}
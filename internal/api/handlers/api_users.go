@@ -10,11 +10,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
 	"github.com/kgugunava/avito-tech-internship/internal/service"
 )
 
@@ -76,11 +78,20 @@ func (api *UsersAPI) UsersSetIsActivePost(c *gin.Context) {
 	}
 
 	user, err := api.userService.SetIsActivePost(c.Request.Context(), usersSetIsActiveRequest)
-	if err != nil {
+	if errors.Is(err, internal_models.ErrUserNotFound) {
 		c.JSON(404, models.ErrorResponse{
 			Error: models.ErrorResponseError{
 				Code: "USER_NOT_FOUND",
-				Message: fmt.Sprint("user %s not found", usersSetIsActiveRequest.UserId),
+				Message: fmt.Sprintf("user %s not found", usersSetIsActiveRequest.UserId),
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
 			},
 		})
 		return
@@ -91,3 +102,171 @@ func (api *UsersAPI) UsersSetIsActivePost(c *gin.Context) {
 	})
 }
 
+
+// Post /users/bulkDeactivate
+// Деактивировать список пользователей или всю команду с переназначением их открытых ревью
+func (api *UsersAPI) UsersBulkDeactivatePost(c *gin.Context) {
+	var usersBulkDeactivateRequest models.UsersBulkDeactivatePostRequest
+
+	if err := c.ShouldBindJSON(&usersBulkDeactivateRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	response, errResponse := api.userService.BulkDeactivate(c.Request.Context(), usersBulkDeactivateRequest)
+
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "USER_NOT_FOUND" || errResponse.Error.Code == "TEAM_NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+// Get /users/get
+// Получить пользователя с его командами
+func (api *UsersAPI) UsersGetGet(c *gin.Context) {
+	userId := c.Query("user_id")
+	if userId == "" {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: "user_id is required",
+			},
+		})
+		return
+	}
+
+	user, errResponse := api.userService.GetUser(c.Request.Context(), userId)
+
+	if errResponse.Error.Code == "USER_NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, models.UsersSetIsActivePost200Response{
+		User: user,
+	})
+}
+
+// Get /users/list
+// Список пользователей с фильтрами по команде, активности и началу имени, с пагинацией
+func (api *UsersAPI) UsersListGet(c *gin.Context) {
+	var usersListGetRequest models.UsersListGetRequest
+
+	if err := c.ShouldBindQuery(&usersListGetRequest); err != nil {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	listResponse, errResponse := api.userService.List(c.Request.Context(), usersListGetRequest)
+
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "TEAM_NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, listResponse)
+}
+
+// Post /users/update
+// Изменить имя, активность, вес или основную команду пользователя
+func (api *UsersAPI) UsersUpdatePost(c *gin.Context) {
+	var usersUpdateRequest models.UsersUpdatePostRequest
+
+	if err := c.ShouldBindJSON(&usersUpdateRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	user, errResponse := api.userService.Update(c.Request.Context(), usersUpdateRequest)
+
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "USER_NOT_FOUND" || errResponse.Error.Code == "TEAM_NOT_FOUND" || errResponse.Error.Code == "NOT_TEAM_MEMBER" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, models.UsersSetIsActivePost200Response{
+		User: user,
+	})
+}
+
+// Post /users/delete
+// Удалить пользователя с переназначением его ревью и обработкой его PR
+func (api *UsersAPI) UsersDeletePost(c *gin.Context) {
+	var usersDeleteRequest models.UsersDeletePostRequest
+
+	if err := c.ShouldBindJSON(&usersDeleteRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	response, errResponse := api.userService.Delete(c.Request.Context(), usersDeleteRequest)
+
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "USER_NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "USER_HAS_OPEN_PULL_REQUESTS" {
+		c.JSON(409, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, response)
+}
@@ -0,0 +1,160 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	"github.com/kgugunava/avito-tech-internship/internal/service"
+)
+
+type WebhooksAPI struct {
+	webhookService *service.WebhookService
+}
+
+func NewWebhooksAPI(webhookService *service.WebhookService) *WebhooksAPI {
+	return &WebhooksAPI{
+		webhookService: webhookService,
+	}
+}
+
+// Post /webhooks/add
+// Подписаться на события PR (pr.created, reviewer.assigned, reviewer.reassigned, pr.merged)
+func (api *WebhooksAPI) WebhooksAddPost(c *gin.Context) {
+	var webhooksAddRequest models.WebhooksAddPostRequest
+
+	if err := c.ShouldBindJSON(&webhooksAddRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	subscription, errResponse := api.webhookService.Add(c.Request.Context(), webhooksAddRequest)
+
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(201, models.WebhooksAddPost201Response{
+		Subscription: subscription,
+	})
+}
+
+// Get /webhooks/list
+// Список подписок на вебхуки
+func (api *WebhooksAPI) WebhooksListGet(c *gin.Context) {
+	listResponse, errResponse := api.webhookService.List(c.Request.Context())
+
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, listResponse)
+}
+
+// Post /webhooks/delete
+// Удалить подписку вместе с журналом её доставок
+func (api *WebhooksAPI) WebhooksDeletePost(c *gin.Context) {
+	var webhooksDeleteRequest models.WebhooksDeletePostRequest
+
+	if err := c.ShouldBindJSON(&webhooksDeleteRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	errResponse := api.webhookService.Delete(c.Request.Context(), webhooksDeleteRequest)
+
+	if errResponse.Error.Code == "NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.Status(204)
+}
+
+// Get /webhooks/deliveries
+// Журнал доставок вебхуков, от новых к старым
+func (api *WebhooksAPI) WebhooksDeliveriesGet(c *gin.Context) {
+	var webhooksDeliveriesRequest models.WebhooksDeliveriesGetRequest
+
+	if err := c.ShouldBindQuery(&webhooksDeliveriesRequest); err != nil {
+		c.JSON(400, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	deliveriesResponse, errResponse := api.webhookService.Deliveries(c.Request.Context(), webhooksDeliveriesRequest)
+
+	if errResponse.Error.Code == "INVALID_REQUEST" {
+		c.JSON(400, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(200, deliveriesResponse)
+}
+
+// Post /webhooks/redeliver
+// Повторно отправить доставку: в очередь ставится её копия с тем же телом
+func (api *WebhooksAPI) WebhooksRedeliverPost(c *gin.Context) {
+	var webhooksRedeliverRequest models.WebhooksRedeliverPostRequest
+
+	if err := c.ShouldBindJSON(&webhooksRedeliverRequest); err != nil {
+		c.JSON(500, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	delivery, errResponse := api.webhookService.Redeliver(c.Request.Context(), webhooksRedeliverRequest)
+
+	if errResponse.Error.Code == "NOT_FOUND" {
+		c.JSON(404, errResponse)
+		return
+	}
+	if errResponse.Error.Code == "INTERNAL_ERROR" {
+		c.JSON(500, errResponse)
+		return
+	}
+
+	c.JSON(202, models.WebhooksRedeliverPost202Response{
+		Delivery: delivery,
+	})
+}
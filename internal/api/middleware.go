@@ -0,0 +1,53 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	"github.com/kgugunava/avito-tech-internship/internal/service"
+)
+
+// Authorize проверяет bearer-токен запроса и кладёт его пользователя в контекст, откуда его
+// берёт сервис для проверки прав и записи истории PR. Если role не пустая, пользователь
+// должен иметь эту роль (admin подходит для любой)
+func Authorize(verifier *JWTVerifier, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			abortUnauthorized(c, "bearer token is required")
+			return
+		}
+
+		claims, err := verifier.Verify(token)
+		if err != nil {
+			abortUnauthorized(c, err.Error())
+			return
+		}
+
+		principal := service.Principal{UserId: claims.Subject, Role: claims.Role}
+		if role != "" && principal.Role != role && !principal.IsAdmin() {
+			c.AbortWithStatusJSON(403, models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "FORBIDDEN",
+					Message: role + " role required",
+				},
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(service.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="pr-reviewer"`)
+	c.AbortWithStatusJSON(401, models.ErrorResponse{
+		Error: models.ErrorResponseError{
+			Code: "UNAUTHORIZED",
+			Message: message,
+		},
+	})
+}
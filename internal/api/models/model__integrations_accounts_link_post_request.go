@@ -0,0 +1,19 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type IntegrationsAccountsLinkPostRequest struct {
+
+	Provider string `json:"provider"`
+
+	Login string `json:"login"`
+
+	UserId string `json:"user_id"`
+}
@@ -0,0 +1,18 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+// параметры запроса GET /integrations/accounts/list
+type IntegrationsAccountsListGetRequest struct {
+
+	Provider string `form:"provider"`
+
+	UserId string `form:"user_id"`
+}
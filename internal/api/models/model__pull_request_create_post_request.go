@@ -16,4 +16,13 @@ type PullRequestCreatePostRequest struct {
 	PullRequestName string `json:"pull_request_name"`
 
 	AuthorId string `json:"author_id"`
+
+	// из какой команды назначать ревьюверов, по умолчанию основная команда автора
+	TeamName string `json:"team_name,omitempty"`
+
+	// сколько ревьюверов назначить, в пределах min_reviewers..max_reviewers команды (по умолчанию max_reviewers)
+	ReviewersCount *int `json:"reviewers_count,omitempty"`
+
+	// создать PR в статусе DRAFT без назначения ревьюверов
+	Draft bool `json:"draft,omitempty"`
 }
@@ -0,0 +1,42 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+// параметры запроса GET /pullRequest/list
+type PullRequestListGetRequest struct {
+
+	// можно передать несколько раз или через запятую
+	Status []string `form:"status"`
+
+	AuthorId string `form:"author_id"`
+
+	ReviewerId string `form:"reviewer_id"`
+
+	TeamName string `form:"team_name"`
+
+	// границы в формате RFC 3339, включительно
+	CreatedFrom string `form:"created_from"`
+
+	CreatedTo string `form:"created_to"`
+
+	MergedFrom string `form:"merged_from"`
+
+	MergedTo string `form:"merged_to"`
+
+	// created_at | merged_at | pull_request_id
+	Sort string `form:"sort"`
+
+	// asc | desc
+	Order string `form:"order"`
+
+	Limit int `form:"limit"`
+
+	Cursor string `form:"cursor"`
+}
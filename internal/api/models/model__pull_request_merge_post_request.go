@@ -12,4 +12,9 @@ package models
 type PullRequestMergePostRequest struct {
 
 	PullRequestId string `json:"pull_request_id"`
+
+	// смержить без нужного числа апрувов (действие администратора, попадает в аудит)
+	Force bool `json:"force,omitempty"`
+
+	Reason string `json:"reason,omitempty"`
 }
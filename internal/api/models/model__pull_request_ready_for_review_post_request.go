@@ -0,0 +1,18 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type PullRequestReadyForReviewPostRequest struct {
+
+	PullRequestId string `json:"pull_request_id"`
+
+	// сколько ревьюверов назначить, в пределах min_reviewers..max_reviewers команды (по умолчанию max_reviewers)
+	ReviewersCount *int `json:"reviewers_count,omitempty"`
+}
@@ -14,4 +14,7 @@ type PullRequestReassignPostRequest struct {
 	PullRequestId string `json:"pull_request_id"`
 
 	OldUserId string `json:"old_user_id"`
+
+	// причина переназначения, попадает в историю PR
+	Reason string `json:"reason,omitempty"`
 }
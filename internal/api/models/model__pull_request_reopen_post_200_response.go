@@ -0,0 +1,20 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type PullRequestReopenPost200Response struct {
+
+	Pr PullRequest `json:"pr"`
+
+	Replacements []ReviewerReplacement `json:"replacements"`
+
+	// неактивные ревьюверы, для которых не нашлось замены
+	Unreplaced []string `json:"unreplaced"`
+}
@@ -0,0 +1,22 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type PullRequestReviewPostRequest struct {
+
+	PullRequestId string `json:"pull_request_id"`
+
+	ReviewerId string `json:"reviewer_id"`
+
+	// APPROVED | CHANGES_REQUESTED | COMMENTED
+	Verdict string `json:"verdict"`
+
+	Comment string `json:"comment,omitempty"`
+}
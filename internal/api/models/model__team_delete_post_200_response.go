@@ -0,0 +1,30 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type TeamDeletePost200Response struct {
+
+	TeamName string `json:"team_name"`
+
+	// участники удалённой команды
+	Members []string `json:"members"`
+
+	// команда, куда переведены участники (пусто, если они остались без команды)
+	MovedToTeamName string `json:"moved_to_team_name,omitempty"`
+
+	// DRAFT/OPEN PR команды; ревьюверы для них теперь берутся из новой команды
+	OpenPullRequests []string `json:"open_pull_requests"`
+
+	// OPEN PR, где менялся состав ревьюверов
+	PullRequests []ReassignedPullRequest `json:"pull_requests"`
+
+	// PR, у которых ревьювер снят без замены
+	ShortOfReviewers []string `json:"short_of_reviewers"`
+}
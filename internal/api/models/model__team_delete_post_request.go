@@ -0,0 +1,24 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type TeamDeletePostRequest struct {
+
+	TeamName string `json:"team_name"`
+
+	// refuse | move | detach, по умолчанию refuse
+	Members string `json:"members,omitempty"`
+
+	// команда, куда переводятся участники при members = move
+	MoveToTeamName string `json:"move_to_team_name,omitempty"`
+
+	// keep | reassign, по умолчанию keep
+	OpenReviews string `json:"open_reviews,omitempty"`
+}
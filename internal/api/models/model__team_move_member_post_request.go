@@ -0,0 +1,23 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type TeamMoveMemberPostRequest struct {
+
+	UserId string `json:"user_id"`
+
+	// из какой команды переводить, по умолчанию основная команда пользователя
+	FromTeamName string `json:"from_team_name,omitempty"`
+
+	ToTeamName string `json:"to_team_name"`
+
+	// keep | reassign (внутри старой команды), по умолчанию keep
+	OpenReviews string `json:"open_reviews,omitempty"`
+}
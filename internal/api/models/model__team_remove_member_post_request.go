@@ -0,0 +1,20 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type TeamRemoveMemberPostRequest struct {
+
+	TeamName string `json:"team_name"`
+
+	UserId string `json:"user_id"`
+
+	// keep | reassign, по умолчанию keep
+	OpenReviews string `json:"open_reviews,omitempty"`
+}
@@ -0,0 +1,17 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type TeamRenamePostRequest struct {
+
+	TeamName string `json:"team_name"`
+
+	NewTeamName string `json:"new_team_name"`
+}
@@ -0,0 +1,30 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type TeamUpdateSettingsPostRequest struct {
+
+	TeamName string `json:"team_name"`
+
+	// random | round_robin | least_loaded | weighted
+	AssignmentStrategy *string `json:"assignment_strategy,omitempty"`
+
+	MinReviewers *int `json:"min_reviewers,omitempty"`
+
+	MaxReviewers *int `json:"max_reviewers,omitempty"`
+
+	RequiredApprovals *int `json:"required_approvals,omitempty"`
+
+	// пустая строка убирает вышестоящую команду
+	ParentTeamName *string `json:"parent_team_name,omitempty"`
+
+	// заменяет список запасных команд целиком
+	FallbackTeamNames *[]string `json:"fallback_team_names,omitempty"`
+}
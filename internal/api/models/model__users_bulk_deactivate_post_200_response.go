@@ -0,0 +1,21 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type UsersBulkDeactivatePost200Response struct {
+
+	DeactivatedUsers []string `json:"deactivated_users"`
+
+	// все OPEN PR, где менялся состав ревьюверов
+	PullRequests []ReassignedPullRequest `json:"pull_requests"`
+
+	// PR, у которых ревьювер снят без замены
+	ShortOfReviewers []string `json:"short_of_reviewers"`
+}
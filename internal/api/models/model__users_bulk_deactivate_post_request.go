@@ -0,0 +1,18 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+// нужно передать либо user_ids, либо team_name
+type UsersBulkDeactivatePostRequest struct {
+
+	UserIds []string `json:"user_ids,omitempty"`
+
+	TeamName string `json:"team_name,omitempty"`
+}
@@ -0,0 +1,24 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type UsersDeletePost200Response struct {
+
+	UserId string `json:"user_id"`
+
+	// DRAFT/OPEN PR пользователя, закрытые при удалении
+	ClosedPullRequests []string `json:"closed_pull_requests"`
+
+	// все OPEN PR, где менялся состав ревьюверов
+	PullRequests []ReassignedPullRequest `json:"pull_requests"`
+
+	// PR, у которых ревьювер снят без замены
+	ShortOfReviewers []string `json:"short_of_reviewers"`
+}
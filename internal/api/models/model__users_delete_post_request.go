@@ -0,0 +1,18 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type UsersDeletePostRequest struct {
+
+	UserId string `json:"user_id"`
+
+	// refuse | close: что делать с DRAFT/OPEN PR пользователя, по умолчанию refuse
+	AuthoredPullRequests string `json:"authored_pull_requests,omitempty"`
+}
@@ -0,0 +1,18 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type UsersListGet200Response struct {
+
+	Users []User `json:"users"`
+
+	// курсор следующей страницы, пусто на последней странице
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,26 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+// параметры запроса GET /users/list
+type UsersListGetRequest struct {
+
+	// пользователи, состоящие в команде (основной или дополнительной)
+	TeamName string `form:"team_name"`
+
+	IsActive *bool `form:"is_active"`
+
+	// начало имени без учёта регистра
+	NamePrefix string `form:"name_prefix"`
+
+	Limit int `form:"limit"`
+
+	Cursor string `form:"cursor"`
+}
@@ -0,0 +1,24 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type UsersUpdatePostRequest struct {
+
+	UserId string `json:"user_id"`
+
+	Username *string `json:"username,omitempty"`
+
+	IsActive *bool `json:"is_active,omitempty"`
+
+	ReviewWeight *int `json:"review_weight,omitempty"`
+
+	// новая основная команда, пользователь должен уже в ней состоять
+	TeamName *string `json:"team_name,omitempty"`
+}
@@ -0,0 +1,20 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type WebhooksAddPostRequest struct {
+
+	Url string `json:"url"`
+
+	// ключ HMAC-SHA256 для подписи тела (заголовок X-Webhook-Signature-256)
+	Secret string `json:"secret"`
+
+	Events []string `json:"events"`
+}
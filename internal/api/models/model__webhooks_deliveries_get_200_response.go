@@ -0,0 +1,16 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type WebhooksDeliveriesGet200Response struct {
+
+	// от новых к старым
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
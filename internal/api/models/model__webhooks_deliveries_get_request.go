@@ -0,0 +1,21 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+// параметры запроса GET /webhooks/deliveries
+type WebhooksDeliveriesGetRequest struct {
+
+	SubscriptionId int64 `form:"subscription_id"`
+
+	// pending | delivered | failed
+	Status string `form:"status"`
+
+	Limit int `form:"limit"`
+}
@@ -14,4 +14,7 @@ type ErrorResponseError struct {
 	Code string `json:"code"`
 
 	Message string `json:"message"`
+
+	// дополнительные сведения об ошибке, например причины исключения кандидатов (user_id -> причина)
+	Details map[string]string `json:"details,omitempty"`
 }
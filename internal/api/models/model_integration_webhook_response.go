@@ -0,0 +1,24 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+// результат обработки входящего вебхука системы контроля версий
+type IntegrationWebhookResponse struct {
+
+	DeliveryId string `json:"delivery_id,omitempty"`
+
+	// processed | ignored | duplicate
+	Status string `json:"status"`
+
+	PullRequestId string `json:"pull_request_id,omitempty"`
+
+	// почему событие пропущено
+	Reason string `json:"reason,omitempty"`
+}
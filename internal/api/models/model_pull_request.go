@@ -21,12 +21,26 @@ type PullRequest struct {
 
 	AuthorId string `json:"author_id"`
 
+	// команда, из которой назначаются ревьюверы
+	TeamName string `json:"team_name,omitempty"`
+
 	Status string `json:"status"`
 
-	// user_id назначенных ревьюверов (0..2)
+	// user_id назначенных ревьюверов (0..max_reviewers команды)
 	AssignedReviewers []string `json:"assigned_reviewers"`
 
 	CreatedAt *time.Time `json:"createdAt,omitempty"`
 
 	MergedAt *time.Time `json:"mergedAt,omitempty"`
+
+	ClosedAt *time.Time `json:"closedAt,omitempty"`
+
+	// из какой команды назначен каждый ревьювер
+	ReviewerSources []ReviewerSource `json:"reviewer_sources,omitempty"`
+
+	// вердикты ревьюверов, уже оставивших ревью
+	Reviews []PullRequestReview `json:"reviews,omitempty"`
+
+	// заполнено, если PR был смержен принудительно
+	MergeOverride *PullRequestMergeOverride `json:"merge_override,omitempty"`
 }
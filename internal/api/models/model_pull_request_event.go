@@ -0,0 +1,45 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+import (
+	"time"
+)
+
+// запись журнала событий PR (append-only)
+type PullRequestEvent struct {
+
+	Id int64 `json:"id"`
+
+	PullRequestId string `json:"pull_request_id"`
+
+	// created | assigned | reassigned | unassigned | status_changed | merged
+	Type string `json:"type"`
+
+	// кто выполнил действие, пусто для системных действий
+	ActorId string `json:"actor_id,omitempty"`
+
+	// для assigned / unassigned
+	ReviewerId string `json:"reviewer_id,omitempty"`
+
+	// для reassigned
+	OldReviewerId string `json:"old_reviewer_id,omitempty"`
+
+	NewReviewerId string `json:"new_reviewer_id,omitempty"`
+
+	// для created / status_changed / merged
+	FromStatus string `json:"from_status,omitempty"`
+
+	ToStatus string `json:"to_status,omitempty"`
+
+	Reason string `json:"reason,omitempty"`
+
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
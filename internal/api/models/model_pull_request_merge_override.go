@@ -0,0 +1,28 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+import (
+	"time"
+)
+
+// запись аудита о принудительном merge без нужного числа апрувов
+type PullRequestMergeOverride struct {
+
+	ForcedBy string `json:"forced_by"`
+
+	Reason string `json:"reason,omitempty"`
+
+	Approvals int `json:"approvals"`
+
+	RequiredApprovals int `json:"required_approvals"`
+
+	ForcedAt *time.Time `json:"forcedAt,omitempty"`
+}
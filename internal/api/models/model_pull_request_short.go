@@ -9,6 +9,10 @@
 
 package models
 
+import (
+	"time"
+)
+
 type PullRequestShort struct {
 
 	PullRequestId string `json:"pull_request_id"`
@@ -18,4 +22,9 @@ type PullRequestShort struct {
 	AuthorId string `json:"author_id"`
 
 	Status string `json:"status"`
+
+	// вердикт пользователя по этому PR, если он уже оставил ревью
+	Verdict string `json:"verdict,omitempty"`
+
+	VerdictAt *time.Time `json:"verdictAt,omitempty"`
 }
@@ -0,0 +1,23 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+// PR, затронутый массовым переназначением
+type ReassignedPullRequest struct {
+
+	PullRequestId string `json:"pull_request_id"`
+
+	Replacements []ReviewerReplacement `json:"replacements"`
+
+	// ревьюверы, снятые без замены (не нашлось подходящего кандидата)
+	Removed []string `json:"removed"`
+
+	AssignedReviewers []string `json:"assigned_reviewers"`
+}
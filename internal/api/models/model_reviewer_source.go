@@ -0,0 +1,21 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+// откуда назначен ревьювер
+type ReviewerSource struct {
+
+	ReviewerId string `json:"reviewer_id"`
+
+	TeamName string `json:"team_name"`
+
+	// team - команда PR | fallback - запасная команда | parent - вышестоящая команда
+	Source string `json:"source"`
+}
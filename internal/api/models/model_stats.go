@@ -0,0 +1,48 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+type ReviewerStats struct {
+
+	UserId string `json:"user_id"`
+
+	// всего назначений ревьювером
+	Assigned int `json:"assigned"`
+
+	Open int `json:"open"`
+
+	Merged int `json:"merged"`
+}
+
+type TeamStats struct {
+
+	TeamName string `json:"team_name"`
+
+	// PR, созданные участниками команды
+	PullRequests int `json:"pull_requests"`
+
+	Open int `json:"open"`
+
+	Merged int `json:"merged"`
+
+	// назначений ревьюверов на PR команды
+	Assignments int `json:"assignments"`
+}
+
+type AuthorStats struct {
+
+	UserId string `json:"user_id"`
+
+	PullRequests int `json:"pull_requests"`
+
+	Open int `json:"open"`
+
+	Merged int `json:"merged"`
+}
@@ -16,4 +16,22 @@ type Team struct {
 	TeamName string `json:"team_name"`
 
 	Members []TeamMember `json:"members"`
+
+	// random | round_robin | least_loaded | weighted
+	AssignmentStrategy string `json:"assignment_strategy,omitempty"`
+
+	// минимум ревьюверов на PR, по умолчанию 0
+	MinReviewers *int `json:"min_reviewers,omitempty"`
+
+	// максимум ревьюверов на PR, по умолчанию 2
+	MaxReviewers *int `json:"max_reviewers,omitempty"`
+
+	// сколько апрувов нужно для merge, по умолчанию 0
+	RequiredApprovals *int `json:"required_approvals,omitempty"`
+
+	// вышестоящая команда, из которой добираются ревьюверы, если своих не хватает
+	ParentTeamName string `json:"parent_team_name,omitempty"`
+
+	// запасные команды того же уровня; опрашиваются по порядку до вышестоящей
+	FallbackTeamNames []string `json:"fallback_team_names,omitempty"`
 }
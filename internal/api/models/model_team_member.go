@@ -16,4 +16,13 @@ type TeamMember struct {
 	Username string `json:"username"`
 
 	IsActive bool `json:"is_active"`
+
+	// вес для стратегии weighted, по умолчанию 1
+	ReviewWeight int `json:"review_weight,omitempty"`
+
+	// основная команда пользователя (только в ответах)
+	PrimaryTeam string `json:"primary_team,omitempty"`
+
+	// другие команды, в которых состоит пользователь (только в ответах)
+	OtherTeams []string `json:"other_teams,omitempty"`
 }
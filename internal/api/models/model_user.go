@@ -18,4 +18,10 @@ type User struct {
 	TeamName string `json:"team_name"`
 
 	IsActive bool `json:"is_active"`
+
+	// вес для стратегии weighted
+	ReviewWeight int `json:"review_weight,omitempty"`
+
+	// все команды пользователя, включая основную team_name (только в ответах)
+	Teams []string `json:"teams,omitempty"`
 }
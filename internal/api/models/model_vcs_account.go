@@ -0,0 +1,21 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+// учётная запись в системе контроля версий, привязанная к пользователю сервиса
+type VcsAccount struct {
+
+	// github | gitlab
+	Provider string `json:"provider"`
+
+	Login string `json:"login"`
+
+	UserId string `json:"user_id"`
+}
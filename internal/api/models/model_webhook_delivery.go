@@ -0,0 +1,49 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// запись журнала доставок вебхука
+type WebhookDelivery struct {
+
+	DeliveryId int64 `json:"delivery_id"`
+
+	SubscriptionId int64 `json:"subscription_id"`
+
+	Event string `json:"event"`
+
+	Payload json.RawMessage `json:"payload"`
+
+	// pending | delivered | failed
+	Status string `json:"status"`
+
+	Attempts int `json:"attempts"`
+
+	// HTTP-статус ответа подписчика на последнюю попытку
+	ResponseStatus *int `json:"response_status,omitempty"`
+
+	LastError string `json:"last_error,omitempty"`
+
+	// для pending - когда будет следующая попытка
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+
+	LastAttemptAt *time.Time `json:"lastAttemptAt,omitempty"`
+
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+
+	// доставка, повтором которой является эта
+	RedeliveryOf *int64 `json:"redelivery_of,omitempty"`
+
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
@@ -0,0 +1,37 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+import (
+	"time"
+)
+
+// тело исходящего вебхука
+type WebhookPayload struct {
+
+	// pr.created | reviewer.assigned | reviewer.reassigned | pr.merged
+	Event string `json:"event"`
+
+	OccurredAt time.Time `json:"occurred_at"`
+
+	PullRequestId string `json:"pull_request_id"`
+
+	ActorId string `json:"actor_id,omitempty"`
+
+	// для reviewer.assigned
+	ReviewerId string `json:"reviewer_id,omitempty"`
+
+	// для reviewer.reassigned
+	OldReviewerId string `json:"old_reviewer_id,omitempty"`
+
+	NewReviewerId string `json:"new_reviewer_id,omitempty"`
+
+	Reason string `json:"reason,omitempty"`
+}
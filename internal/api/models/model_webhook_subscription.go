@@ -0,0 +1,27 @@
+/*
+ * PR Reviewer Assignment Service (Test Task, Fall 2025)
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package models
+
+import (
+	"time"
+)
+
+// подписка на исходящие вебхуки; секрет наружу не отдаётся
+type WebhookSubscription struct {
+
+	SubscriptionId int64 `json:"subscription_id"`
+
+	Url string `json:"url"`
+
+	// pr.created | reviewer.assigned | reviewer.reassigned | pr.merged
+	Events []string `json:"events"`
+
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
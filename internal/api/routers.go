@@ -15,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/kgugunava/avito-tech-internship/internal/api/handlers"
+	"github.com/kgugunava/avito-tech-internship/internal/service"
 )
 
 // Route is the information for every URI.
@@ -29,28 +30,63 @@ type Route struct {
 	HandlerFunc	gin.HandlerFunc
 }
 
+// routeRoles - роль, которая нужна для маршрута. Маршруты без записи доступны любому
+// аутентифицированному пользователю; права на конкретный PR проверяет сервис
+var routeRoles = map[string]string{
+	"TeamAddPost":                    service.RoleAdmin,
+	"TeamUpdateSettingsPost":         service.RoleAdmin,
+	"TeamAddMemberPost":              service.RoleAdmin,
+	"TeamRemoveMemberPost":           service.RoleAdmin,
+	"TeamMoveMemberPost":             service.RoleAdmin,
+	"TeamRenamePost":                 service.RoleAdmin,
+	"TeamDeletePost":                 service.RoleAdmin,
+	"UsersSetIsActivePost":           service.RoleAdmin,
+	"UsersBulkDeactivatePost":        service.RoleAdmin,
+	"UsersUpdatePost":                service.RoleAdmin,
+	"UsersDeletePost":                service.RoleAdmin,
+	"WebhooksAddPost":                service.RoleAdmin,
+	"WebhooksListGet":                service.RoleAdmin,
+	"WebhooksDeletePost":             service.RoleAdmin,
+	"WebhooksDeliveriesGet":          service.RoleAdmin,
+	"WebhooksRedeliverPost":          service.RoleAdmin,
+	"IntegrationsAccountsLinkPost":   service.RoleAdmin,
+	"IntegrationsAccountsUnlinkPost": service.RoleAdmin,
+	"IntegrationsAccountsListGet":    service.RoleAdmin,
+}
+
+// publicRoutes - маршруты без bearer-токена: внешние системы подписывают запросы своим секретом,
+// и его проверяет сам обработчик
+var publicRoutes = map[string]bool{
+	"IntegrationsGithubWebhookPost": true,
+	"IntegrationsGitlabWebhookPost": true,
+}
+
 // NewRouter returns a new router.
-func NewRouter(handleFunctions ApiHandleFunctions) *gin.Engine {
-	return NewRouterWithGinEngine(gin.Default(), handleFunctions)
+func NewRouter(handleFunctions ApiHandleFunctions, verifier *JWTVerifier) *gin.Engine {
+	return NewRouterWithGinEngine(gin.Default(), handleFunctions, verifier)
 }
 
 // NewRouter add routes to existing gin engine.
-func NewRouterWithGinEngine(router *gin.Engine, handleFunctions ApiHandleFunctions) *gin.Engine {
+func NewRouterWithGinEngine(router *gin.Engine, handleFunctions ApiHandleFunctions, verifier *JWTVerifier) *gin.Engine {
 	for _, route := range getRoutes(handleFunctions) {
 		if route.HandlerFunc == nil {
 			route.HandlerFunc = DefaultHandleFunc
 		}
+		handlers := []gin.HandlerFunc{Authorize(verifier, routeRoles[route.Name]), route.HandlerFunc}
+		if publicRoutes[route.Name] {
+			handlers = []gin.HandlerFunc{route.HandlerFunc}
+		}
 		switch route.Method {
 		case http.MethodGet:
-			router.GET(route.Pattern, route.HandlerFunc)
+			router.GET(route.Pattern, handlers...)
 		case http.MethodPost:
-			router.POST(route.Pattern, route.HandlerFunc)
+			router.POST(route.Pattern, handlers...)
 		case http.MethodPut:
-			router.PUT(route.Pattern, route.HandlerFunc)
+			router.PUT(route.Pattern, handlers...)
 		case http.MethodPatch:
-			router.PATCH(route.Pattern, route.HandlerFunc)
+			router.PATCH(route.Pattern, handlers...)
 		case http.MethodDelete:
-			router.DELETE(route.Pattern, route.HandlerFunc)
+			router.DELETE(route.Pattern, handlers...)
 		}
 	}
 
@@ -70,6 +106,12 @@ type ApiHandleFunctions struct {
 	TeamsAPI handlers.TeamsAPI
 	// Routes for the UsersAPI part of the API
 	UsersAPI handlers.UsersAPI
+	// Routes for the StatsAPI part of the API
+	StatsAPI handlers.StatsAPI
+	// Routes for the WebhooksAPI part of the API
+	WebhooksAPI handlers.WebhooksAPI
+	// Routes for the IntegrationsAPI part of the API
+	IntegrationsAPI handlers.IntegrationsAPI
 }
 
 func getRoutes(handleFunctions ApiHandleFunctions) []Route {
@@ -80,6 +122,24 @@ func getRoutes(handleFunctions ApiHandleFunctions) []Route {
 			"/pullRequest/create",
 			handleFunctions.PullRequestsAPI.PullRequestCreatePost,
 		},
+		{
+			"PullRequestGetGet",
+			http.MethodGet,
+			"/pullRequest/get",
+			handleFunctions.PullRequestsAPI.PullRequestGetGet,
+		},
+		{
+			"PullRequestHistoryGet",
+			http.MethodGet,
+			"/pullRequest/history",
+			handleFunctions.PullRequestsAPI.PullRequestHistoryGet,
+		},
+		{
+			"PullRequestListGet",
+			http.MethodGet,
+			"/pullRequest/list",
+			handleFunctions.PullRequestsAPI.PullRequestListGet,
+		},
 		{
 			"PullRequestMergePost",
 			http.MethodPost,
@@ -92,6 +152,30 @@ func getRoutes(handleFunctions ApiHandleFunctions) []Route {
 			"/pullRequest/reassign",
 			handleFunctions.PullRequestsAPI.PullRequestReassignPost,
 		},
+		{
+			"PullRequestReviewPost",
+			http.MethodPost,
+			"/pullRequest/review",
+			handleFunctions.PullRequestsAPI.PullRequestReviewPost,
+		},
+		{
+			"PullRequestReadyForReviewPost",
+			http.MethodPost,
+			"/pullRequest/readyForReview",
+			handleFunctions.PullRequestsAPI.PullRequestReadyForReviewPost,
+		},
+		{
+			"PullRequestClosePost",
+			http.MethodPost,
+			"/pullRequest/close",
+			handleFunctions.PullRequestsAPI.PullRequestClosePost,
+		},
+		{
+			"PullRequestReopenPost",
+			http.MethodPost,
+			"/pullRequest/reopen",
+			handleFunctions.PullRequestsAPI.PullRequestReopenPost,
+		},
 		{
 			"TeamAddPost",
 			http.MethodPost,
@@ -104,6 +188,42 @@ func getRoutes(handleFunctions ApiHandleFunctions) []Route {
 			"/team/get",
 			handleFunctions.TeamsAPI.TeamGetGet,
 		},
+		{
+			"TeamUpdateSettingsPost",
+			http.MethodPost,
+			"/team/updateSettings",
+			handleFunctions.TeamsAPI.TeamUpdateSettingsPost,
+		},
+		{
+			"TeamAddMemberPost",
+			http.MethodPost,
+			"/team/addMember",
+			handleFunctions.TeamsAPI.TeamAddMemberPost,
+		},
+		{
+			"TeamRemoveMemberPost",
+			http.MethodPost,
+			"/team/removeMember",
+			handleFunctions.TeamsAPI.TeamRemoveMemberPost,
+		},
+		{
+			"TeamMoveMemberPost",
+			http.MethodPost,
+			"/team/moveMember",
+			handleFunctions.TeamsAPI.TeamMoveMemberPost,
+		},
+		{
+			"TeamRenamePost",
+			http.MethodPost,
+			"/team/rename",
+			handleFunctions.TeamsAPI.TeamRenamePost,
+		},
+		{
+			"TeamDeletePost",
+			http.MethodPost,
+			"/team/delete",
+			handleFunctions.TeamsAPI.TeamDeletePost,
+		},
 		{
 			"UsersGetReviewGet",
 			http.MethodGet,
@@ -116,5 +236,101 @@ func getRoutes(handleFunctions ApiHandleFunctions) []Route {
 			"/users/setIsActive",
 			handleFunctions.UsersAPI.UsersSetIsActivePost,
 		},
+		{
+			"UsersBulkDeactivatePost",
+			http.MethodPost,
+			"/users/bulkDeactivate",
+			handleFunctions.UsersAPI.UsersBulkDeactivatePost,
+		},
+		{
+			"UsersGetGet",
+			http.MethodGet,
+			"/users/get",
+			handleFunctions.UsersAPI.UsersGetGet,
+		},
+		{
+			"UsersListGet",
+			http.MethodGet,
+			"/users/list",
+			handleFunctions.UsersAPI.UsersListGet,
+		},
+		{
+			"UsersUpdatePost",
+			http.MethodPost,
+			"/users/update",
+			handleFunctions.UsersAPI.UsersUpdatePost,
+		},
+		{
+			"UsersDeletePost",
+			http.MethodPost,
+			"/users/delete",
+			handleFunctions.UsersAPI.UsersDeletePost,
+		},
+		{
+			"StatsGet",
+			http.MethodGet,
+			"/stats",
+			handleFunctions.StatsAPI.StatsGet,
+		},
+		{
+			"WebhooksAddPost",
+			http.MethodPost,
+			"/webhooks/add",
+			handleFunctions.WebhooksAPI.WebhooksAddPost,
+		},
+		{
+			"WebhooksListGet",
+			http.MethodGet,
+			"/webhooks/list",
+			handleFunctions.WebhooksAPI.WebhooksListGet,
+		},
+		{
+			"WebhooksDeletePost",
+			http.MethodPost,
+			"/webhooks/delete",
+			handleFunctions.WebhooksAPI.WebhooksDeletePost,
+		},
+		{
+			"WebhooksDeliveriesGet",
+			http.MethodGet,
+			"/webhooks/deliveries",
+			handleFunctions.WebhooksAPI.WebhooksDeliveriesGet,
+		},
+		{
+			"WebhooksRedeliverPost",
+			http.MethodPost,
+			"/webhooks/redeliver",
+			handleFunctions.WebhooksAPI.WebhooksRedeliverPost,
+		},
+		{
+			"IntegrationsGithubWebhookPost",
+			http.MethodPost,
+			"/integrations/github/webhook",
+			handleFunctions.IntegrationsAPI.IntegrationsGithubWebhookPost,
+		},
+		{
+			"IntegrationsGitlabWebhookPost",
+			http.MethodPost,
+			"/integrations/gitlab/webhook",
+			handleFunctions.IntegrationsAPI.IntegrationsGitlabWebhookPost,
+		},
+		{
+			"IntegrationsAccountsLinkPost",
+			http.MethodPost,
+			"/integrations/accounts/link",
+			handleFunctions.IntegrationsAPI.IntegrationsAccountsLinkPost,
+		},
+		{
+			"IntegrationsAccountsUnlinkPost",
+			http.MethodPost,
+			"/integrations/accounts/unlink",
+			handleFunctions.IntegrationsAPI.IntegrationsAccountsUnlinkPost,
+		},
+		{
+			"IntegrationsAccountsListGet",
+			http.MethodGet,
+			"/integrations/accounts/list",
+			handleFunctions.IntegrationsAPI.IntegrationsAccountsListGet,
+		},
 	}
 }
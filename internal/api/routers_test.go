@@ -0,0 +1,179 @@
+package api_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kgugunava/avito-tech-internship/internal/adapters/memory"
+	"github.com/kgugunava/avito-tech-internship/internal/api"
+	"github.com/kgugunava/avito-tech-internship/internal/api/handlers"
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	"github.com/kgugunava/avito-tech-internship/internal/service"
+)
+
+const testSecret = "secret"
+
+// newTestRouter собирает приложение как app.NewApp со STORAGE=memory, но без конфигурации из окружения
+func newTestRouter() *gin.Engine {
+    gin.SetMode(gin.TestMode)
+
+    store := memory.NewStore()
+    webhooks := service.NewWebhookService(memory.NewWebhookRepository(store))
+    pullRequestService := service.NewPullRequestService(memory.NewPullRequestRepository(store), memory.NewUnitOfWork(store), webhooks)
+    integrationService := service.NewIntegrationService(memory.NewIntegrationRepository(store), pullRequestService, "", "")
+
+    return api.NewRouterWithGinEngine(gin.New(), api.ApiHandleFunctions{
+        PullRequestsAPI: *handlers.NewPullRequestAPI(pullRequestService),
+        TeamsAPI:        *handlers.NewTeamsAPI(service.NewTeamService(memory.NewTeamRepository(store), webhooks)),
+        UsersAPI:        *handlers.NewUserAPI(service.NewUserService(memory.NewUserRepository(store), webhooks)),
+        StatsAPI:        *handlers.NewStatsAPI(service.NewStatsService(memory.NewStatsRepository(store))),
+        WebhooksAPI:     *handlers.NewWebhooksAPI(webhooks),
+        IntegrationsAPI: *handlers.NewIntegrationsAPI(integrationService),
+    }, api.NewJWTVerifier(testSecret, nil))
+}
+
+// token выпускает HS256-токен, который примет api.NewJWTVerifier(testSecret, nil)
+func token(t *testing.T, userId, role string) string {
+    t.Helper()
+
+    segment := func(v any) string {
+        data, err := json.Marshal(v)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return base64.RawURLEncoding.EncodeToString(data)
+    }
+    unsigned := segment(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." +
+        segment(map[string]any{"sub": userId, "role": role, "exp": time.Now().Add(time.Hour).Unix()})
+
+    mac := hmac.New(sha256.New, []byte(testSecret))
+    mac.Write([]byte(unsigned))
+    return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// call выполняет запрос к router, проверяет статус и раскладывает ответ в response (если не nil)
+func call(t *testing.T, router http.Handler, method, path, bearer string, body any, wantStatus int, response any) {
+    t.Helper()
+
+    var payload bytes.Buffer
+    if body != nil {
+        if err := json.NewEncoder(&payload).Encode(body); err != nil {
+            t.Fatal(err)
+        }
+    }
+    req := httptest.NewRequest(method, path, &payload)
+    req.Header.Set("Content-Type", "application/json")
+    if bearer != "" {
+        req.Header.Set("Authorization", "Bearer "+bearer)
+    }
+
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, req)
+
+    if rec.Code != wantStatus {
+        t.Fatalf("%s %s: status %d, want %d: %s", method, path, rec.Code, wantStatus, rec.Body.String())
+    }
+    if response != nil {
+        if err := json.Unmarshal(rec.Body.Bytes(), response); err != nil {
+            t.Fatalf("%s %s: decode %s: %v", method, path, rec.Body.String(), err)
+        }
+    }
+}
+
+func TestPullRequestLifecycle(t *testing.T) {
+    router := newTestRouter()
+    admin := token(t, "admin", service.RoleAdmin)
+    author := token(t, "u1", service.RoleUser)
+
+    requiredApprovals := 1
+    call(t, router, http.MethodPost, "/team/add", admin, models.Team{
+        TeamName:          "backend",
+        RequiredApprovals: &requiredApprovals,
+        Members: []models.TeamMember{
+            {UserId: "u1", Username: "Alice", IsActive: true},
+            {UserId: "u2", Username: "Bob", IsActive: true},
+            {UserId: "u3", Username: "Carol", IsActive: true},
+        },
+    }, http.StatusCreated, nil)
+
+    call(t, router, http.MethodPost, "/pullRequest/create", "", models.PullRequestCreatePostRequest{
+        PullRequestId: "pr-1", PullRequestName: "Add search", AuthorId: "u1",
+    }, http.StatusUnauthorized, nil)
+
+    var created models.PullRequestCreatePost201Response
+    call(t, router, http.MethodPost, "/pullRequest/create", author, models.PullRequestCreatePostRequest{
+        PullRequestId: "pr-1", PullRequestName: "Add search", AuthorId: "u1",
+    }, http.StatusCreated, &created)
+    if created.Pr.Status != service.StatusOpen || len(created.Pr.AssignedReviewers) != 2 {
+        t.Fatalf("created PR: status %s, reviewers %v; want OPEN with 2 reviewers", created.Pr.Status, created.Pr.AssignedReviewers)
+    }
+    for _, reviewer := range created.Pr.AssignedReviewers {
+        if reviewer == "u1" {
+            t.Fatalf("author is assigned as a reviewer: %v", created.Pr.AssignedReviewers)
+        }
+    }
+    reviewer := created.Pr.AssignedReviewers[0]
+
+    var errResponse models.ErrorResponse
+    call(t, router, http.MethodPost, "/pullRequest/merge", author, models.PullRequestMergePostRequest{
+        PullRequestId: "pr-1",
+    }, http.StatusConflict, &errResponse)
+    if errResponse.Error.Code != "NOT_APPROVED" {
+        t.Fatalf("merge without approvals: code %s, want NOT_APPROVED", errResponse.Error.Code)
+    }
+
+    review := models.PullRequestReviewPostRequest{PullRequestId: "pr-1", ReviewerId: reviewer, Verdict: service.VerdictApproved}
+    call(t, router, http.MethodPost, "/pullRequest/review", author, review, http.StatusForbidden, nil)
+    call(t, router, http.MethodPost, "/pullRequest/review", token(t, reviewer, service.RoleUser), review, http.StatusOK, nil)
+
+    var merged models.PullRequestCreatePost201Response
+    call(t, router, http.MethodPost, "/pullRequest/merge", author, models.PullRequestMergePostRequest{
+        PullRequestId: "pr-1",
+    }, http.StatusOK, &merged)
+    if merged.Pr.Status != service.StatusMerged || merged.Pr.MergedAt == nil {
+        t.Fatalf("merged PR: status %s, mergedAt %v; want MERGED with mergedAt", merged.Pr.Status, merged.Pr.MergedAt)
+    }
+
+    var history models.PullRequestHistoryGet200Response
+    call(t, router, http.MethodGet, "/pullRequest/history?pull_request_id=pr-1", author, nil, http.StatusOK, &history)
+    types := []string{}
+    for _, event := range history.Events {
+        types = append(types, event.Type)
+    }
+    want := []string{service.EventCreated, service.EventAssigned, service.EventAssigned, service.EventMerged}
+    if len(types) != len(want) {
+        t.Fatalf("history %v, want %v", types, want)
+    }
+    for i := range want {
+        if types[i] != want[i] {
+            t.Fatalf("history %v, want %v", types, want)
+        }
+    }
+}
+
+func TestWebhookBodyLimit(t *testing.T) {
+    router := newTestRouter()
+
+    for _, path := range []string{"/integrations/github/webhook", "/integrations/gitlab/webhook"} {
+        req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(make([]byte, 25<<20+1)))
+        rec := httptest.NewRecorder()
+        router.ServeHTTP(rec, req)
+
+        var errResponse models.ErrorResponse
+        if err := json.Unmarshal(rec.Body.Bytes(), &errResponse); err != nil {
+            t.Fatalf("%s: decode %s: %v", path, rec.Body.String(), err)
+        }
+        if rec.Code != http.StatusRequestEntityTooLarge || errResponse.Error.Code != "PAYLOAD_TOO_LARGE" {
+            t.Fatalf("%s: status %d, code %s; want 413 PAYLOAD_TOO_LARGE", path, rec.Code, errResponse.Error.Code)
+        }
+    }
+}
@@ -1,8 +1,14 @@
 package app
 
 import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"log"
+
 	"github.com/gin-gonic/gin"
 
+	"github.com/kgugunava/avito-tech-internship/internal/adapters/memory"
 	"github.com/kgugunava/avito-tech-internship/internal/adapters/postgres"
 	"github.com/kgugunava/avito-tech-internship/internal/api"
 	"github.com/kgugunava/avito-tech-internship/internal/api/handlers"
@@ -13,54 +19,137 @@ import (
 type App struct {
 	Cfg config.Config
 	Router *gin.Engine
-	DB *postgres.Postgres
+	DB *postgres.Postgres // nil при STORAGE=memory
+	Webhooks *service.WebhookService
 }
 
 func NewApp() *App {
 	app := &App{
 		Cfg: config.NewConfig(),
 	}
-	app.Cfg.InitConfig()
+	if err := app.Cfg.InitConfig(); err != nil {
+		panic(err)
+	}
 
-	db := postgres.NewPostgres()
+	var pullRequestRepository service.PullRequestRepository
+	var teamRepository service.TeamRepository
+	var userRepository service.UserRepository
+	var statsRepository service.StatsRepository
+	var webhookRepository service.WebhookRepository
+	var integrationRepository service.IntegrationRepository
+	var unitOfWork service.UnitOfWork
 
-    if err := db.ConnectToPostgresMainDatabase(app.Cfg); err != nil {
-        panic(err)
-    }
+	if app.Cfg.Storage == config.StorageMemory {
+		// данные живут только в памяти процесса и пропадают при перезапуске
+		log.Println("Using in-memory storage")
+		store := memory.NewStore()
+		pullRequestRepository = memory.NewPullRequestRepository(store)
+		teamRepository = memory.NewTeamRepository(store)
+		userRepository = memory.NewUserRepository(store)
+		statsRepository = memory.NewStatsRepository(store)
+		webhookRepository = memory.NewWebhookRepository(store)
+		integrationRepository = memory.NewIntegrationRepository(store)
+		unitOfWork = memory.NewUnitOfWork(store)
+	} else {
+		db, err := connectDatabase(app.Cfg)
+		if err != nil {
+			panic(err)
+		}
 
-	if err := db.CreateDatabase(app.Cfg); err != nil {
-        panic(err)
-    }
-    
-    if err := db.ConnectToDatabase(app.Cfg); err != nil {
-        panic(err)
-    }
-    
-    if err := db.CreateDatabaseTables(); err != nil {
-        panic(err)
-    }
-    
-    app.DB = &db
+		// реплики применяют миграции по очереди под advisory lock, поэтому это безопасно при параллельном старте
+		applied, err := db.MigrateUp(context.Background())
+		if err != nil {
+			panic(err)
+		}
+		for _, m := range applied {
+			log.Printf("Applied migration %04d_%s\n", m.Version, m.Name)
+		}
+
+		app.DB = db
 
-	pullRequestRepository := postgres.NewPullRequestRepository(app.DB.Pool)
-	teamRepository := postgres.NewTeamRepository(app.DB.Pool)
-	userRepository := postgres.NewUserRepository(app.DB.Pool)
+		pullRequestRepository = postgres.NewPullRequestRepository(app.DB.Pool)
+		teamRepository = postgres.NewTeamRepository(app.DB.Pool)
+		userRepository = postgres.NewUserRepository(app.DB.Pool)
+		statsRepository = postgres.NewStatsRepository(app.DB.Pool)
+		webhookRepository = postgres.NewWebhookRepository(app.DB.Pool)
+		integrationRepository = postgres.NewIntegrationRepository(app.DB.Pool)
+		unitOfWork = postgres.NewUnitOfWork(app.DB.Pool)
+	}
 
-	pullRequestService := service.NewPullRequestService(pullRequestRepository)
-	teamService := service.NewTeamService(teamRepository)
-	userService := service.NewUserService(userRepository)
+	app.Webhooks = service.NewWebhookService(webhookRepository)
+	pullRequestService := service.NewPullRequestService(pullRequestRepository, unitOfWork, app.Webhooks)
+	teamService := service.NewTeamService(teamRepository, app.Webhooks)
+	userService := service.NewUserService(userRepository, app.Webhooks)
+	statsService := service.NewStatsService(statsRepository)
+	integrationService := service.NewIntegrationService(integrationRepository, pullRequestService, app.Cfg.GitHubWebhookSecret, app.Cfg.GitLabWebhookToken)
 
 	apiPullRequests := handlers.NewPullRequestAPI(pullRequestService)
 	apiTeams := handlers.NewTeamsAPI(teamService)
 	apiUsers := handlers.NewUserAPI(userService)
+	apiStats := handlers.NewStatsAPI(statsService)
+	apiWebhooks := handlers.NewWebhooksAPI(app.Webhooks)
+	apiIntegrations := handlers.NewIntegrationsAPI(integrationService)
 
 	apiHandleFunctions := api.ApiHandleFunctions{
 		PullRequestsAPI: *apiPullRequests,
 		TeamsAPI: *apiTeams,
 		UsersAPI: *apiUsers,
+		StatsAPI: *apiStats,
+		WebhooksAPI: *apiWebhooks,
+		IntegrationsAPI: *apiIntegrations,
 	}
     
-    app.Router = api.NewRouter(apiHandleFunctions)
+    verifier, err := newJWTVerifier(app.Cfg)
+    if err != nil {
+        panic(err)
+    }
+
+    app.Router = api.NewRouter(apiHandleFunctions, verifier)
     
     return app
-}
\ No newline at end of file
+}
+
+// Run запускает рассылку вебхуков и HTTP-сервер
+func (app *App) Run() error {
+	go app.Webhooks.Run(context.Background())
+
+	return app.Router.Run(app.Cfg.ServerAddress)
+}
+
+// newJWTVerifier настраивает проверку токенов; без ключей все запросы к API получали бы 401,
+// поэтому сервис без них не стартует
+func newJWTVerifier(cfg config.Config) (*api.JWTVerifier, error) {
+	if cfg.JWTSecret == "" && cfg.JWTPublicKeyFile == "" {
+		return nil, errors.New("JWT_SECRET or JWT_PUBLIC_KEY_FILE must be set")
+	}
+
+	var publicKey *rsa.PublicKey
+	if cfg.JWTPublicKeyFile != "" {
+		key, err := api.LoadRSAPublicKey(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = key
+	}
+
+	return api.NewJWTVerifier(cfg.JWTSecret, publicKey), nil
+}
+
+// connectDatabase создаёт базу сервиса, если её нет, и подключается к ней
+func connectDatabase(cfg config.Config) (*postgres.Postgres, error) {
+	db := postgres.NewPostgres()
+
+	if err := db.ConnectToPostgresMainDatabase(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := db.CreateDatabase(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := db.ConnectToDatabase(cfg); err != nil {
+		return nil, err
+	}
+
+	return &db, nil
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/kgugunava/avito-tech-internship/internal/config"
+)
+
+const migrateUsage = "usage: migrate status | up | down [steps]"
+
+// RunMigrate выполняет подкоманду migrate: status - список миграций, up - применить все новые,
+// down [steps] - откатить последние steps миграций (по умолчанию одну)
+func RunMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	steps := 1
+	switch args[0] {
+	case "status", "up":
+		if len(args) > 1 {
+			return errors.New(migrateUsage)
+		}
+	case "down":
+		if len(args) > 2 {
+			return errors.New(migrateUsage)
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return errors.New("steps must be a positive integer")
+			}
+			steps = n
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	cfg := config.NewConfig()
+	if err := cfg.InitConfig(); err != nil {
+		return err
+	}
+	if cfg.Storage == config.StorageMemory {
+		return errors.New("migrations are not used with STORAGE=memory")
+	}
+
+	db, err := connectDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Pool.Close()
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "status":
+		statuses, err := db.MigrationsStatus(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied at " + s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	case "up":
+		applied, err := db.MigrateUp(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		reverted, err := db.MigrateDown(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	}
+
+	return nil
+}
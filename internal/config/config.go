@@ -1,21 +1,35 @@
 package config
 
 import (
+    "fmt"
     "os"
 )
 
 type Config struct {
-    ServerAddress string `env:"SERVER_ADDRESS"`
-    Port          string `env:"SERVER_PORT"`
-    DbUser        string `env:"DB_USER"`
-    DbPassword    string `env:"DB_PASSWORD"`
-    DbHost        string `env:"DB_HOST"`
-    DbPort        string `env:"DB_PORT"`
-    SslMode       string `env:"SSL_MODE"`
-    DbName        string `env:"DB_NAME"`
-    JWTSecret     string `env:"JWT_SECRET"`
+    ServerAddress       string `env:"SERVER_ADDRESS"`
+    Port                string `env:"SERVER_PORT"`
+    DbUser              string `env:"DB_USER"`
+    DbPassword          string `env:"DB_PASSWORD"`
+    DbHost              string `env:"DB_HOST"`
+    DbPort              string `env:"DB_PORT"`
+    SslMode             string `env:"SSL_MODE"`
+    DbName              string `env:"DB_NAME"`
+    // ключи проверки bearer-токенов: секрет HS256 и/или путь к PEM с публичным ключом RS256
+    JWTSecret           string `env:"JWT_SECRET"`
+    JWTPublicKeyFile    string `env:"JWT_PUBLIC_KEY_FILE"`
+    // секрет вебхука GitHub; пусто - приём событий GitHub выключен
+    GitHubWebhookSecret string `env:"GITHUB_WEBHOOK_SECRET"`
+    // секретный токен вебхука GitLab; пусто - приём событий GitLab выключен
+    GitLabWebhookToken  string `env:"GITLAB_WEBHOOK_TOKEN"`
+    // postgres (по умолчанию) | memory - хранилище в памяти процесса, без базы
+    Storage             string `env:"STORAGE"`
 }
 
+const (
+    StoragePostgres = "postgres"
+    StorageMemory   = "memory"
+)
+
 func NewConfig() Config {
     return Config{}
 }
@@ -29,5 +43,16 @@ func (cfg *Config) InitConfig() error {
     cfg.DbPort = os.Getenv("DB_PORT")
     cfg.SslMode = os.Getenv("SSL_MODE")
     cfg.DbName = os.Getenv("DB_NAME")
+    cfg.JWTSecret = os.Getenv("JWT_SECRET")
+    cfg.JWTPublicKeyFile = os.Getenv("JWT_PUBLIC_KEY_FILE")
+    cfg.GitHubWebhookSecret = os.Getenv("GITHUB_WEBHOOK_SECRET")
+    cfg.GitLabWebhookToken = os.Getenv("GITLAB_WEBHOOK_TOKEN")
+    cfg.Storage = os.Getenv("STORAGE")
+    if cfg.Storage == "" {
+        cfg.Storage = StoragePostgres
+    }
+    if cfg.Storage != StoragePostgres && cfg.Storage != StorageMemory {
+        return fmt.Errorf("STORAGE must be %s or %s, got %q", StoragePostgres, StorageMemory, cfg.Storage)
+    }
     return nil
 }
\ No newline at end of file
@@ -0,0 +1,26 @@
+package models
+
+import "errors"
+
+// ошибки хранилища; адаптеры (postgres, memory) возвращают именно их, чтобы сервисы не зависели от реализации
+var (
+    ErrPullRequestNotFound = errors.New("pull request not found")
+    ErrPullRequestExists   = errors.New("pull request already exists")
+
+    ErrTeamNotFound        = errors.New("team not found")
+    ErrTeamExists          = errors.New("team already exists")
+    ErrMemberExists        = errors.New("user is already a member of the team")
+    ErrNotTeamMember       = errors.New("user is not a member of the team")
+    ErrTeamNotEmpty        = errors.New("team has members")
+    ErrTeamHierarchyCycle  = errors.New("parent team would create a cycle in the team hierarchy")
+    ErrInvalidFallbackTeam = errors.New("team cannot be its own fallback team")
+
+    ErrUserNotFound            = errors.New("user not found")
+    ErrUserHasOpenPullRequests = errors.New("user is the author of open pull requests")
+
+    ErrWebhookNotFound   = errors.New("webhook subscription not found")
+    ErrDeliveryNotFound  = errors.New("webhook delivery not found")
+    ErrDeliveryLeaseLost = errors.New("webhook delivery lease expired and it was claimed again")
+
+    ErrAccountNotFound = errors.New("vcs account is not linked to a user")
+)
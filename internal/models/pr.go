@@ -1,8 +1,36 @@
 package models
 
+import "time"
+
 type PullRequestDB struct {
     PRID      int        `db:"pr_id"`
     Name      string     `db:"name"`
     AuthorID  int        `db:"author_id"`
     Status    string     `db:"status"` // OPEN / MERGED
-}
\ No newline at end of file
+}
+
+// PullRequestFilter - фильтры, сортировка и keyset-пагинация для списка PR
+type PullRequestFilter struct {
+    Statuses    []string
+    AuthorID    string
+    ReviewerID  string
+    TeamName    string
+    CreatedFrom *time.Time
+    CreatedTo   *time.Time
+    MergedFrom  *time.Time
+    MergedTo    *time.Time
+
+    SortBy string // created_at | merged_at | pull_request_id
+    Desc   bool
+    Limit  int
+
+    // позиция последней записи предыдущей страницы
+    After *PullRequestCursor
+}
+
+type PullRequestCursor struct {
+    SortBy string `json:"s"`
+    Desc   bool   `json:"d"`
+    Value  string `json:"v,omitempty"`
+    ID     string `json:"id"`
+}
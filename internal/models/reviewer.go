@@ -1,6 +1,77 @@
 package models
 
+import "time"
+
 type ReviewerDB struct {
     PRID   int `db:"pr_id"`
     UserID int `db:"user_id"`
-}
\ No newline at end of file
+}
+
+type ReviewerCandidate struct {
+    UserID         string     `db:"user_id"`
+    Weight         int        `db:"review_weight"`
+    OpenReviews    int        `db:"open_reviews"`
+    LastAssignedAt *time.Time `db:"last_assigned_at"`
+}
+
+// источники ревьюверов при назначении
+const (
+    ReviewerSourceTeam     = "team"
+    ReviewerSourceFallback = "fallback"
+    ReviewerSourceParent   = "parent"
+)
+
+// ReviewerPool - команда, из которой можно добирать ревьюверов, в порядке опроса
+type ReviewerPool struct {
+    TeamID   int
+    TeamName string
+    Strategy string
+    Source   string
+}
+
+// SelectedReviewer - выбранный ревьювер и команда, из которой он пришёл
+type SelectedReviewer struct {
+    UserID   string
+    TeamID   int
+    TeamName string
+    Source   string
+}
+
+type TeamAssignmentSettings struct {
+    Strategy     string `db:"assignment_strategy"`
+    MinReviewers int    `db:"min_reviewers"`
+    MaxReviewers int    `db:"max_reviewers"`
+
+    RequiredApprovals int `db:"required_approvals"`
+}
+
+// OpenReviewAssignment - назначение ревьювера на OPEN PR, которое нужно переназначить
+type OpenReviewAssignment struct {
+    PullRequestID string
+    ReviewerID    string
+    AuthorID      string
+    TeamID        int      // команда, из которой подбирается замена; 0 - команды нет
+    Reviewers     []string // все текущие ревьюверы PR
+}
+
+// ReassignmentSnapshot - всё, что нужно для подбора замен, прочитанное внутри одной транзакции
+type ReassignmentSnapshot struct {
+    Assignments []OpenReviewAssignment
+    // Pools - пулы для команды PR в порядке опроса, как у GetReviewerPools
+    Pools      map[int][]ReviewerPool
+    Candidates map[int][]ReviewerCandidate // активные участники каждой команды из Pools
+}
+
+// ReviewerReassignment - решение по одному назначению; пустой NewReviewerID - ревьювер снят без замены.
+// Source и SourceTeamID - откуда взят новый ревьювер, как у SelectedReviewer
+type ReviewerReassignment struct {
+    PullRequestID string
+    OldReviewerID string
+    NewReviewerID string
+    Source        string
+    SourceTeamID  int
+}
+
+// ReassignmentPlanner подбирает замены по снимку; хранилище вызывает его внутри транзакции,
+// поэтому он не должен обращаться к хранилищу
+type ReassignmentPlanner func(snapshot ReassignmentSnapshot) []ReviewerReassignment
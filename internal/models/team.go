@@ -0,0 +1,11 @@
+package models
+
+// TeamDeletion - что изменилось при удалении команды
+type TeamDeletion struct {
+    Members []string // участники, переведённые в другую команду или оставшиеся без команды
+
+    // DRAFT/OPEN PR команды: пул ревьюверов для них теперь берётся из move_to_team или основной команды автора
+    OpenPullRequests []string
+
+    Reassignments []ReviewerReassignment
+}
@@ -5,4 +5,15 @@ type UserDB struct {
     Name     string `db:"name"`
     IsActive bool   `db:"is_active"`
     TeamID   *int   `db:"team_id"`
-}
\ No newline at end of file
+}
+
+// UserFilter - фильтры и keyset-пагинация для списка пользователей (по user_id)
+type UserFilter struct {
+    TeamName   string
+    IsActive   *bool
+    NamePrefix string
+    Limit      int
+
+    // user_id последней записи предыдущей страницы
+    After string
+}
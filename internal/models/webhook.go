@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// WebhookTask - доставка, взятая диспетчером на отправку
+type WebhookTask struct {
+    DeliveryID  int64
+    URL         string
+    Secret      string
+    Event       string
+    Payload     []byte
+    // попыток до этой
+    Attempts    int
+    // до какого момента доставка закреплена за диспетчером, взявшим её
+    LeasedUntil time.Time
+}
+
+// WebhookAttempt - результат попытки доставки. NextAttemptAt == nil и !Delivered - попытки исчерпаны
+type WebhookAttempt struct {
+    At             time.Time
+    Delivered      bool
+    ResponseStatus *int
+    Error          string
+    NextAttemptAt  *time.Time
+}
+
+// WebhookDeliveryFilter - фильтры журнала доставок (0 / пусто - без ограничения)
+type WebhookDeliveryFilter struct {
+    SubscriptionID int64
+    Status         string
+    Limit          int
+}
@@ -0,0 +1,71 @@
+package service
+
+import "context"
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Principal - аутентифицированный пользователь, от имени которого выполняется запрос
+type Principal struct {
+	UserId string
+	Role   string
+}
+
+func (p Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+type principalKey struct{}
+
+// WithPrincipal запоминает в контексте запроса, кто выполняет действие
+// (для проверки прав и истории PR)
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает пользователя запроса. Его нет у внутренних вызовов
+// (интеграции, фоновые задачи) - они выполняются без ограничений по правам
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+type systemActorKey struct{}
+
+// WithSystemActor помечает внутренний вызов и указывает, кто выполняет действие (например, пользователь,
+// смерживший PR во внешней системе). Запросы с токеном его не получают: actor берётся из токена
+func WithSystemActor(ctx context.Context, actorId string) context.Context {
+	return context.WithValue(ctx, systemActorKey{}, actorId)
+}
+
+// SystemActorFromContext возвращает actor внутреннего вызова, заданный WithSystemActor
+func SystemActorFromContext(ctx context.Context) (string, bool) {
+	actorId, ok := ctx.Value(systemActorKey{}).(string)
+	return actorId, ok && actorId != ""
+}
+
+// ActorFromContext - пользователь запроса, а для внутренних вызовов - их системный actor
+func ActorFromContext(ctx context.Context) string {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return principal.UserId
+	}
+	actorId, _ := SystemActorFromContext(ctx)
+	return actorId
+}
+
+// canActAs сообщает, может ли пользователь запроса действовать за одного из userIds:
+// admin и внутренние вызовы могут всё, остальные - только за себя
+func canActAs(ctx context.Context, userIds ...string) bool {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.IsAdmin() {
+		return true
+	}
+	for _, userId := range userIds {
+		if userId != "" && userId == principal.UserId {
+			return true
+		}
+	}
+	return false
+}
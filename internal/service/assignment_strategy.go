@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+const (
+	StrategyRandom      = "random"
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastLoaded = "least_loaded"
+	StrategyWeighted    = "weighted"
+
+	DefaultAssignmentStrategy = StrategyLeastLoaded
+)
+
+var ErrUnknownStrategy = errors.New("unknown assignment strategy")
+
+// AssignmentStrategy выбирает до count ревьюверов из подходящих кандидатов.
+// Кандидаты уже отфильтрованы: активные, не автор, не назначенные ранее.
+type AssignmentStrategy interface {
+	Name() string
+	Select(candidates []models.ReviewerCandidate, count int) []string
+}
+
+var assignmentStrategies = map[string]AssignmentStrategy{
+	StrategyRandom:      randomStrategy{},
+	StrategyRoundRobin:  roundRobinStrategy{},
+	StrategyLeastLoaded: leastLoadedStrategy{},
+	StrategyWeighted:    weightedStrategy{},
+}
+
+func GetAssignmentStrategy(name string) (AssignmentStrategy, error) {
+	strategy, ok := assignmentStrategies[name]
+	if !ok {
+		return nil, ErrUnknownStrategy
+	}
+	return strategy, nil
+}
+
+// randomStrategy - равновероятный выбор
+type randomStrategy struct{}
+
+func (randomStrategy) Name() string { return StrategyRandom }
+
+func (randomStrategy) Select(candidates []models.ReviewerCandidate, count int) []string {
+	shuffled := shuffleCandidates(candidates)
+	return candidateIDs(shuffled, count)
+}
+
+// roundRobinStrategy - по очереди: первым идёт тот, кого дольше всех не назначали
+type roundRobinStrategy struct{}
+
+func (roundRobinStrategy) Name() string { return StrategyRoundRobin }
+
+func (roundRobinStrategy) Select(candidates []models.ReviewerCandidate, count int) []string {
+	ordered := append([]models.ReviewerCandidate(nil), candidates...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i].LastAssignedAt, ordered[j].LastAssignedAt
+		if a == nil || b == nil {
+			if a == nil && b == nil {
+				return ordered[i].UserID < ordered[j].UserID
+			}
+			return a == nil
+		}
+		if !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return ordered[i].UserID < ordered[j].UserID
+	})
+	return candidateIDs(ordered, count)
+}
+
+// leastLoadedStrategy - меньше всего открытых ревью, при равенстве - случайно
+type leastLoadedStrategy struct{}
+
+func (leastLoadedStrategy) Name() string { return StrategyLeastLoaded }
+
+func (leastLoadedStrategy) Select(candidates []models.ReviewerCandidate, count int) []string {
+	ordered := shuffleCandidates(candidates)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].OpenReviews < ordered[j].OpenReviews
+	})
+	return candidateIDs(ordered, count)
+}
+
+// weightedStrategy - случайный выбор без повторов с вероятностью, пропорциональной весу
+type weightedStrategy struct{}
+
+func (weightedStrategy) Name() string { return StrategyWeighted }
+
+func (weightedStrategy) Select(candidates []models.ReviewerCandidate, count int) []string {
+	pool := append([]models.ReviewerCandidate(nil), candidates...)
+	selected := make([]string, 0, min(count, len(pool)))
+
+	for len(selected) < count && len(pool) > 0 {
+		total := 0
+		for _, c := range pool {
+			total += max(c.Weight, 1)
+		}
+
+		pick := rand.IntN(total)
+		idx := 0
+		for i, c := range pool {
+			pick -= max(c.Weight, 1)
+			if pick < 0 {
+				idx = i
+				break
+			}
+		}
+
+		selected = append(selected, pool[idx].UserID)
+		pool = append(pool[:idx], pool[idx+1:]...)
+	}
+
+	return selected
+}
+
+func shuffleCandidates(candidates []models.ReviewerCandidate) []models.ReviewerCandidate {
+	shuffled := append([]models.ReviewerCandidate(nil), candidates...)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+func candidateIDs(candidates []models.ReviewerCandidate, count int) []string {
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+	ids := make([]string, 0, count)
+	for _, c := range candidates[:count] {
+		ids = append(ids, c.UserID)
+	}
+	return ids
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+)
+
+// заголовки вебхуков GitHub
+const (
+    GitHubEventHeader     = "X-GitHub-Event"
+    GitHubDeliveryHeader  = "X-GitHub-Delivery"
+    GitHubSignatureHeader = "X-Hub-Signature-256"
+)
+
+type gitHubUser struct {
+    Login string `json:"login"`
+}
+
+// gitHubPullRequestEvent - поля события pull_request, которые использует сервис
+type gitHubPullRequestEvent struct {
+    Action      string `json:"action"`
+    PullRequest struct {
+        Number   int         `json:"number"`
+        Title    string      `json:"title"`
+        Draft    bool        `json:"draft"`
+        Merged   bool        `json:"merged"`
+        User     gitHubUser  `json:"user"`
+        MergedBy *gitHubUser `json:"merged_by"`
+    } `json:"pull_request"`
+    Repository struct {
+        FullName string `json:"full_name"`
+    } `json:"repository"`
+    Sender gitHubUser `json:"sender"`
+}
+
+// HandleGitHub проверяет подпись доставки GitHub и применяет событие pull_request к PR сервиса
+func (s *IntegrationService) HandleGitHub(ctx context.Context, eventType, deliveryId, signature string, body []byte) (models.IntegrationWebhookResponse, models.ErrorResponse) {
+    return s.receive(ctx, deliveryId, GitHubDeliveryHeader, s.verifyGitHubSignature(body, signature), func() (VcsEvent, bool, string, error) {
+        return parseGitHubEvent(eventType, deliveryId, body)
+    })
+}
+
+// verifyGitHubSignature проверяет X-Hub-Signature-256 ("sha256=" + hex(HMAC-SHA256(secret, тело)))
+func (s *IntegrationService) verifyGitHubSignature(body []byte, signature string) error {
+    if s.githubSecret == "" {
+        return ErrIntegrationDisabled
+    }
+
+    sum, ok := strings.CutPrefix(signature, "sha256=")
+    if !ok {
+        return ErrInvalidSignature
+    }
+    expected, err := hex.DecodeString(sum)
+    if err != nil {
+        return ErrInvalidSignature
+    }
+
+    mac := hmac.New(sha256.New, []byte(s.githubSecret))
+    mac.Write(body)
+    if !hmac.Equal(expected, mac.Sum(nil)) {
+        return ErrInvalidSignature
+    }
+    return nil
+}
+
+// parseGitHubEvent приводит событие GitHub к VcsEvent. ok == false - событие сервису не интересно
+// (ping, другие типы событий и действия), reason объясняет почему
+func parseGitHubEvent(eventType, deliveryId string, body []byte) (event VcsEvent, ok bool, reason string, err error) {
+    if eventType != "pull_request" {
+        return VcsEvent{}, false, fmt.Sprintf("event %q is not handled", eventType), nil
+    }
+
+    var payload gitHubPullRequestEvent
+    if err := json.Unmarshal(body, &payload); err != nil {
+        return VcsEvent{}, false, "", err
+    }
+    if payload.Repository.FullName == "" || payload.PullRequest.Number == 0 {
+        return VcsEvent{}, false, "", errors.New("repository.full_name and pull_request.number are required")
+    }
+
+    event = VcsEvent{
+        Provider:      ProviderGitHub,
+        DeliveryId:    deliveryId,
+        PullRequestId: fmt.Sprintf("%s:%s#%d", ProviderGitHub, payload.Repository.FullName, payload.PullRequest.Number),
+        Title:         payload.PullRequest.Title,
+        Draft:         payload.PullRequest.Draft,
+        AuthorLogin:   payload.PullRequest.User.Login,
+        ActorLogin:    payload.Sender.Login,
+    }
+
+    switch payload.Action {
+    case "opened":
+        event.Action = VcsOpened
+    case "ready_for_review":
+        event.Action = VcsReadyForReview
+    case "reopened":
+        event.Action = VcsReopened
+    case "closed":
+        event.Action = VcsClosed
+        if payload.PullRequest.Merged {
+            event.Action = VcsMerged
+            if payload.PullRequest.MergedBy != nil {
+                event.ActorLogin = payload.PullRequest.MergedBy.Login
+            }
+        }
+    default:
+        return VcsEvent{}, false, fmt.Sprintf("action %q is not handled", payload.Action), nil
+    }
+
+    return event, true, "", nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+)
+
+const gitHubTestSecret = "github-secret"
+
+// readGitHubFixture читает из testdata/github полное тело события pull_request в том виде, в каком его присылает GitHub
+func readGitHubFixture(tb testing.TB, name string) []byte {
+    tb.Helper()
+    body, err := os.ReadFile(filepath.Join("testdata", "github", name))
+    if err != nil {
+        tb.Fatalf("read fixture: %v", err)
+    }
+    return body
+}
+
+// signGitHub считает X-Hub-Signature-256 так же, как GitHub
+func signGitHub(secret string, body []byte) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write(body)
+    return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseGitHubEvent(t *testing.T) {
+    tests := []struct {
+        name      string
+        eventType string
+        body      []byte
+        want      VcsEvent
+        wantOk    bool
+        reason    string
+    }{
+        {
+            name:      "opened draft",
+            eventType: "pull_request",
+            body:      readGitHubFixture(t, "opened_draft.json"),
+            want:      VcsEvent{Action: VcsOpened, Title: "Add search", Draft: true, AuthorLogin: "alice", ActorLogin: "alice"},
+            wantOk:    true,
+        },
+        {
+            name:      "ready for review",
+            eventType: "pull_request",
+            body:      readGitHubFixture(t, "ready_for_review.json"),
+            want:      VcsEvent{Action: VcsReadyForReview, Title: "Add search", AuthorLogin: "alice", ActorLogin: "alice"},
+            wantOk:    true,
+        },
+        {
+            name:      "closed",
+            eventType: "pull_request",
+            body:      readGitHubFixture(t, "closed.json"),
+            want:      VcsEvent{Action: VcsClosed, Title: "Add search", AuthorLogin: "alice", ActorLogin: "bob"},
+            wantOk:    true,
+        },
+        {
+            name:      "reopened",
+            eventType: "pull_request",
+            body:      readGitHubFixture(t, "reopened.json"),
+            want:      VcsEvent{Action: VcsReopened, Title: "Add search", AuthorLogin: "alice", ActorLogin: "bob"},
+            wantOk:    true,
+        },
+        {
+            // closed с merged: true; actor - merged_by, а не sender (бот автослияния)
+            name:      "merged",
+            eventType: "pull_request",
+            body:      readGitHubFixture(t, "merged.json"),
+            want:      VcsEvent{Action: VcsMerged, Title: "Add search", AuthorLogin: "alice", ActorLogin: "bob"},
+            wantOk:    true,
+        },
+        {
+            name:      "other action",
+            eventType: "pull_request",
+            body:      []byte(`{"action":"edited","number":7,"pull_request":{"number":7,"title":"Add PR search"},"repository":{"full_name":"acme/backend"}}`),
+            reason:    `action "edited" is not handled`,
+        },
+        {
+            name:      "ping",
+            eventType: "ping",
+            body:      []byte(`{"zen":"Keep it logically awesome.","hook_id":123}`),
+            reason:    `event "ping" is not handled`,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            event, ok, reason, err := parseGitHubEvent(tt.eventType, "delivery-1", tt.body)
+            if err != nil {
+                t.Fatalf("parseGitHubEvent: %v", err)
+            }
+            if ok != tt.wantOk || reason != tt.reason {
+                t.Fatalf("ok %v, reason %q; want %v, %q", ok, reason, tt.wantOk, tt.reason)
+            }
+            if !ok {
+                return
+            }
+
+            tt.want.Provider = ProviderGitHub
+            tt.want.DeliveryId = "delivery-1"
+            tt.want.PullRequestId = "github:acme/backend#7"
+            if event != tt.want {
+                t.Fatalf("event %+v, want %+v", event, tt.want)
+            }
+        })
+    }
+}
+
+func TestParseGitHubEventRequiresPullRequest(t *testing.T) {
+    bodies := []string{
+        `{"action":"opened","pull_request":{"title":"Add search"},"repository":{"full_name":"acme/backend"}}`,
+        `{"action":"opened","pull_request":{"number":7}}`,
+        `not json`,
+    }
+    for _, body := range bodies {
+        if _, _, _, err := parseGitHubEvent("pull_request", "delivery-1", []byte(body)); err == nil {
+            t.Fatalf("parseGitHubEvent(%s): no error", body)
+        }
+    }
+}
+
+func TestHandleGitHubRejectsRequest(t *testing.T) {
+    body := readGitHubFixture(t, "opened_draft.json")
+    tests := []struct {
+        name       string
+        configured string
+        signature  string
+        deliveryId string
+        code       string
+    }{
+        {"signed with another secret", gitHubTestSecret, signGitHub("other-secret", body), "delivery-1", "UNAUTHORIZED"},
+        {"signature of another body", gitHubTestSecret, signGitHub(gitHubTestSecret, []byte(`{}`)), "delivery-1", "UNAUTHORIZED"},
+        {"missing signature", gitHubTestSecret, "", "delivery-1", "UNAUTHORIZED"},
+        {"sha1 signature", gitHubTestSecret, "sha1=0123456789abcdef0123456789abcdef01234567", "delivery-1", "UNAUTHORIZED"},
+        {"signature not hex", gitHubTestSecret, "sha256=not-hex", "delivery-1", "UNAUTHORIZED"},
+        {"integration disabled", "", signGitHub(gitHubTestSecret, body), "delivery-1", "INTEGRATION_DISABLED"},
+        {"missing delivery id", gitHubTestSecret, signGitHub(gitHubTestSecret, body), "", "INVALID_REQUEST"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            // до репозиториев запрос не доходит
+            integrations := NewIntegrationService(nil, nil, tt.configured, "")
+            _, errResponse := integrations.HandleGitHub(context.Background(), "pull_request", tt.deliveryId, tt.signature, body)
+            if errResponse.Error.Code != tt.code {
+                t.Fatalf("code %q (%s), want %s", errResponse.Error.Code, errResponse.Error.Message, tt.code)
+            }
+        })
+    }
+}
+
+// TestHandleGitHubLifecycle проводит PR через все фикстуры и проверяет статус PR после каждой доставки
+// и то, что повтор доставки с тем же X-GitHub-Delivery не применяется второй раз
+func TestHandleGitHubLifecycle(t *testing.T) {
+    steps := []struct {
+        fixture string
+        status  string
+    }{
+        {"opened_draft.json", StatusDraft},
+        {"ready_for_review.json", StatusOpen},
+        {"closed.json", StatusClosed},
+        {"reopened.json", StatusOpen},
+        {"merged.json", StatusMerged},
+    }
+
+    for _, storage := range testStorages {
+        t.Run(storage.name, func(t *testing.T) {
+            ctx := context.Background()
+            st := storage.open(t)
+            pullRequests := seedReviewTeam(t, st, 4)
+            integrations := NewIntegrationService(st.integrations, pullRequests, gitHubTestSecret, "")
+
+            for login, userId := range map[string]string{"alice": "u0", "bob": "u1"} {
+                req := models.IntegrationsAccountsLinkPostRequest{Provider: ProviderGitHub, Login: login, UserId: userId}
+                if _, errResponse := integrations.LinkAccount(ctx, req); errResponse.Error.Code != "" {
+                    t.Fatalf("LinkAccount %s: %s", login, errResponse.Error.Message)
+                }
+            }
+
+            for _, step := range steps {
+                body := readGitHubFixture(t, step.fixture)
+                deliveryId := "delivery-" + step.fixture
+                response, errResponse := integrations.HandleGitHub(ctx, "pull_request", deliveryId, signGitHub(gitHubTestSecret, body), body)
+                if errResponse.Error.Code != "" {
+                    t.Fatalf("%s: %s: %s", step.fixture, errResponse.Error.Code, errResponse.Error.Message)
+                }
+                if response.Status != IntegrationProcessed || response.PullRequestId != "github:acme/backend#7" {
+                    t.Fatalf("%s: response %+v, want processed github:acme/backend#7", step.fixture, response)
+                }
+
+                pr, errResponse := pullRequests.Get(ctx, response.PullRequestId)
+                if errResponse.Error.Code != "" {
+                    t.Fatalf("%s: Get: %s", step.fixture, errResponse.Error.Message)
+                }
+                if pr.Status != step.status {
+                    t.Fatalf("%s: status %s, want %s", step.fixture, pr.Status, step.status)
+                }
+                if pr.AuthorId != "u0" {
+                    t.Fatalf("%s: author %s, want u0", step.fixture, pr.AuthorId)
+                }
+            }
+
+            // GitHub повторяет доставку с тем же X-GitHub-Delivery при redeliver или таймауте
+            body := readGitHubFixture(t, "closed.json")
+            response, errResponse := integrations.HandleGitHub(ctx, "pull_request", "delivery-closed.json", signGitHub(gitHubTestSecret, body), body)
+            if errResponse.Error.Code != "" || response.Status != IntegrationDuplicate {
+                t.Fatalf("redelivery: response %+v, error %+v; want duplicate", response, errResponse)
+            }
+            pr, _ := pullRequests.Get(ctx, "github:acme/backend#7")
+            if pr.Status != StatusMerged {
+                t.Fatalf("status after redelivery %s, want %s", pr.Status, StatusMerged)
+            }
+        })
+    }
+}
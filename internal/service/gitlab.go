@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+)
+
+// заголовки вебхуков GitLab
+const (
+    GitLabEventHeader    = "X-Gitlab-Event"
+    GitLabDeliveryHeader = "X-Gitlab-Event-UUID"
+    GitLabTokenHeader    = "X-Gitlab-Token"
+)
+
+const gitLabMergeRequestHook = "Merge Request Hook"
+
+// gitLabMergeRequestEvent - поля Merge Request Hook, которые использует сервис
+type gitLabMergeRequestEvent struct {
+    ObjectKind string `json:"object_kind"`
+    // кто выполнил действие
+    User struct {
+        Username string `json:"username"`
+    } `json:"user"`
+    Project struct {
+        PathWithNamespace string `json:"path_with_namespace"`
+    } `json:"project"`
+    ObjectAttributes struct {
+        Iid    int    `json:"iid"`
+        Title  string `json:"title"`
+        Action string `json:"action"`
+        Draft  bool   `json:"draft"`
+    } `json:"object_attributes"`
+    Changes struct {
+        Draft *struct {
+            Previous bool `json:"previous"`
+            Current  bool `json:"current"`
+        } `json:"draft"`
+    } `json:"changes"`
+}
+
+// HandleGitLab проверяет X-Gitlab-Token доставки GitLab и применяет Merge Request Hook к PR сервиса
+func (s *IntegrationService) HandleGitLab(ctx context.Context, eventType, deliveryId, token string, body []byte) (models.IntegrationWebhookResponse, models.ErrorResponse) {
+    return s.receive(ctx, deliveryId, GitLabDeliveryHeader, s.verifyGitLabToken(token), func() (VcsEvent, bool, string, error) {
+        return parseGitLabEvent(eventType, deliveryId, body)
+    })
+}
+
+// verifyGitLabToken сравнивает X-Gitlab-Token с секретом: GitLab не подписывает тело, а передаёт секрет как есть
+func (s *IntegrationService) verifyGitLabToken(token string) error {
+    if s.gitlabToken == "" {
+        return ErrIntegrationDisabled
+    }
+    if subtle.ConstantTimeCompare([]byte(token), []byte(s.gitlabToken)) != 1 {
+        return ErrInvalidSignature
+    }
+    return nil
+}
+
+// parseGitLabEvent приводит Merge Request Hook к VcsEvent. В хуке нет логина автора MR (только числовой
+// author_id), поэтому автором при открытии считается тот, кто открыл MR (user)
+func parseGitLabEvent(eventType, deliveryId string, body []byte) (event VcsEvent, ok bool, reason string, err error) {
+    if eventType != gitLabMergeRequestHook {
+        return VcsEvent{}, false, fmt.Sprintf("event %q is not handled", eventType), nil
+    }
+
+    var payload gitLabMergeRequestEvent
+    if err := json.Unmarshal(body, &payload); err != nil {
+        return VcsEvent{}, false, "", err
+    }
+    if payload.ObjectKind != "merge_request" || payload.Project.PathWithNamespace == "" || payload.ObjectAttributes.Iid == 0 {
+        return VcsEvent{}, false, "", errors.New("merge_request with project.path_with_namespace and object_attributes.iid is required")
+    }
+
+    event = VcsEvent{
+        Provider:      ProviderGitLab,
+        DeliveryId:    deliveryId,
+        PullRequestId: fmt.Sprintf("%s:%s!%d", ProviderGitLab, payload.Project.PathWithNamespace, payload.ObjectAttributes.Iid),
+        Title:         payload.ObjectAttributes.Title,
+        Draft:         payload.ObjectAttributes.Draft,
+        AuthorLogin:   payload.User.Username,
+        ActorLogin:    payload.User.Username,
+    }
+
+    switch payload.ObjectAttributes.Action {
+    case "open":
+        event.Action = VcsOpened
+    case "reopen":
+        event.Action = VcsReopened
+    case "close":
+        event.Action = VcsClosed
+    case "merge":
+        event.Action = VcsMerged
+    case "update":
+        // снятие отметки Draft приходит как update с changes.draft
+        if draft := payload.Changes.Draft; draft != nil && draft.Previous && !draft.Current {
+            event.Action = VcsReadyForReview
+            break
+        }
+        return VcsEvent{}, false, `action "update" is handled only when draft is removed`, nil
+    default:
+        return VcsEvent{}, false, fmt.Sprintf("action %q is not handled", payload.ObjectAttributes.Action), nil
+    }
+
+    return event, true, "", nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+)
+
+const gitLabTestToken = "gitlab-token"
+
+// readGitLabFixture читает из testdata/gitlab полное тело Merge Request Hook в том виде, в каком его присылает GitLab
+func readGitLabFixture(tb testing.TB, name string) []byte {
+    tb.Helper()
+    body, err := os.ReadFile(filepath.Join("testdata", "gitlab", name))
+    if err != nil {
+        tb.Fatalf("read fixture: %v", err)
+    }
+    return body
+}
+
+func TestParseGitLabEvent(t *testing.T) {
+    tests := []struct {
+        name      string
+        eventType string
+        body      []byte
+        want      VcsEvent
+        wantOk    bool
+        reason    string
+    }{
+        {
+            name:      "open draft",
+            eventType: gitLabMergeRequestHook,
+            body:      readGitLabFixture(t, "open.json"),
+            want:      VcsEvent{Action: VcsOpened, Title: "Draft: Add search", Draft: true, AuthorLogin: "alice", ActorLogin: "alice"},
+            wantOk:    true,
+        },
+        {
+            name:      "update with draft removed",
+            eventType: gitLabMergeRequestHook,
+            body:      readGitLabFixture(t, "update_draft_removed.json"),
+            want:      VcsEvent{Action: VcsReadyForReview, Title: "Add search", AuthorLogin: "alice", ActorLogin: "alice"},
+            wantOk:    true,
+        },
+        {
+            name:      "close",
+            eventType: gitLabMergeRequestHook,
+            body:      readGitLabFixture(t, "close.json"),
+            want:      VcsEvent{Action: VcsClosed, Title: "Add search", AuthorLogin: "bob", ActorLogin: "bob"},
+            wantOk:    true,
+        },
+        {
+            name:      "reopen",
+            eventType: gitLabMergeRequestHook,
+            body:      readGitLabFixture(t, "reopen.json"),
+            want:      VcsEvent{Action: VcsReopened, Title: "Add search", AuthorLogin: "bob", ActorLogin: "bob"},
+            wantOk:    true,
+        },
+        {
+            name:      "merge",
+            eventType: gitLabMergeRequestHook,
+            body:      readGitLabFixture(t, "merge.json"),
+            want:      VcsEvent{Action: VcsMerged, Title: "Add search", AuthorLogin: "bob", ActorLogin: "bob"},
+            wantOk:    true,
+        },
+        {
+            name:      "update without draft change",
+            eventType: gitLabMergeRequestHook,
+            body:      []byte(`{"object_kind":"merge_request","project":{"path_with_namespace":"acme/backend"},"object_attributes":{"iid":7,"action":"update"},"changes":{"title":{"previous":"Add search","current":"Add PR search"}}}`),
+            reason:    `action "update" is handled only when draft is removed`,
+        },
+        {
+            name:      "other hook",
+            eventType: "Push Hook",
+            body:      readGitLabFixture(t, "open.json"),
+            reason:    `event "Push Hook" is not handled`,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            event, ok, reason, err := parseGitLabEvent(tt.eventType, "delivery-1", tt.body)
+            if err != nil {
+                t.Fatalf("parseGitLabEvent: %v", err)
+            }
+            if ok != tt.wantOk || reason != tt.reason {
+                t.Fatalf("ok %v, reason %q; want %v, %q", ok, reason, tt.wantOk, tt.reason)
+            }
+            if !ok {
+                return
+            }
+
+            tt.want.Provider = ProviderGitLab
+            tt.want.DeliveryId = "delivery-1"
+            tt.want.PullRequestId = "gitlab:acme/backend!7"
+            if event != tt.want {
+                t.Fatalf("event %+v, want %+v", event, tt.want)
+            }
+        })
+    }
+}
+
+func TestParseGitLabEventRequiresMergeRequest(t *testing.T) {
+    bodies := []string{
+        `{"object_kind":"merge_request","project":{"path_with_namespace":"acme/backend"},"object_attributes":{"action":"open"}}`,
+        `{"object_kind":"merge_request","object_attributes":{"iid":7,"action":"open"}}`,
+        `{"object_kind":"note","project":{"path_with_namespace":"acme/backend"},"object_attributes":{"iid":7}}`,
+        `not json`,
+    }
+    for _, body := range bodies {
+        if _, _, _, err := parseGitLabEvent(gitLabMergeRequestHook, "delivery-1", []byte(body)); err == nil {
+            t.Fatalf("parseGitLabEvent(%s): no error", body)
+        }
+    }
+}
+
+func TestHandleGitLabRejectsRequest(t *testing.T) {
+    tests := []struct {
+        name       string
+        configured string
+        token      string
+        deliveryId string
+        code       string
+    }{
+        {"wrong token", gitLabTestToken, "other-token", "delivery-1", "UNAUTHORIZED"},
+        {"missing token", gitLabTestToken, "", "delivery-1", "UNAUTHORIZED"},
+        {"integration disabled", "", gitLabTestToken, "delivery-1", "INTEGRATION_DISABLED"},
+        {"missing delivery id", gitLabTestToken, gitLabTestToken, "", "INVALID_REQUEST"},
+    }
+
+    body := readGitLabFixture(t, "open.json")
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            // до репозиториев запрос не доходит
+            integrations := NewIntegrationService(nil, nil, "", tt.configured)
+            _, errResponse := integrations.HandleGitLab(context.Background(), gitLabMergeRequestHook, tt.deliveryId, tt.token, body)
+            if errResponse.Error.Code != tt.code {
+                t.Fatalf("code %q (%s), want %s", errResponse.Error.Code, errResponse.Error.Message, tt.code)
+            }
+        })
+    }
+}
+
+// TestHandleGitLabLifecycle проводит MR через все фикстуры и проверяет статус PR после каждой доставки
+// и то, что повтор доставки с тем же X-Gitlab-Event-UUID не применяется второй раз
+func TestHandleGitLabLifecycle(t *testing.T) {
+    steps := []struct {
+        fixture string
+        status  string
+    }{
+        {"open.json", StatusDraft},
+        {"update_draft_removed.json", StatusOpen},
+        {"close.json", StatusClosed},
+        {"reopen.json", StatusOpen},
+        {"merge.json", StatusMerged},
+    }
+
+    for _, storage := range testStorages {
+        t.Run(storage.name, func(t *testing.T) {
+            ctx := context.Background()
+            st := storage.open(t)
+            pullRequests := seedReviewTeam(t, st, 4)
+            integrations := NewIntegrationService(st.integrations, pullRequests, "", gitLabTestToken)
+
+            for login, userId := range map[string]string{"alice": "u0", "bob": "u1"} {
+                req := models.IntegrationsAccountsLinkPostRequest{Provider: ProviderGitLab, Login: login, UserId: userId}
+                if _, errResponse := integrations.LinkAccount(ctx, req); errResponse.Error.Code != "" {
+                    t.Fatalf("LinkAccount %s: %s", login, errResponse.Error.Message)
+                }
+            }
+
+            for _, step := range steps {
+                deliveryId := "delivery-" + step.fixture
+                response, errResponse := integrations.HandleGitLab(ctx, gitLabMergeRequestHook, deliveryId, gitLabTestToken, readGitLabFixture(t, step.fixture))
+                if errResponse.Error.Code != "" {
+                    t.Fatalf("%s: %s: %s", step.fixture, errResponse.Error.Code, errResponse.Error.Message)
+                }
+                if response.Status != IntegrationProcessed || response.PullRequestId != "gitlab:acme/backend!7" {
+                    t.Fatalf("%s: response %+v, want processed gitlab:acme/backend!7", step.fixture, response)
+                }
+
+                pr, errResponse := pullRequests.Get(ctx, response.PullRequestId)
+                if errResponse.Error.Code != "" {
+                    t.Fatalf("%s: Get: %s", step.fixture, errResponse.Error.Message)
+                }
+                if pr.Status != step.status {
+                    t.Fatalf("%s: status %s, want %s", step.fixture, pr.Status, step.status)
+                }
+                if pr.AuthorId != "u0" {
+                    t.Fatalf("%s: author %s, want u0", step.fixture, pr.AuthorId)
+                }
+            }
+
+            // GitLab повторяет доставку с тем же UUID, если не дождался ответа
+            response, errResponse := integrations.HandleGitLab(ctx, gitLabMergeRequestHook, "delivery-close.json", gitLabTestToken, readGitLabFixture(t, "close.json"))
+            if errResponse.Error.Code != "" || response.Status != IntegrationDuplicate {
+                t.Fatalf("redelivery: response %+v, error %+v; want duplicate", response, errResponse)
+            }
+            pr, _ := pullRequests.Get(ctx, "gitlab:acme/backend!7")
+            if pr.Status != StatusMerged {
+                t.Fatalf("status after redelivery %s, want %s", pr.Status, StatusMerged)
+            }
+        })
+    }
+}
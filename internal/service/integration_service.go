@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+// системы контроля версий, из которых принимаются вебхуки
+const (
+    ProviderGitHub = "github"
+    ProviderGitLab = "gitlab"
+)
+
+var vcsProviders = map[string]bool{
+    ProviderGitHub: true,
+    ProviderGitLab: true,
+}
+
+// действия с PR во внешней системе, на которые реагирует сервис
+const (
+    VcsOpened         = "opened"
+    VcsReadyForReview = "ready_for_review"
+    VcsClosed         = "closed"
+    VcsMerged         = "merged"
+    VcsReopened       = "reopened"
+)
+
+// итог обработки входящей доставки
+const (
+    IntegrationProcessed = "processed"
+    IntegrationIgnored   = "ignored"
+    IntegrationDuplicate = "duplicate"
+    IntegrationFailed    = "failed"
+)
+
+var ErrIntegrationDisabled = errors.New("integration is not configured")
+var ErrInvalidSignature = errors.New("invalid webhook signature or token")
+
+// доставка, которая обрабатывается дольше, считается зависшей (реплика упала) и принимается повторно
+const integrationStaleAfter = 5 * time.Minute
+
+// VcsEvent - событие PR внешней системы, приведённое к общему виду. PullRequestId уже включает
+// провайдера и репозиторий, чтобы PR разных систем и репозиториев не пересекались
+type VcsEvent struct {
+    Provider      string
+    DeliveryId    string
+    Action        string
+    PullRequestId string
+    Title         string
+    Draft         bool
+    AuthorLogin   string
+    // кто выполнил действие (для merge - кто смержил)
+    ActorLogin    string
+}
+
+// IntegrationService применяет события внешних систем контроля версий к PR сервиса.
+// Каждая доставка обрабатывается один раз: повтор с тем же id получает статус duplicate
+type IntegrationService struct {
+	integrationRepo    IntegrationRepository
+	pullRequestService *PullRequestService
+	githubSecret       string
+	gitlabToken        string
+}
+
+// пустой githubSecret или gitlabToken выключает приём вебхуков соответствующей системы
+func NewIntegrationService(integrationRepo IntegrationRepository, pullRequestService *PullRequestService, githubSecret, gitlabToken string) *IntegrationService {
+	return &IntegrationService{
+		integrationRepo:    integrationRepo,
+		pullRequestService: pullRequestService,
+		githubSecret:       githubSecret,
+		gitlabToken:        gitlabToken,
+	}
+}
+
+func (s *IntegrationService) LinkAccount(ctx context.Context, req models.IntegrationsAccountsLinkPostRequest) (models.VcsAccount, models.ErrorResponse) {
+    if !vcsProviders[req.Provider] || req.Login == "" || req.UserId == "" {
+        return models.VcsAccount{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: "provider (github or gitlab), login and user_id are required",
+			},
+		}
+    }
+
+    account := models.VcsAccount{Provider: req.Provider, Login: req.Login, UserId: req.UserId}
+    err := s.integrationRepo.LinkAccount(ctx, account)
+    if errors.Is(err, internal_models.ErrUserNotFound) {
+        return models.VcsAccount{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "USER_NOT_FOUND",
+				Message: err.Error(),
+			},
+		}
+    }
+    if err != nil {
+        return models.VcsAccount{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return account, models.ErrorResponse{}
+}
+
+func (s *IntegrationService) UnlinkAccount(ctx context.Context, req models.IntegrationsAccountsUnlinkPostRequest) models.ErrorResponse {
+    err := s.integrationRepo.UnlinkAccount(ctx, req.Provider, req.Login)
+    if errors.Is(err, internal_models.ErrAccountNotFound) {
+        return models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_FOUND",
+				Message: err.Error(),
+			},
+		}
+    }
+    if err != nil {
+        return models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return models.ErrorResponse{}
+}
+
+func (s *IntegrationService) ListAccounts(ctx context.Context, req models.IntegrationsAccountsListGetRequest) (models.IntegrationsAccountsListGet200Response, models.ErrorResponse) {
+    accounts, err := s.integrationRepo.ListAccounts(ctx, req.Provider, req.UserId)
+    if err != nil {
+        return models.IntegrationsAccountsListGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return models.IntegrationsAccountsListGet200Response{Accounts: accounts}, models.ErrorResponse{}
+}
+
+// receive - общая часть приёма вебхука: проверка подлинности (verifyErr), id доставки из заголовка
+// deliveryHeader, разбор тела провайдера (parse) и однократное применение события
+func (s *IntegrationService) receive(ctx context.Context, deliveryId, deliveryHeader string, verifyErr error, parse func() (VcsEvent, bool, string, error)) (models.IntegrationWebhookResponse, models.ErrorResponse) {
+    response := models.IntegrationWebhookResponse{DeliveryId: deliveryId}
+
+    if verifyErr != nil {
+        code := "UNAUTHORIZED"
+        if errors.Is(verifyErr, ErrIntegrationDisabled) {
+            code = "INTEGRATION_DISABLED"
+        }
+        return response, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: code,
+				Message: verifyErr.Error(),
+			},
+		}
+    }
+    if deliveryId == "" {
+        return response, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: deliveryHeader + " header is required",
+			},
+		}
+    }
+
+    event, ok, reason, err := parse()
+    if err != nil {
+        return response, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		}
+    }
+    if !ok {
+        response.Status = IntegrationIgnored
+        response.Reason = reason
+        return response, models.ErrorResponse{}
+    }
+
+    return s.Handle(ctx, event)
+}
+
+// Handle применяет событие один раз. Если применить не удалось из-за внутренней ошибки,
+// доставка помечается failed и её повтор будет обработан заново
+func (s *IntegrationService) Handle(ctx context.Context, event VcsEvent) (models.IntegrationWebhookResponse, models.ErrorResponse) {
+    response := models.IntegrationWebhookResponse{DeliveryId: event.DeliveryId, PullRequestId: event.PullRequestId}
+
+    claimed, err := s.integrationRepo.ClaimDelivery(ctx, event.Provider, event.DeliveryId, event.Action, time.Now().UTC().Add(-integrationStaleAfter))
+    if err != nil {
+        return response, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+    if !claimed {
+        response.Status = IntegrationDuplicate
+        return response, models.ErrorResponse{}
+    }
+
+    errResponse := s.apply(ctx, event)
+    switch errResponse.Error.Code {
+    case "":
+        response.Status = IntegrationProcessed
+    case "INTERNAL_ERROR":
+        if err := s.integrationRepo.FinishDelivery(ctx, event.Provider, event.DeliveryId, IntegrationFailed, errResponse.Error.Message); err != nil {
+            errResponse.Error.Message += "; " + err.Error()
+        }
+        return response, errResponse
+    case "NOT_FOUND":
+        response.Status = IntegrationIgnored
+        response.Reason = "pull request is not tracked"
+    default:
+        response.Status = IntegrationIgnored
+        response.Reason = errResponse.Error.Message
+    }
+
+    if err := s.integrationRepo.FinishDelivery(ctx, event.Provider, event.DeliveryId, response.Status, response.Reason); err != nil {
+        return response, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return response, models.ErrorResponse{}
+}
+
+// apply вызывает операцию PR, соответствующую событию. Вызов внутренний (без пользователя
+// в контексте), поэтому проверки прав к нему не применяются
+func (s *IntegrationService) apply(ctx context.Context, event VcsEvent) models.ErrorResponse {
+    var errResponse models.ErrorResponse
+
+    switch event.Action {
+    case VcsOpened:
+        authorId, err := s.integrationRepo.ResolveAccount(ctx, event.Provider, event.AuthorLogin)
+        if errors.Is(err, internal_models.ErrAccountNotFound) {
+            return models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "AUTHOR_NOT_FOUND",
+					Message: fmt.Sprintf("%s login %q is not linked to a user", event.Provider, event.AuthorLogin),
+				},
+			}
+        }
+        if err != nil {
+            return models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "INTERNAL_ERROR",
+					Message: err.Error(),
+				},
+			}
+        }
+
+        _, errResponse = s.pullRequestService.Create(ctx, models.PullRequestCreatePostRequest{
+            PullRequestId:   event.PullRequestId,
+            PullRequestName: event.Title,
+            AuthorId:        authorId,
+            Draft:           event.Draft,
+        })
+    case VcsReadyForReview:
+        _, errResponse = s.pullRequestService.ReadyForReview(ctx, models.PullRequestReadyForReviewPostRequest{
+            PullRequestId: event.PullRequestId,
+        })
+    case VcsMerged:
+        // PR уже смержен во внешней системе: если апрувов не хватает, merge записывается в аудит как принудительный
+        actorId, err := s.actorId(ctx, event)
+        if err != nil {
+            return models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "INTERNAL_ERROR",
+					Message: err.Error(),
+				},
+			}
+        }
+        _, errResponse = s.pullRequestService.Merge(WithSystemActor(ctx, actorId), models.PullRequestMergePostRequest{
+            PullRequestId: event.PullRequestId,
+            Force:         true,
+            Reason:        "merged in " + event.Provider,
+        })
+    case VcsClosed:
+        _, errResponse = s.pullRequestService.Close(ctx, models.PullRequestClosePostRequest{
+            PullRequestId: event.PullRequestId,
+        })
+    case VcsReopened:
+        _, errResponse = s.pullRequestService.Reopen(ctx, models.PullRequestReopenPostRequest{
+            PullRequestId: event.PullRequestId,
+        })
+    }
+
+    return errResponse
+}
+
+// actorId - пользователь, выполнивший действие, или provider:login, если логин не привязан
+func (s *IntegrationService) actorId(ctx context.Context, event VcsEvent) (string, error) {
+    if event.ActorLogin == "" {
+        return event.Provider, nil
+    }
+
+    userId, err := s.integrationRepo.ResolveAccount(ctx, event.Provider, event.ActorLogin)
+    if errors.Is(err, internal_models.ErrAccountNotFound) {
+        return event.Provider + ":" + event.ActorLogin, nil
+    }
+    return userId, err
+}
@@ -2,26 +2,146 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
-	"github.com/kgugunava/avito-tech-internship/internal/adapters/postgres"
 	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+const (
+    StatusDraft  = "DRAFT"
+    StatusOpen   = "OPEN"
+    StatusMerged = "MERGED"
+    StatusClosed = "CLOSED"
+)
+
+// допустимые переходы статусов PR; MERGED - конечный статус
+var prStatusTransitions = map[string][]string{
+    StatusDraft:  {StatusOpen, StatusClosed},
+    StatusOpen:   {StatusMerged, StatusClosed},
+    StatusClosed: {StatusOpen, StatusDraft}, // reopen возвращает статус до закрытия
+    StatusMerged: {},
+}
+
+func canTransition(from, to string) bool {
+    for _, allowed := range prStatusTransitions[from] {
+        if allowed == to {
+            return true
+        }
+    }
+    return false
+}
+
+// transitionError описывает, почему PR нельзя перевести из from в to
+func transitionError(from, to string) models.ErrorResponse {
+    code := "INVALID_TRANSITION"
+    switch from {
+    case StatusMerged:
+        code = "PR_MERGED"
+    case StatusClosed:
+        code = "PR_CLOSED"
+    case StatusDraft:
+        code = "PR_DRAFT"
+    }
+
+    return models.ErrorResponse{
+		Error: models.ErrorResponseError{
+			Code: code,
+			Message: fmt.Sprintf("cannot move PR from %s to %s", from, to),
+		},
+	}
+}
+
+// forbiddenError - у пользователя запроса нет прав на действие с PR
+func forbiddenError(message string) models.ErrorResponse {
+    return models.ErrorResponse{
+		Error: models.ErrorResponseError{
+			Code: "FORBIDDEN",
+			Message: message,
+		},
+	}
+}
+
+// типы событий в истории PR
+const (
+    EventCreated       = "created"
+    EventAssigned      = "assigned"
+    EventReassigned    = "reassigned"
+    EventUnassigned    = "unassigned"
+    EventStatusChanged = "status_changed"
+    EventMerged        = "merged"
+)
+
+const (
+    VerdictApproved         = "APPROVED"
+    VerdictChangesRequested = "CHANGES_REQUESTED"
+    VerdictCommented        = "COMMENTED"
 )
 
 type PullRequestService struct {
-	pullRequestRepo *postgres.PullRequestRepository
+	pullRequestRepo PullRequestRepository
+	uow             UnitOfWork
+	publisher       EventPublisher
+}
+
+func NewPullRequestService(pullRequestRepo PullRequestRepository, uow UnitOfWork, publisher EventPublisher) *PullRequestService {
+	return &PullRequestService{pullRequestRepo: pullRequestRepo, uow: uow, publisher: publisher}
 }
 
-func NewPullRequestService(pullRequestRepo *postgres.PullRequestRepository) *PullRequestService {
-	return &PullRequestService{pullRequestRepo: pullRequestRepo}
+// errRolledBack прерывает UnitOfWork, когда операция завершилась ErrorResponse
+var errRolledBack = errors.New("unit of work rolled back")
+
+// inTransaction выполняет fn в одной транзакции; если fn вернула ErrorResponse, всё откатывается
+func (s *PullRequestService) inTransaction(ctx context.Context, fn func(ctx context.Context) models.ErrorResponse) models.ErrorResponse {
+    var errResponse models.ErrorResponse
+    err := s.uow.Do(ctx, func(ctx context.Context) error {
+        errResponse = fn(ctx)
+        if errResponse.Error.Code != "" {
+            return errRolledBack
+        }
+        return nil
+    })
+    if errResponse.Error.Code != "" {
+        return errResponse
+    }
+    if err != nil {
+        return models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+    return models.ErrorResponse{}
 }
 
+// Create регистрирует PR и назначает ревьюверов одной транзакцией:
+// при любой ошибке не остаётся PR без ревьюверов или событий
 func (s *PullRequestService) Create(ctx context.Context, req models.PullRequestCreatePostRequest) (models.PullRequest, models.ErrorResponse) {
+    var pr models.PullRequest
+    errResponse := s.inTransaction(ctx, func(ctx context.Context) models.ErrorResponse {
+        var errResponse models.ErrorResponse
+        pr, errResponse = s.create(ctx, req)
+        return errResponse
+    })
+    return pr, errResponse
+}
+
+func (s *PullRequestService) create(ctx context.Context, req models.PullRequestCreatePostRequest) (models.PullRequest, models.ErrorResponse) {
+    if !canActAs(ctx, req.AuthorId) {
+        return models.PullRequest{}, forbiddenError("PR can be created only on behalf of yourself")
+    }
+
     exists, err := s.pullRequestRepo.PRExists(ctx, req.PullRequestId)
     if err != nil {
         return models.PullRequest{}, models.ErrorResponse{
 			Error: models.ErrorResponseError{
-				Code: "INERNAL_ERROR",
+				Code: "INTERNAL_ERROR",
 				Message: err.Error(),
 			},
 		}
@@ -44,6 +164,21 @@ func (s *PullRequestService) Create(ctx context.Context, req models.PullRequestC
 			},
 		}
     }
+
+    // ревьюверы берутся из явно указанной команды, иначе из основной команды автора
+    if req.TeamName != "" {
+        teamId, err = s.pullRequestRepo.GetTeamID(ctx, req.TeamName)
+        if errors.Is(err, internal_models.ErrTeamNotFound) {
+            teamId = 0
+        } else if err != nil {
+            return models.PullRequest{}, models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "INTERNAL_ERROR",
+					Message: err.Error(),
+				},
+			}
+        }
+    }
     if teamId == 0 {
         return models.PullRequest{}, models.ErrorResponse{
 			Error: models.ErrorResponseError{
@@ -53,25 +188,54 @@ func (s *PullRequestService) Create(ctx context.Context, req models.PullRequestC
 		}
     }
 
-    reviewers, err := s.pullRequestRepo.GetTeamReviewers(ctx, teamId, req.AuthorId)
-    if err != nil {
+    // назначать ревьюверов из чужой команды нельзя: автор должен в ней состоять
+    if req.TeamName != "" {
+        isMember, err := s.pullRequestRepo.IsTeamMember(ctx, teamId, req.AuthorId)
+        if err != nil {
+            return models.PullRequest{}, models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "INTERNAL_ERROR",
+					Message: err.Error(),
+				},
+			}
+        }
+        if !isMember {
+            return models.PullRequest{}, models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "NOT_TEAM_MEMBER",
+					Message: fmt.Sprintf("author is not a member of team %s", req.TeamName),
+				},
+			}
+        }
+    }
+
+    status := StatusOpen
+    reviewers := []internal_models.SelectedReviewer{}
+    if req.Draft {
+        // черновик регистрируется без ревьюверов, назначение произойдёт в ReadyForReview
+        status = StatusDraft
+    } else {
+        var errResponse models.ErrorResponse
+        reviewers, errResponse = s.pickReviewers(ctx, teamId, req.AuthorId, req.ReviewersCount)
+        if errResponse.Error.Code != "" {
+            return models.PullRequest{}, errResponse
+        }
+    }
+
+    // вставка блокирует id: параллельный Create с тем же id дождётся коммита и получит PR_EXISTS
+    err = s.pullRequestRepo.CreatePR(ctx, req, teamId, status)
+    if errors.Is(err, internal_models.ErrPullRequestExists) {
         return models.PullRequest{}, models.ErrorResponse{
 			Error: models.ErrorResponseError{
-				Code: "INERNAL_ERROR",
-				Message: err.Error(),
+				Code: "PR_EXISTS",
+				Message: "PR id already exists",
 			},
 		}
     }
-
-    if len(reviewers) > 2 {
-        reviewers = reviewers[:2]
-    }
-
-    err = s.pullRequestRepo.CreatePR(ctx, req)
     if err != nil {
         return models.PullRequest{}, models.ErrorResponse{
 			Error: models.ErrorResponseError{
-				Code: "INERNAL_ERROR",
+				Code: "INTERNAL_ERROR",
 				Message: err.Error(),
 			},
 		}
@@ -81,109 +245,1216 @@ func (s *PullRequestService) Create(ctx context.Context, req models.PullRequestC
     if err != nil {
         return models.PullRequest{}, models.ErrorResponse{
 			Error: models.ErrorResponseError{
-				Code: "INERNAL_ERROR",
+				Code: "INTERNAL_ERROR",
 				Message: err.Error(),
 			},
 		}
     }
 
+    events := []models.PullRequestEvent{{PullRequestId: req.PullRequestId, Type: EventCreated, ToStatus: status}}
+    events = append(events, assignedEvents(req.PullRequestId, reviewers)...)
+    if errResponse := s.recordEvents(ctx, events...); errResponse.Error.Code != "" {
+        return models.PullRequest{}, errResponse
+    }
+
     return models.PullRequest{
         PullRequestId:     req.PullRequestId,
         PullRequestName:   req.PullRequestName,
         AuthorId:          req.AuthorId,
-        Status:            "OPEN",
-        AssignedReviewers: reviewers,
+        Status:            status,
+        AssignedReviewers: reviewerIDs(reviewers),
+        ReviewerSources:   reviewerSources(reviewers),
     }, models.ErrorResponse{}
 }
 
-func (s *PullRequestService) Merge(ctx context.Context, req models.PullRequestMergePostRequest) (models.PullRequest, models.ErrorResponse) {
-	mergedAt := time.Now().UTC()
+// ReadyForReview переводит черновик в OPEN, назначает ревьюверов и записывает события
+// в одной транзакции под блокировкой PR
+func (s *PullRequestService) ReadyForReview(ctx context.Context, req models.PullRequestReadyForReviewPostRequest) (models.PullRequest, models.ErrorResponse) {
+    var pr models.PullRequest
+    errResponse := s.inTransaction(ctx, func(ctx context.Context) models.ErrorResponse {
+        var errResponse models.ErrorResponse
+        pr, errResponse = s.readyForReview(ctx, req)
+        return errResponse
+    })
+    return pr, errResponse
+}
+
+func (s *PullRequestService) readyForReview(ctx context.Context, req models.PullRequestReadyForReviewPostRequest) (models.PullRequest, models.ErrorResponse) {
+    err := s.pullRequestRepo.LockPullRequest(ctx, req.PullRequestId)
+    if err != nil && !errors.Is(err, internal_models.ErrPullRequestNotFound) {
+        return models.PullRequest{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    pr, err := s.pullRequestRepo.GetByID(ctx, req.PullRequestId)
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_FOUND",
+				Message: "pull request not found",
+			},
+		}
+    }
+
+    if !canActAs(ctx, pr.AuthorId) {
+        return pr, forbiddenError("only the PR author can mark it ready for review")
+    }
+
+    if pr.Status != StatusDraft {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "PR_NOT_DRAFT",
+				Message: fmt.Sprintf("PR is %s, only DRAFT can be marked ready for review", pr.Status),
+			},
+		}
+    }
+
+    teamId, err := s.pullRequestRepo.GetPullRequestTeam(ctx, pr.PullRequestId)
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+    if teamId == 0 {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "TEAM_NOT_FOUND",
+				Message: "team not found",
+			},
+		}
+    }
+
+    reviewers, errResponse := s.pickReviewers(ctx, teamId, pr.AuthorId, req.ReviewersCount)
+    if errResponse.Error.Code != "" {
+        return pr, errResponse
+    }
 
-    pr, err := s.pullRequestRepo.SetMerged(ctx, req.PullRequestId, mergedAt)
+    err = s.pullRequestRepo.MarkReadyForReview(ctx, req.PullRequestId, reviewers)
     if err != nil {
         return pr, models.ErrorResponse{
 			Error: models.ErrorResponseError{
-				Code: "NOT FOUND",
+				Code: "INTERNAL_ERROR",
 				Message: err.Error(),
 			},
 		}
     }
 
+    events := []models.PullRequestEvent{{PullRequestId: pr.PullRequestId, Type: EventStatusChanged, FromStatus: StatusDraft, ToStatus: StatusOpen}}
+    events = append(events, assignedEvents(pr.PullRequestId, reviewers)...)
+    if errResponse := s.recordEvents(ctx, events...); errResponse.Error.Code != "" {
+        return pr, errResponse
+    }
+
+    pr.Status = StatusOpen
+    pr.AssignedReviewers = reviewerIDs(reviewers)
+    pr.ReviewerSources = reviewerSources(reviewers)
+
     return pr, models.ErrorResponse{}
 }
 
-func (s *PullRequestService) Reassign(ctx context.Context, req models.PullRequestReassignPostRequest) (models.PullRequest, models.ErrorResponse, string) {
-	pr, err := s.pullRequestRepo.GetByID(ctx, req.PullRequestId)
-    if err != nil {
+func (s *PullRequestService) Get(ctx context.Context, prId string) (models.PullRequest, models.ErrorResponse) {
+    pr, err := s.pullRequestRepo.GetByID(ctx, prId)
+    if errors.Is(err, internal_models.ErrPullRequestNotFound) {
         return pr, models.ErrorResponse{
 			Error: models.ErrorResponseError{
 				Code: "NOT_FOUND",
 				Message: "pull request not found",
 			},
-		}, ""
+		}
     }
-
-    if pr.Status == "MERGED" {
+    if err != nil {
         return pr, models.ErrorResponse{
 			Error: models.ErrorResponseError{
-				Code: "PR_MERGED",
-				Message: "cannot reassign on merged PR",
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
 			},
-		}, ""
+		}
     }
 
-    isAssigned := false
-    for _, r := range pr.AssignedReviewers {
-        if r == req.OldUserId {
-            isAssigned = true
-            break
+    return pr, models.ErrorResponse{}
+}
+
+const (
+    defaultListLimit = 50
+    maxListLimit     = 200
+)
+
+func (s *PullRequestService) List(ctx context.Context, req models.PullRequestListGetRequest) (models.PullRequestListGet200Response, models.ErrorResponse) {
+    filter, err := buildPullRequestFilter(req)
+    if err != nil {
+        return models.PullRequestListGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    // запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+    pageSize := filter.Limit
+    filter.Limit++
+
+    prs, err := s.pullRequestRepo.ListPullRequests(ctx, filter)
+    if err != nil {
+        return models.PullRequestListGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    response := models.PullRequestListGet200Response{PullRequests: prs}
+    if len(prs) > pageSize {
+        response.PullRequests = prs[:pageSize]
+        response.NextCursor = encodeCursor(filter, prs[pageSize-1])
+    }
+
+    return response, models.ErrorResponse{}
+}
+
+func buildPullRequestFilter(req models.PullRequestListGetRequest) (internal_models.PullRequestFilter, error) {
+    filter := internal_models.PullRequestFilter{
+        AuthorID:   req.AuthorId,
+        ReviewerID: req.ReviewerId,
+        TeamName:   req.TeamName,
+        SortBy:     req.Sort,
+        Desc:       true,
+        Limit:      req.Limit,
+    }
+
+    for _, raw := range req.Status {
+        for _, status := range strings.Split(raw, ",") {
+            status = strings.ToUpper(strings.TrimSpace(status))
+            if status == "" {
+                continue
+            }
+            if _, ok := prStatusTransitions[status]; !ok {
+                return filter, fmt.Errorf("unknown status %q", status)
+            }
+            filter.Statuses = append(filter.Statuses, status)
         }
     }
-    if !isAssigned {
-        return pr, models.ErrorResponse{
+
+    var err error
+    for _, bound := range []struct {
+        name  string
+        value string
+        dest  **time.Time
+    }{
+        {"created_from", req.CreatedFrom, &filter.CreatedFrom},
+        {"created_to", req.CreatedTo, &filter.CreatedTo},
+        {"merged_from", req.MergedFrom, &filter.MergedFrom},
+        {"merged_to", req.MergedTo, &filter.MergedTo},
+    } {
+        if *bound.dest, err = parseTimeParam(bound.name, bound.value); err != nil {
+            return filter, err
+        }
+    }
+
+    switch filter.SortBy {
+    case "":
+        filter.SortBy = "created_at"
+    case "created_at", "merged_at", "pull_request_id":
+    default:
+        return filter, fmt.Errorf("sort must be one of created_at, merged_at, pull_request_id")
+    }
+
+    switch strings.ToLower(req.Order) {
+    case "", "desc":
+    case "asc":
+        filter.Desc = false
+    default:
+        return filter, fmt.Errorf("order must be asc or desc")
+    }
+
+    if filter.Limit == 0 {
+        filter.Limit = defaultListLimit
+    }
+    if filter.Limit < 0 || filter.Limit > maxListLimit {
+        return filter, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+    }
+
+    if req.Cursor != "" {
+        cursor, err := decodeCursor(req.Cursor)
+        if err != nil {
+            return filter, err
+        }
+        if cursor.SortBy != filter.SortBy || cursor.Desc != filter.Desc {
+            return filter, fmt.Errorf("cursor does not match sort and order")
+        }
+        filter.After = &cursor
+    }
+
+    return filter, nil
+}
+
+func parseTimeParam(name, value string) (*time.Time, error) {
+    if value == "" {
+        return nil, nil
+    }
+    t, err := time.Parse(time.RFC3339, value)
+    if err != nil {
+        return nil, fmt.Errorf("%s must be RFC 3339 date-time", name)
+    }
+    return &t, nil
+}
+
+func encodeCursor(filter internal_models.PullRequestFilter, last models.PullRequest) string {
+    cursor := internal_models.PullRequestCursor{
+        SortBy: filter.SortBy,
+        Desc:   filter.Desc,
+        ID:     last.PullRequestId,
+    }
+
+    var value *time.Time
+    switch filter.SortBy {
+    case "created_at":
+        value = last.CreatedAt
+    case "merged_at":
+        value = last.MergedAt
+    }
+    if filter.SortBy != "pull_request_id" {
+        // NULL сортируется как -infinity, см. ListPullRequests
+        cursor.Value = "-infinity"
+        if value != nil {
+            cursor.Value = value.UTC().Format(time.RFC3339Nano)
+        }
+    }
+
+    raw, _ := json.Marshal(cursor)
+    return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(encoded string) (internal_models.PullRequestCursor, error) {
+    var cursor internal_models.PullRequestCursor
+
+    raw, err := base64.RawURLEncoding.DecodeString(encoded)
+    if err != nil {
+        return cursor, fmt.Errorf("invalid cursor")
+    }
+    if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == "" {
+        return cursor, fmt.Errorf("invalid cursor")
+    }
+    if cursor.SortBy != "pull_request_id" && cursor.Value != "-infinity" {
+        if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+            return cursor, fmt.Errorf("invalid cursor")
+        }
+    }
+
+    return cursor, nil
+}
+
+// Merge проверяет апрувы и мержит PR в одной транзакции под блокировкой PR,
+// чтобы параллельный reassign не сбросил учтённый вердикт
+func (s *PullRequestService) Merge(ctx context.Context, req models.PullRequestMergePostRequest) (models.PullRequest, models.ErrorResponse) {
+    var pr models.PullRequest
+    errResponse := s.inTransaction(ctx, func(ctx context.Context) models.ErrorResponse {
+        var errResponse models.ErrorResponse
+        pr, errResponse = s.merge(ctx, req)
+        return errResponse
+    })
+    return pr, errResponse
+}
+
+func (s *PullRequestService) merge(ctx context.Context, req models.PullRequestMergePostRequest) (models.PullRequest, models.ErrorResponse) {
+    err := s.pullRequestRepo.LockPullRequest(ctx, req.PullRequestId)
+    if err != nil && !errors.Is(err, internal_models.ErrPullRequestNotFound) {
+        return models.PullRequest{}, models.ErrorResponse{
 			Error: models.ErrorResponseError{
-				Code: "NOT_ASSIGNED",
-				Message: "reviewer is not assigned to this PR",
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
 			},
-		}, ""
+		}
     }
 
-    teamName, err := s.pullRequestRepo.GetUserTeam(ctx, req.OldUserId)
+    pr, err := s.pullRequestRepo.GetByID(ctx, req.PullRequestId)
     if err != nil {
         return pr, models.ErrorResponse{
 			Error: models.ErrorResponseError{
 				Code: "NOT_FOUND",
-				Message: "team not found",
+				Message: err.Error(),
 			},
-		}, ""
+		}
+    }
+
+    if !canActAs(ctx, pr.AuthorId) {
+        return pr, forbiddenError("only the PR author can merge it")
+    }
+
+    if pr.Status == StatusMerged {
+        return pr, models.ErrorResponse{}
+    }
+
+    if !canTransition(pr.Status, StatusMerged) {
+        return pr, transitionError(pr.Status, StatusMerged)
     }
 
-    newReviewer, err := s.pullRequestRepo.FindReplacement(ctx, req.OldUserId, teamName)
+    teamId, err := s.pullRequestRepo.GetPullRequestTeam(ctx, pr.PullRequestId)
     if err != nil {
         return pr, models.ErrorResponse{
 			Error: models.ErrorResponseError{
-				Code: "NO_CANDIDATE",
-				Message: "no active replacement candidate in team",
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
 			},
-		}, ""
+		}
+    }
+
+    requiredApprovals := 0
+    if teamId != 0 {
+        settings, err := s.pullRequestRepo.GetTeamAssignmentSettings(ctx, teamId)
+        if err != nil {
+            return pr, models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "INTERNAL_ERROR",
+					Message: err.Error(),
+				},
+			}
+        }
+        requiredApprovals = settings.RequiredApprovals
+    }
+
+    // считаются апрувы только назначенных сейчас ревьюверов: вердикт удалённого (reviewer_id пуст)
+    // остаётся в истории PR, но в merge не учитывается
+    assigned := make(map[string]bool, len(pr.AssignedReviewers))
+    for _, reviewerId := range pr.AssignedReviewers {
+        assigned[reviewerId] = true
+    }
+    approvals := 0
+    for _, review := range pr.Reviews {
+        if review.Verdict == VerdictApproved && review.ReviewerId != "" && assigned[review.ReviewerId] {
+            approvals++
+        }
+    }
+
+    var override *models.PullRequestMergeOverride
+    if approvals < requiredApprovals {
+        if !req.Force {
+            return pr, models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "NOT_APPROVED",
+					Message: fmt.Sprintf("PR has %d of %d required approvals", approvals, requiredApprovals),
+				},
+			}
+        }
+        // принудительный merge записывается в аудит от имени admin из токена или системного actor
+        // внутреннего вызова; без них его не выполнить
+        var actorId string
+        if principal, ok := PrincipalFromContext(ctx); ok {
+            if !principal.IsAdmin() {
+                return pr, forbiddenError("forced merge requires admin role")
+            }
+            actorId = principal.UserId
+        } else if actorId, ok = SystemActorFromContext(ctx); !ok {
+            return pr, forbiddenError("forced merge requires admin role")
+        }
+        override = &models.PullRequestMergeOverride{
+            ForcedBy:          actorId,
+            Reason:            req.Reason,
+            Approvals:         approvals,
+            RequiredApprovals: requiredApprovals,
+        }
     }
 
-    err = s.pullRequestRepo.ReplaceReviewer(ctx, req.PullRequestId, req.OldUserId, newReviewer)
+	mergedAt := time.Now().UTC()
+
+    fromStatus := pr.Status
+
+    pr, err = s.pullRequestRepo.SetMerged(ctx, req.PullRequestId, mergedAt, override)
     if err != nil {
         return pr, models.ErrorResponse{
 			Error: models.ErrorResponseError{
-				Code: "INTERNAL_ERROR", 
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    event := models.PullRequestEvent{PullRequestId: pr.PullRequestId, Type: EventMerged, FromStatus: fromStatus, ToStatus: StatusMerged}
+    if override != nil {
+        event.ActorId = override.ForcedBy
+        event.Reason = "forced: " + override.Reason
+    }
+    if errResponse := s.recordEvents(ctx, event); errResponse.Error.Code != "" {
+        return pr, errResponse
+    }
+
+    return pr, models.ErrorResponse{}
+}
+
+// Reassign заменяет ревьювера в одной транзакции под блокировкой PR: параллельные замены
+// того же PR выполняются по очереди и не назначают одного кандидата дважды
+func (s *PullRequestService) Reassign(ctx context.Context, req models.PullRequestReassignPostRequest) (models.PullRequest, models.ErrorResponse, string) {
+    var pr models.PullRequest
+    var newReviewer string
+    errResponse := s.inTransaction(ctx, func(ctx context.Context) models.ErrorResponse {
+        var errResponse models.ErrorResponse
+        pr, errResponse, newReviewer = s.reassign(ctx, req)
+        return errResponse
+    })
+    return pr, errResponse, newReviewer
+}
+
+func (s *PullRequestService) reassign(ctx context.Context, req models.PullRequestReassignPostRequest) (models.PullRequest, models.ErrorResponse, string) {
+    err := s.pullRequestRepo.LockPullRequest(ctx, req.PullRequestId)
+    if err != nil && !errors.Is(err, internal_models.ErrPullRequestNotFound) {
+        return models.PullRequest{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
 				Message: err.Error(),
 			},
 		}, ""
     }
 
-    for i := range pr.AssignedReviewers {
-        if pr.AssignedReviewers[i] == req.OldUserId {
-            pr.AssignedReviewers[i] = newReviewer
-            break
-        }
+	pr, err := s.pullRequestRepo.GetByID(ctx, req.PullRequestId)
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_FOUND",
+				Message: "pull request not found",
+			},
+		}, ""
     }
 
-    return pr, models.ErrorResponse{}, newReviewer
-}
\ No newline at end of file
+    // заменить ревьювера может автор PR или сам заменяемый ревьювер
+    if !canActAs(ctx, pr.AuthorId, req.OldUserId) {
+        return pr, forbiddenError("only the PR author or the replaced reviewer can reassign"), ""
+    }
+
+    if pr.Status == StatusMerged {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "PR_MERGED",
+				Message: "cannot reassign on merged PR",
+			},
+		}, ""
+    }
+
+    if pr.Status == StatusClosed {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "PR_CLOSED",
+				Message: "cannot reassign on closed PR",
+			},
+		}, ""
+    }
+
+    isAssigned := false
+    for _, r := range pr.AssignedReviewers {
+        if r == req.OldUserId {
+            isAssigned = true
+            break
+        }
+    }
+    if !isAssigned {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_ASSIGNED",
+				Message: "reviewer is not assigned to this PR",
+			},
+		}, ""
+    }
+
+    teamId, err := s.pullRequestRepo.GetPullRequestTeam(ctx, pr.PullRequestId)
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, ""
+    }
+    if teamId == 0 {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_FOUND",
+				Message: "team not found",
+			},
+		}, ""
+    }
+
+    // автор и все текущие ревьюверы (включая заменяемого) не могут стать новым ревьювером
+    exclude := append([]string{pr.AuthorId}, pr.AssignedReviewers...)
+
+    replacement, err := s.selectReviewers(ctx, teamId, exclude, 1)
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, ""
+    }
+    if len(replacement) == 0 {
+        details, err := s.explainNoCandidate(ctx, teamId, pr, req.OldUserId)
+        if err != nil {
+            return pr, models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "INTERNAL_ERROR",
+					Message: err.Error(),
+				},
+			}, ""
+        }
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NO_CANDIDATE",
+				Message: "no active replacement candidate in team, its fallback or parent teams",
+				Details: details,
+			},
+		}, ""
+    }
+    newReviewer := replacement[0].UserID
+
+    err = s.pullRequestRepo.ReplaceReviewer(ctx, req.PullRequestId, req.OldUserId, replacement[0])
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR", 
+				Message: err.Error(),
+			},
+		}, ""
+    }
+
+    errResponse := s.recordEvents(ctx, models.PullRequestEvent{
+        PullRequestId: req.PullRequestId,
+        Type:          EventReassigned,
+        OldReviewerId: req.OldUserId,
+        NewReviewerId: newReviewer,
+        Reason:        req.Reason,
+    })
+    if errResponse.Error.Code != "" {
+        return pr, errResponse, ""
+    }
+
+    // вердикт и источник заменённого ревьювера изменились, перечитываем PR
+    pr, err = s.pullRequestRepo.GetByID(ctx, req.PullRequestId)
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, ""
+    }
+
+    return pr, models.ErrorResponse{}, newReviewer
+}
+
+// Close закрывает PR и записывает событие в одной транзакции под блокировкой PR
+func (s *PullRequestService) Close(ctx context.Context, req models.PullRequestClosePostRequest) (models.PullRequest, models.ErrorResponse) {
+    var pr models.PullRequest
+    errResponse := s.inTransaction(ctx, func(ctx context.Context) models.ErrorResponse {
+        var errResponse models.ErrorResponse
+        pr, errResponse = s.close(ctx, req)
+        return errResponse
+    })
+    return pr, errResponse
+}
+
+func (s *PullRequestService) close(ctx context.Context, req models.PullRequestClosePostRequest) (models.PullRequest, models.ErrorResponse) {
+    err := s.pullRequestRepo.LockPullRequest(ctx, req.PullRequestId)
+    if err != nil && !errors.Is(err, internal_models.ErrPullRequestNotFound) {
+        return models.PullRequest{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    pr, err := s.pullRequestRepo.GetByID(ctx, req.PullRequestId)
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_FOUND",
+				Message: "pull request not found",
+			},
+		}
+    }
+
+    if !canActAs(ctx, pr.AuthorId) {
+        return pr, forbiddenError("only the PR author can close it")
+    }
+
+    if pr.Status == StatusClosed {
+        return pr, models.ErrorResponse{}
+    }
+
+    if !canTransition(pr.Status, StatusClosed) {
+        return pr, transitionError(pr.Status, StatusClosed)
+    }
+
+    closedAt := time.Now().UTC()
+
+    err = s.pullRequestRepo.UpdateStatus(ctx, req.PullRequestId, pr.Status, StatusClosed, closedAt)
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    errResponse := s.recordEvents(ctx, models.PullRequestEvent{
+        PullRequestId: pr.PullRequestId,
+        Type:          EventStatusChanged,
+        FromStatus:    pr.Status,
+        ToStatus:      StatusClosed,
+    })
+    if errResponse.Error.Code != "" {
+        return pr, errResponse
+    }
+
+    pr.Status = StatusClosed
+    pr.ClosedAt = &closedAt
+
+    return pr, models.ErrorResponse{}
+}
+
+// Reopen возвращает PR в статус, из которого его закрыли: закрытый черновик снова становится DRAFT
+// (ревьюверы ему назначатся в ReadyForReview), остальные PR - OPEN. Смена статуса, событие
+// и замена неактивных ревьюверов выполняются в одной транзакции под блокировкой PR
+func (s *PullRequestService) Reopen(ctx context.Context, req models.PullRequestReopenPostRequest) (models.PullRequestReopenPost200Response, models.ErrorResponse) {
+    var response models.PullRequestReopenPost200Response
+    errResponse := s.inTransaction(ctx, func(ctx context.Context) models.ErrorResponse {
+        var errResponse models.ErrorResponse
+        response, errResponse = s.reopen(ctx, req)
+        return errResponse
+    })
+    return response, errResponse
+}
+
+func (s *PullRequestService) reopen(ctx context.Context, req models.PullRequestReopenPostRequest) (models.PullRequestReopenPost200Response, models.ErrorResponse) {
+    err := s.pullRequestRepo.LockPullRequest(ctx, req.PullRequestId)
+    if err != nil && !errors.Is(err, internal_models.ErrPullRequestNotFound) {
+        return models.PullRequestReopenPost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    pr, err := s.pullRequestRepo.GetByID(ctx, req.PullRequestId)
+    if err != nil {
+        return models.PullRequestReopenPost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_FOUND",
+				Message: "pull request not found",
+			},
+		}
+    }
+
+    if !canActAs(ctx, pr.AuthorId) {
+        return models.PullRequestReopenPost200Response{}, forbiddenError("only the PR author can reopen it")
+    }
+
+    if pr.Status != StatusClosed {
+        return models.PullRequestReopenPost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "PR_NOT_CLOSED",
+				Message: fmt.Sprintf("PR is %s, only CLOSED can be reopened", pr.Status),
+			},
+		}
+    }
+
+    // PR удалённого пользователя остаётся в истории, но вернуть его в работу некому
+    if pr.AuthorId == "" {
+        return models.PullRequestReopenPost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "AUTHOR_NOT_FOUND",
+				Message: "PR author was deleted",
+			},
+		}
+    }
+
+    events, err := s.pullRequestRepo.GetEvents(ctx, req.PullRequestId)
+    if err != nil {
+        return models.PullRequestReopenPost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+    toStatus := statusBeforeClose(events)
+
+    err = s.pullRequestRepo.UpdateStatus(ctx, req.PullRequestId, StatusClosed, toStatus, time.Now().UTC())
+    if err != nil {
+        return models.PullRequestReopenPost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    errResponse := s.recordEvents(ctx, models.PullRequestEvent{
+        PullRequestId: pr.PullRequestId,
+        Type:          EventStatusChanged,
+        FromStatus:    StatusClosed,
+        ToStatus:      toStatus,
+    })
+    if errResponse.Error.Code != "" {
+        return models.PullRequestReopenPost200Response{}, errResponse
+    }
+
+    pr.Status = toStatus
+    pr.ClosedAt = nil
+
+    response := models.PullRequestReopenPost200Response{
+        Replacements: []models.ReviewerReplacement{},
+        Unreplaced:   []string{},
+    }
+
+    if req.ReassignInactive && toStatus == StatusOpen {
+        response.Replacements, response.Unreplaced, errResponse = s.replaceInactiveReviewers(ctx, &pr)
+        if errResponse.Error.Code != "" {
+            return models.PullRequestReopenPost200Response{}, errResponse
+        }
+    }
+
+    response.Pr = pr
+
+    return response, models.ErrorResponse{}
+}
+
+// statusBeforeClose - статус, из которого PR закрыли в последний раз. PR, закрытые до появления
+// истории событий, считаются закрытыми из OPEN
+func statusBeforeClose(events []models.PullRequestEvent) string {
+    for i := len(events) - 1; i >= 0; i-- {
+        if events[i].Type == EventStatusChanged && events[i].ToStatus == StatusClosed {
+            if events[i].FromStatus == StatusDraft {
+                return StatusDraft
+            }
+            return StatusOpen
+        }
+    }
+    return StatusOpen
+}
+
+// replaceInactiveReviewers заменяет ставших неактивными ревьюверов PR на кандидатов из команды PR
+func (s *PullRequestService) replaceInactiveReviewers(ctx context.Context, pr *models.PullRequest) ([]models.ReviewerReplacement, []string, models.ErrorResponse) {
+    replacements := []models.ReviewerReplacement{}
+    unreplaced := []string{}
+
+    activity, err := s.pullRequestRepo.GetUsersActivity(ctx, pr.AssignedReviewers)
+    if err != nil {
+        return nil, nil, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    teamId, err := s.pullRequestRepo.GetPullRequestTeam(ctx, pr.PullRequestId)
+    if err != nil {
+        return nil, nil, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    for i, reviewer := range pr.AssignedReviewers {
+        if activity[reviewer] {
+            continue
+        }
+
+        var replacement []internal_models.SelectedReviewer
+        if teamId != 0 {
+            exclude := append([]string{pr.AuthorId}, pr.AssignedReviewers...)
+            replacement, err = s.selectReviewers(ctx, teamId, exclude, 1)
+            if err != nil {
+                return nil, nil, models.ErrorResponse{
+					Error: models.ErrorResponseError{
+						Code: "INTERNAL_ERROR",
+						Message: err.Error(),
+					},
+				}
+            }
+        }
+        if len(replacement) == 0 {
+            unreplaced = append(unreplaced, reviewer)
+            continue
+        }
+
+        err = s.pullRequestRepo.ReplaceReviewer(ctx, pr.PullRequestId, reviewer, replacement[0])
+        if err != nil {
+            return nil, nil, models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "INTERNAL_ERROR",
+					Message: err.Error(),
+				},
+			}
+        }
+
+        errResponse := s.recordEvents(ctx, models.PullRequestEvent{
+            PullRequestId: pr.PullRequestId,
+            Type:          EventReassigned,
+            OldReviewerId: reviewer,
+            NewReviewerId: replacement[0].UserID,
+            Reason:        "reviewer inactive",
+        })
+        if errResponse.Error.Code != "" {
+            return nil, nil, errResponse
+        }
+
+        pr.AssignedReviewers[i] = replacement[0].UserID
+        replacements = append(replacements, models.ReviewerReplacement{
+            OldUserId: reviewer,
+            NewUserId: replacement[0].UserID,
+        })
+    }
+
+    // вердикты заменённых ревьюверов сброшены, перечитываем PR
+    if len(replacements) > 0 {
+        updated, err := s.pullRequestRepo.GetByID(ctx, pr.PullRequestId)
+        if err != nil {
+            return nil, nil, models.ErrorResponse{
+				Error: models.ErrorResponseError{
+					Code: "INTERNAL_ERROR",
+					Message: err.Error(),
+				},
+			}
+        }
+        *pr = updated
+    }
+
+    return replacements, unreplaced, models.ErrorResponse{}
+}
+
+func (s *PullRequestService) SubmitReview(ctx context.Context, req models.PullRequestReviewPostRequest) (models.PullRequest, models.ErrorResponse) {
+    if req.Verdict != VerdictApproved && req.Verdict != VerdictChangesRequested && req.Verdict != VerdictCommented {
+        return models.PullRequest{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_VERDICT",
+				Message: "verdict must be one of APPROVED, CHANGES_REQUESTED, COMMENTED",
+			},
+		}
+    }
+
+    if !canActAs(ctx, req.ReviewerId) {
+        return models.PullRequest{}, forbiddenError("review can be submitted only on behalf of yourself")
+    }
+
+    var pr models.PullRequest
+    errResponse := s.inTransaction(ctx, func(ctx context.Context) models.ErrorResponse {
+        var errResponse models.ErrorResponse
+        pr, errResponse = s.submitReview(ctx, req)
+        return errResponse
+    })
+    return pr, errResponse
+}
+
+// submitReview сохраняет вердикт под блокировкой PR, чтобы он не разошёлся с параллельными
+// merge, close и переназначением ревьюверов
+func (s *PullRequestService) submitReview(ctx context.Context, req models.PullRequestReviewPostRequest) (models.PullRequest, models.ErrorResponse) {
+    err := s.pullRequestRepo.LockPullRequest(ctx, req.PullRequestId)
+    if err != nil && !errors.Is(err, internal_models.ErrPullRequestNotFound) {
+        return models.PullRequest{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    pr, err := s.pullRequestRepo.GetByID(ctx, req.PullRequestId)
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_FOUND",
+				Message: "pull request not found",
+			},
+		}
+    }
+
+    if pr.Status == StatusMerged || pr.Status == StatusClosed {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "PR_" + pr.Status,
+				Message: fmt.Sprintf("cannot review %s PR", strings.ToLower(pr.Status)),
+			},
+		}
+    }
+
+    isAssigned := false
+    for _, r := range pr.AssignedReviewers {
+        if r == req.ReviewerId {
+            isAssigned = true
+            break
+        }
+    }
+    if !isAssigned {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_ASSIGNED",
+				Message: "reviewer is not assigned to this PR",
+			},
+		}
+    }
+
+    err = s.pullRequestRepo.SubmitReview(ctx, req.PullRequestId, req.ReviewerId, req.Verdict, req.Comment, time.Now().UTC())
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    pr, err = s.pullRequestRepo.GetByID(ctx, req.PullRequestId)
+    if err != nil {
+        return pr, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return pr, models.ErrorResponse{}
+}
+
+func (s *PullRequestService) History(ctx context.Context, prId string) (models.PullRequestHistoryGet200Response, models.ErrorResponse) {
+    exists, err := s.pullRequestRepo.PRExists(ctx, prId)
+    if err != nil {
+        return models.PullRequestHistoryGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+    if !exists {
+        return models.PullRequestHistoryGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_FOUND",
+				Message: "pull request not found",
+			},
+		}
+    }
+
+    events, err := s.pullRequestRepo.GetEvents(ctx, prId)
+    if err != nil {
+        return models.PullRequestHistoryGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return models.PullRequestHistoryGet200Response{
+        PullRequestId: prId,
+        Events:        events,
+    }, models.ErrorResponse{}
+}
+
+// recordEvents дописывает события в историю PR и ставит в очередь вебхуки по ним;
+// если у события не указан автор действия, берётся actor из контекста запроса
+func (s *PullRequestService) recordEvents(ctx context.Context, events ...models.PullRequestEvent) models.ErrorResponse {
+    actorId := ActorFromContext(ctx)
+    for i := range events {
+        if events[i].ActorId == "" {
+            events[i].ActorId = actorId
+        }
+    }
+
+    if err := s.pullRequestRepo.AddEvents(ctx, events); err != nil {
+        return models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+    if err := s.publisher.Publish(ctx, events...); err != nil {
+        return models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+    return models.ErrorResponse{}
+}
+
+func assignedEvents(prId string, reviewers []internal_models.SelectedReviewer) []models.PullRequestEvent {
+    events := make([]models.PullRequestEvent, 0, len(reviewers))
+    for _, reviewer := range reviewers {
+        event := models.PullRequestEvent{PullRequestId: prId, Type: EventAssigned, ReviewerId: reviewer.UserID}
+        if reviewer.Source != internal_models.ReviewerSourceTeam {
+            event.Reason = fmt.Sprintf("%s team %s", reviewer.Source, reviewer.TeamName)
+        }
+        events = append(events, event)
+    }
+    return events
+}
+
+func reviewerIDs(reviewers []internal_models.SelectedReviewer) []string {
+    ids := make([]string, 0, len(reviewers))
+    for _, reviewer := range reviewers {
+        ids = append(ids, reviewer.UserID)
+    }
+    return ids
+}
+
+func reviewerSources(reviewers []internal_models.SelectedReviewer) []models.ReviewerSource {
+    sources := make([]models.ReviewerSource, 0, len(reviewers))
+    for _, reviewer := range reviewers {
+        sources = append(sources, models.ReviewerSource{
+            ReviewerId: reviewer.UserID,
+            TeamName:   reviewer.TeamName,
+            Source:     reviewer.Source,
+        })
+    }
+    return sources
+}
+
+// pickReviewers выбирает ревьюверов для нового PR с учётом настроек команды PR;
+// если её участников не хватает, добирает из запасных и вышестоящих команд
+func (s *PullRequestService) pickReviewers(ctx context.Context, teamId int, authorId string, requestedCount *int) ([]internal_models.SelectedReviewer, models.ErrorResponse) {
+    settings, err := s.pullRequestRepo.GetTeamAssignmentSettings(ctx, teamId)
+    if err != nil {
+        return nil, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    reviewersCount := settings.MaxReviewers
+    if requestedCount != nil {
+        reviewersCount = *requestedCount
+    }
+    if reviewersCount < settings.MinReviewers || reviewersCount > settings.MaxReviewers {
+        return nil, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REVIEWERS_COUNT",
+				Message: fmt.Sprintf("reviewers_count must be between %d and %d for this team",
+                    settings.MinReviewers, settings.MaxReviewers),
+			},
+		}
+    }
+
+    reviewers, err := s.selectReviewers(ctx, teamId, []string{authorId}, reviewersCount)
+    if err != nil {
+        return nil, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+    if len(reviewers) < settings.MinReviewers {
+        return nil, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_ENOUGH_REVIEWERS",
+				Message: fmt.Sprintf("team requires at least %d reviewers, only %d active candidates available",
+                    settings.MinReviewers, len(reviewers)),
+			},
+		}
+    }
+
+    return reviewers, models.ErrorResponse{}
+}
+
+// explainNoCandidate объясняет, почему не подошёл каждый участник каждой команды, из которой
+// подбирается замена: своей, запасных и вышестоящих. Участник нескольких команд объясняется один раз
+func (s *PullRequestService) explainNoCandidate(ctx context.Context, teamId int, pr models.PullRequest, oldUserId string) (map[string]string, error) {
+    pools, err := s.pullRequestRepo.GetReviewerPools(ctx, teamId)
+    if err != nil {
+        return nil, err
+    }
+
+    assigned := make(map[string]bool, len(pr.AssignedReviewers))
+    for _, r := range pr.AssignedReviewers {
+        assigned[r] = true
+    }
+
+    details := map[string]string{}
+    for _, pool := range pools {
+        members, err := s.pullRequestRepo.GetTeamMembers(ctx, pool.TeamID)
+        if err != nil {
+            return nil, err
+        }
+
+        for _, m := range members {
+            if _, ok := details[m.UserId]; ok {
+                continue
+            }
+            switch {
+            case m.UserId == oldUserId:
+                details[m.UserId] = "reviewer being replaced"
+            case m.UserId == pr.AuthorId:
+                details[m.UserId] = "author of the pull request"
+            case assigned[m.UserId]:
+                details[m.UserId] = "already assigned to this pull request"
+            case !m.IsActive:
+                details[m.UserId] = "inactive"
+            default:
+                // активный и не исключённый участник мог появиться уже после подбора
+                details[m.UserId] = fmt.Sprintf("not selected from %s team %s", pool.Source, pool.TeamName)
+            }
+        }
+    }
+
+    return details, nil
+}
+
+// selectReviewers выбирает до count ревьюверов, опрашивая по порядку команду teamId,
+// её запасные и вышестоящие команды; внутри каждой действует её стратегия назначения
+func (s *PullRequestService) selectReviewers(ctx context.Context, teamId int, exclude []string, count int) ([]internal_models.SelectedReviewer, error) {
+    selected := []internal_models.SelectedReviewer{}
+    if count <= 0 {
+        return selected, nil
+    }
+
+    pools, err := s.pullRequestRepo.GetReviewerPools(ctx, teamId)
+    if err != nil {
+        return nil, err
+    }
+
+    exclude = append([]string{}, exclude...)
+    for _, pool := range pools {
+        if len(selected) >= count {
+            break
+        }
+
+        strategy, err := GetAssignmentStrategy(pool.Strategy)
+        if err != nil {
+            return nil, err
+        }
+
+        candidates, err := s.pullRequestRepo.GetTeamCandidates(ctx, pool.TeamID, exclude)
+        if err != nil {
+            return nil, err
+        }
+
+        for _, userId := range strategy.Select(candidates, count-len(selected)) {
+            selected = append(selected, internal_models.SelectedReviewer{
+                UserID:   userId,
+                TeamID:   pool.TeamID,
+                TeamName: pool.TeamName,
+                Source:   pool.Source,
+            })
+            exclude = append(exclude, userId)
+        }
+    }
+
+    return selected, nil
+}
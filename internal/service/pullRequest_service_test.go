@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+// concurrentCalls - сколько горутин одновременно бьют в один PR
+const concurrentCalls = 16
+
+// seedReviewTeam создаёт команду из size активных участников u0..u<size-1> с двумя ревьюверами на PR
+func seedReviewTeam(tb testing.TB, st testStorage, size int) *PullRequestService {
+    tb.Helper()
+
+    members := make([]models.TeamMember, 0, size)
+    for i := 0; i < size; i++ {
+        members = append(members, models.TeamMember{UserId: fmt.Sprintf("u%d", i), Username: fmt.Sprintf("user %d", i), IsActive: true})
+    }
+    maxReviewers := 2
+    teams := NewTeamService(st.teams, nopPublisher{})
+    if _, err := teams.CreateNewTeam(context.Background(), models.Team{TeamName: "backend", Members: members, MaxReviewers: &maxReviewers}); err != nil {
+        tb.Fatalf("CreateNewTeam: %v", err)
+    }
+
+    return NewPullRequestService(st.pullRequests, st.uow, nopPublisher{})
+}
+
+// runConcurrently запускает calls одновременно и ждёт их завершения
+func runConcurrently(calls ...func()) {
+    var start, done sync.WaitGroup
+    start.Add(1)
+    for _, call := range calls {
+        done.Add(1)
+        go func() {
+            defer done.Done()
+            start.Wait()
+            call()
+        }()
+    }
+    start.Done()
+    done.Wait()
+}
+
+func TestCreateConcurrentSameID(t *testing.T) {
+    for _, storage := range testStorages {
+        t.Run(storage.name, func(t *testing.T) {
+            st := storage.open(t)
+            pullRequests := seedReviewTeam(t, st, 4)
+
+            created := make([]models.PullRequest, concurrentCalls)
+            errResponses := make([]models.ErrorResponse, concurrentCalls)
+            calls := make([]func(), concurrentCalls)
+            for i := range calls {
+                calls[i] = func() {
+                    created[i], errResponses[i] = pullRequests.Create(context.Background(), models.PullRequestCreatePostRequest{
+                        PullRequestId:   "pr-1",
+                        PullRequestName: fmt.Sprintf("attempt %d", i),
+                        AuthorId:        fmt.Sprintf("u%d", i%4),
+                    })
+                }
+            }
+            runConcurrently(calls...)
+
+            winner := -1
+            for i, errResponse := range errResponses {
+                switch errResponse.Error.Code {
+                case "":
+                    if winner != -1 {
+                        t.Fatalf("calls %d and %d both created pr-1", winner, i)
+                    }
+                    winner = i
+                case "PR_EXISTS":
+                default:
+                    t.Fatalf("call %d: %s: %s, want PR_EXISTS", i, errResponse.Error.Code, errResponse.Error.Message)
+                }
+            }
+            if winner == -1 {
+                t.Fatal("no call created pr-1")
+            }
+
+            pr, errResponse := pullRequests.Get(context.Background(), "pr-1")
+            if errResponse.Error.Code != "" {
+                t.Fatalf("Get: %s", errResponse.Error.Message)
+            }
+            if pr.PullRequestName != created[winner].PullRequestName || pr.AuthorId != created[winner].AuthorId {
+                t.Fatalf("stored %q by %s, winner created %q by %s", pr.PullRequestName, pr.AuthorId, created[winner].PullRequestName, created[winner].AuthorId)
+            }
+
+            // проигравшие откатились целиком: остались только ревьюверы и события победителя
+            reviewers, events := st.countRows(t, "pr-1")
+            if want := len(created[winner].AssignedReviewers); reviewers != want {
+                t.Fatalf("%d reviewer rows, want %d", reviewers, want)
+            }
+            if want := 1 + len(created[winner].AssignedReviewers); events != want {
+                t.Fatalf("%d event rows, want %d (created + assigned)", events, want)
+            }
+        })
+    }
+}
+
+func TestReassignConcurrentWithMerge(t *testing.T) {
+    for _, storage := range testStorages {
+        t.Run(storage.name, func(t *testing.T) {
+            st := storage.open(t)
+            pullRequests := seedReviewTeam(t, st, 6)
+            ctx := context.Background()
+
+            for i := 0; i < concurrentCalls; i++ {
+                prId := fmt.Sprintf("pr-%d", i)
+                created, errResponse := pullRequests.Create(ctx, models.PullRequestCreatePostRequest{
+                    PullRequestId: prId, PullRequestName: "race", AuthorId: "u0",
+                })
+                if errResponse.Error.Code != "" {
+                    t.Fatalf("Create: %s", errResponse.Error.Message)
+                }
+                oldReviewer := created.AssignedReviewers[0]
+
+                var reassignError, mergeError models.ErrorResponse
+                var newReviewer string
+                runConcurrently(
+                    func() {
+                        _, reassignError, newReviewer = pullRequests.Reassign(ctx, models.PullRequestReassignPostRequest{
+                            PullRequestId: prId, OldUserId: oldReviewer,
+                        })
+                    },
+                    func() {
+                        _, mergeError = pullRequests.Merge(ctx, models.PullRequestMergePostRequest{PullRequestId: prId})
+                    },
+                )
+
+                if mergeError.Error.Code != "" {
+                    t.Fatalf("%s: Merge: %s: %s", prId, mergeError.Error.Code, mergeError.Error.Message)
+                }
+                reassigned := reassignError.Error.Code == ""
+                if !reassigned && reassignError.Error.Code != "PR_MERGED" {
+                    t.Fatalf("%s: Reassign: %s: %s, want success or PR_MERGED", prId, reassignError.Error.Code, reassignError.Error.Message)
+                }
+
+                pr, errResponse := pullRequests.Get(ctx, prId)
+                if errResponse.Error.Code != "" {
+                    t.Fatalf("Get: %s", errResponse.Error.Message)
+                }
+                if pr.Status != StatusMerged {
+                    t.Fatalf("%s: status %s, want MERGED", prId, pr.Status)
+                }
+                if hasReviewer(pr, oldReviewer) == reassigned || (reassigned && !hasReviewer(pr, newReviewer)) {
+                    t.Fatalf("%s: reviewers %v after reassign=%v of %s to %q", prId, pr.AssignedReviewers, reassigned, oldReviewer, newReviewer)
+                }
+
+                history, errResponse := pullRequests.History(ctx, prId)
+                if errResponse.Error.Code != "" {
+                    t.Fatalf("History: %s", errResponse.Error.Message)
+                }
+                // замена либо целиком до merge, либо не случилась вовсе
+                var order []string
+                for _, event := range history.Events {
+                    if event.Type == EventReassigned || event.Type == EventMerged {
+                        order = append(order, event.Type)
+                    }
+                }
+                want := []string{EventMerged}
+                if reassigned {
+                    want = []string{EventReassigned, EventMerged}
+                }
+                if fmt.Sprint(order) != fmt.Sprint(want) {
+                    t.Fatalf("%s: events %v, want %v", prId, order, want)
+                }
+
+                if reviewers, _ := st.countRows(t, prId); reviewers != len(created.AssignedReviewers) {
+                    t.Fatalf("%s: %d reviewer rows, want %d", prId, reviewers, len(created.AssignedReviewers))
+                }
+            }
+        })
+    }
+}
+
+func hasReviewer(pr models.PullRequest, userId string) bool {
+    for _, reviewer := range pr.AssignedReviewers {
+        if reviewer == userId {
+            return true
+        }
+    }
+    return false
+}
+
+// TestReassignNoCandidateExplainsEveryPool: в NO_CANDIDATE объяснён каждый участник своей и запасной команды
+func TestReassignNoCandidateExplainsEveryPool(t *testing.T) {
+    for _, storage := range testStorages {
+        t.Run(storage.name, func(t *testing.T) {
+            st := storage.open(t)
+            ctx := context.Background()
+            teams := NewTeamService(st.teams, nopPublisher{})
+            maxReviewers := 2
+
+            if _, err := teams.CreateNewTeam(ctx, models.Team{TeamName: "platform", Members: []models.TeamMember{
+                {UserId: "f0", Username: "fallback 0", IsActive: true},
+                {UserId: "f1", Username: "fallback 1", IsActive: false},
+            }}); err != nil {
+                t.Fatalf("CreateNewTeam platform: %v", err)
+            }
+            if _, err := teams.CreateNewTeam(ctx, models.Team{TeamName: "backend", MaxReviewers: &maxReviewers, FallbackTeamNames: []string{"platform"}, Members: []models.TeamMember{
+                {UserId: "u0", Username: "user 0", IsActive: true},
+                {UserId: "u1", Username: "user 1", IsActive: true},
+            }}); err != nil {
+                t.Fatalf("CreateNewTeam backend: %v", err)
+            }
+
+            // u1 - из своей команды, f0 добран из запасной
+            pullRequests := NewPullRequestService(st.pullRequests, st.uow, nopPublisher{})
+            created, errResponse := pullRequests.Create(ctx, models.PullRequestCreatePostRequest{PullRequestId: "pr-1", PullRequestName: "search", AuthorId: "u0"})
+            if errResponse.Error.Code != "" {
+                t.Fatalf("Create: %s", errResponse.Error.Message)
+            }
+            if !hasReviewer(created, "u1") || !hasReviewer(created, "f0") {
+                t.Fatalf("reviewers %v, want u1 and f0", created.AssignedReviewers)
+            }
+
+            _, errResponse, _ = pullRequests.Reassign(ctx, models.PullRequestReassignPostRequest{PullRequestId: "pr-1", OldUserId: "u1"})
+            if errResponse.Error.Code != "NO_CANDIDATE" {
+                t.Fatalf("Reassign: %s: %s, want NO_CANDIDATE", errResponse.Error.Code, errResponse.Error.Message)
+            }
+            want := map[string]string{
+                "u0": "author of the pull request",
+                "u1": "reviewer being replaced",
+                "f0": "already assigned to this pull request",
+                "f1": "inactive",
+            }
+            if fmt.Sprint(errResponse.Error.Details) != fmt.Sprint(want) {
+                t.Fatalf("details %v, want %v", errResponse.Error.Details, want)
+            }
+        })
+    }
+}
+
+// TestForcedMergeActor: принудительный merge доступен только admin из токена и внутренним вызовам
+// с системным actor, и в аудит попадает именно он
+func TestForcedMergeActor(t *testing.T) {
+    tests := []struct {
+        name     string
+        ctx      context.Context
+        code     string
+        forcedBy string
+    }{
+        {"no principal", context.Background(), "FORBIDDEN", ""},
+        {"user", WithPrincipal(context.Background(), Principal{UserId: "u0", Role: RoleUser}), "FORBIDDEN", ""},
+        {"admin", WithPrincipal(context.Background(), Principal{UserId: "admin", Role: RoleAdmin}), "", "admin"},
+        {"system actor", WithSystemActor(context.Background(), "gitlab:bob"), "", "gitlab:bob"},
+    }
+
+    for _, storage := range testStorages {
+        t.Run(storage.name, func(t *testing.T) {
+            st := storage.open(t)
+            ctx := context.Background()
+            maxReviewers, requiredApprovals := 2, 1
+            teams := NewTeamService(st.teams, nopPublisher{})
+            if _, err := teams.CreateNewTeam(ctx, models.Team{TeamName: "backend", MaxReviewers: &maxReviewers, RequiredApprovals: &requiredApprovals, Members: []models.TeamMember{
+                {UserId: "u0", Username: "user 0", IsActive: true},
+                {UserId: "u1", Username: "user 1", IsActive: true},
+            }}); err != nil {
+                t.Fatalf("CreateNewTeam: %v", err)
+            }
+            pullRequests := NewPullRequestService(st.pullRequests, st.uow, nopPublisher{})
+
+            for i, tt := range tests {
+                prId := fmt.Sprintf("pr-%d", i)
+                if _, errResponse := pullRequests.Create(ctx, models.PullRequestCreatePostRequest{PullRequestId: prId, PullRequestName: "search", AuthorId: "u0"}); errResponse.Error.Code != "" {
+                    t.Fatalf("Create: %s", errResponse.Error.Message)
+                }
+
+                pr, errResponse := pullRequests.Merge(tt.ctx, models.PullRequestMergePostRequest{PullRequestId: prId, Force: true, Reason: "hotfix"})
+                if errResponse.Error.Code != tt.code {
+                    t.Fatalf("%s: Merge: %q (%s), want %q", tt.name, errResponse.Error.Code, errResponse.Error.Message, tt.code)
+                }
+                if tt.code != "" {
+                    continue
+                }
+                if pr.MergeOverride == nil || pr.MergeOverride.ForcedBy != tt.forcedBy {
+                    t.Fatalf("%s: merge override %+v, want forced by %s", tt.name, pr.MergeOverride, tt.forcedBy)
+                }
+            }
+        })
+    }
+}
+
+// TestUnitOfWorkRollback: если fn вернула ошибку, ни одно изменение внутри UnitOfWork не остаётся -
+// ни у существующего PR, ни новый PR, ни события
+func TestUnitOfWorkRollback(t *testing.T) {
+    for _, storage := range testStorages {
+        t.Run(storage.name, func(t *testing.T) {
+            st := storage.open(t)
+            ctx := context.Background()
+            pullRequests := seedReviewTeam(t, st, 4)
+
+            created, errResponse := pullRequests.Create(ctx, models.PullRequestCreatePostRequest{PullRequestId: "pr-1", PullRequestName: "search", AuthorId: "u0"})
+            if errResponse.Error.Code != "" {
+                t.Fatalf("Create: %s", errResponse.Error.Message)
+            }
+            before, err := st.pullRequests.GetByID(ctx, "pr-1")
+            if err != nil {
+                t.Fatalf("GetByID: %v", err)
+            }
+            _, eventsBefore := st.countRows(t, "pr-1")
+            free := "u1"
+            for _, userId := range []string{"u1", "u2", "u3"} {
+                if !hasReviewer(created, userId) {
+                    free = userId
+                }
+            }
+
+            errRollback := errors.New("rollback")
+            err = st.uow.Do(ctx, func(ctx context.Context) error {
+                teamId, err := st.pullRequests.GetTeamID(ctx, "backend")
+                if err != nil {
+                    return err
+                }
+                steps := []error{
+                    st.pullRequests.SubmitReview(ctx, "pr-1", created.AssignedReviewers[0], VerdictApproved, "", time.Now().UTC()),
+                    st.pullRequests.ReplaceReviewer(ctx, "pr-1", created.AssignedReviewers[1], internal_models.SelectedReviewer{UserID: free, TeamID: teamId}),
+                    st.pullRequests.UpdateStatus(ctx, "pr-1", StatusOpen, StatusClosed, time.Now().UTC()),
+                    st.pullRequests.CreatePR(ctx, models.PullRequestCreatePostRequest{PullRequestId: "pr-2", PullRequestName: "rolled back", AuthorId: "u1"}, teamId, StatusOpen),
+                    st.pullRequests.AddEvents(ctx, []models.PullRequestEvent{{PullRequestId: "pr-1", Type: EventStatusChanged, FromStatus: StatusOpen, ToStatus: StatusClosed}}),
+                }
+                for i, err := range steps {
+                    if err != nil {
+                        t.Errorf("step %d: %v", i, err)
+                    }
+                }
+                return errRollback
+            })
+            if !errors.Is(err, errRollback) {
+                t.Fatalf("Do: %v, want the error returned by fn", err)
+            }
+
+            after, err := st.pullRequests.GetByID(ctx, "pr-1")
+            if err != nil {
+                t.Fatalf("GetByID: %v", err)
+            }
+            if !reflect.DeepEqual(after, before) {
+                t.Fatalf("pr-1 after rollback %+v, want %+v", after, before)
+            }
+            if exists, err := st.pullRequests.PRExists(ctx, "pr-2"); err != nil || exists {
+                t.Fatalf("pr-2 exists after rollback: %v, %v", exists, err)
+            }
+            if _, events := st.countRows(t, "pr-1"); events != eventsBefore {
+                t.Fatalf("%d events after rollback, want %d", events, eventsBefore)
+            }
+        })
+    }
+}
+
+// TestMergeIgnoresApprovalOfDeletedReviewer: апрув удалённого ревьювера остаётся в истории PR,
+// но после reopen не засчитывается в required_approvals
+func TestMergeIgnoresApprovalOfDeletedReviewer(t *testing.T) {
+    for _, storage := range testStorages {
+        t.Run(storage.name, func(t *testing.T) {
+            st := storage.open(t)
+            ctx := context.Background()
+            maxReviewers, requiredApprovals := 2, 1
+            teams := NewTeamService(st.teams, nopPublisher{})
+            if _, err := teams.CreateNewTeam(ctx, models.Team{TeamName: "backend", MaxReviewers: &maxReviewers, RequiredApprovals: &requiredApprovals, Members: []models.TeamMember{
+                {UserId: "u0", Username: "user 0", IsActive: true},
+                {UserId: "u1", Username: "user 1", IsActive: true},
+                {UserId: "u2", Username: "user 2", IsActive: true},
+            }}); err != nil {
+                t.Fatalf("CreateNewTeam: %v", err)
+            }
+            pullRequests := NewPullRequestService(st.pullRequests, st.uow, nopPublisher{})
+            users := NewUserService(st.users, nopPublisher{})
+
+            if _, errResponse := pullRequests.Create(ctx, models.PullRequestCreatePostRequest{PullRequestId: "pr-1", PullRequestName: "search", AuthorId: "u0"}); errResponse.Error.Code != "" {
+                t.Fatalf("Create: %s", errResponse.Error.Message)
+            }
+            if _, errResponse := pullRequests.SubmitReview(ctx, models.PullRequestReviewPostRequest{PullRequestId: "pr-1", ReviewerId: "u1", Verdict: VerdictApproved}); errResponse.Error.Code != "" {
+                t.Fatalf("SubmitReview: %s", errResponse.Error.Message)
+            }
+            if _, errResponse := pullRequests.Close(ctx, models.PullRequestClosePostRequest{PullRequestId: "pr-1"}); errResponse.Error.Code != "" {
+                t.Fatalf("Close: %s", errResponse.Error.Message)
+            }
+            if _, errResponse := users.Delete(ctx, models.UsersDeletePostRequest{UserId: "u1"}); errResponse.Error.Code != "" {
+                t.Fatalf("Delete: %s", errResponse.Error.Message)
+            }
+            if _, errResponse := pullRequests.Reopen(ctx, models.PullRequestReopenPostRequest{PullRequestId: "pr-1"}); errResponse.Error.Code != "" {
+                t.Fatalf("Reopen: %s", errResponse.Error.Message)
+            }
+
+            pr, errResponse := pullRequests.Get(ctx, "pr-1")
+            if errResponse.Error.Code != "" {
+                t.Fatalf("Get: %s", errResponse.Error.Message)
+            }
+            if len(pr.Reviews) != 1 || pr.Reviews[0].ReviewerId != "" || pr.Reviews[0].Verdict != VerdictApproved {
+                t.Fatalf("reviews %+v, want the approval of the deleted reviewer kept without reviewer_id", pr.Reviews)
+            }
+
+            _, errResponse = pullRequests.Merge(ctx, models.PullRequestMergePostRequest{PullRequestId: "pr-1"})
+            if errResponse.Error.Code != "NOT_APPROVED" {
+                t.Fatalf("Merge: %q (%s), want NOT_APPROVED", errResponse.Error.Code, errResponse.Error.Message)
+            }
+        })
+    }
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+// reassignmentPlan подбирает замены для пакета назначений (деактивация, перевод в другую команду)
+// и запоминает итоговый состав ревьюверов каждого затронутого PR для ответа API
+type reassignmentPlan struct {
+	finalReviewers map[string][]string
+}
+
+func newReassignmentPlan() *reassignmentPlan {
+	return &reassignmentPlan{finalReviewers: map[string][]string{}}
+}
+
+// Plan подходит как postgres.ReassignmentPlanner. Замена ищется так же, как в selectReviewers:
+// по пулам команды PR по порядку, в каждом - по его стратегии. Загрузка кандидатов обновляется по ходу,
+// чтобы пакет ревью распределялся так же, как если бы они назначались по одному.
+// Снимаемые ревьюверы не выбираются ни в один PR, даже если остались в запасной или вышестоящей команде
+func (p *reassignmentPlan) Plan(snapshot internal_models.ReassignmentSnapshot) []internal_models.ReviewerReassignment {
+	now := time.Now().UTC()
+
+	byUser := map[string][]*internal_models.ReviewerCandidate{}
+	for teamId := range snapshot.Candidates {
+		candidates := snapshot.Candidates[teamId]
+		for i := range candidates {
+			byUser[candidates[i].UserID] = append(byUser[candidates[i].UserID], &candidates[i])
+		}
+	}
+
+	leaving := map[string]bool{}
+	for _, a := range snapshot.Assignments {
+		leaving[a.ReviewerID] = true
+		if _, ok := p.finalReviewers[a.PullRequestID]; !ok {
+			p.finalReviewers[a.PullRequestID] = append([]string(nil), a.Reviewers...)
+		}
+	}
+
+	decisions := make([]internal_models.ReviewerReassignment, 0, len(snapshot.Assignments))
+	for _, a := range snapshot.Assignments {
+		decision := internal_models.ReviewerReassignment{
+			PullRequestID: a.PullRequestID,
+			OldReviewerID: a.ReviewerID,
+		}
+		reviewers := p.finalReviewers[a.PullRequestID]
+
+		excluded := map[string]bool{a.AuthorID: true}
+		for _, r := range reviewers {
+			excluded[r] = true
+		}
+
+		for _, pool := range snapshot.Pools[a.TeamID] {
+			strategy, err := GetAssignmentStrategy(pool.Strategy)
+			if err != nil {
+				strategy, _ = GetAssignmentStrategy(DefaultAssignmentStrategy)
+			}
+
+			candidates := make([]internal_models.ReviewerCandidate, 0, len(snapshot.Candidates[pool.TeamID]))
+			for _, c := range snapshot.Candidates[pool.TeamID] {
+				if !excluded[c.UserID] && !leaving[c.UserID] {
+					candidates = append(candidates, c)
+				}
+			}
+
+			if picked := strategy.Select(candidates, 1); len(picked) > 0 {
+				decision.NewReviewerID = picked[0]
+				decision.Source = pool.Source
+				decision.SourceTeamID = pool.TeamID
+				for _, c := range byUser[picked[0]] {
+					c.OpenReviews++
+					c.LastAssignedAt = &now
+				}
+				break
+			}
+		}
+
+		updated := make([]string, 0, len(reviewers))
+		for _, r := range reviewers {
+			if r != a.ReviewerID {
+				updated = append(updated, r)
+			} else if decision.NewReviewerID != "" {
+				updated = append(updated, decision.NewReviewerID)
+			}
+		}
+		p.finalReviewers[a.PullRequestID] = updated
+
+		decisions = append(decisions, decision)
+	}
+
+	return decisions
+}
+
+// Report группирует решения по PR; второй результат - PR, у которых ревьювер снят без замены
+func (p *reassignmentPlan) Report(decisions []internal_models.ReviewerReassignment) ([]models.ReassignedPullRequest, []string) {
+	byPR := map[string]*models.ReassignedPullRequest{}
+	order := []string{}
+
+	for _, d := range decisions {
+		pr, ok := byPR[d.PullRequestID]
+		if !ok {
+			pr = &models.ReassignedPullRequest{
+				PullRequestId:     d.PullRequestID,
+				Replacements:      []models.ReviewerReplacement{},
+				Removed:           []string{},
+				AssignedReviewers: p.finalReviewers[d.PullRequestID],
+			}
+			byPR[d.PullRequestID] = pr
+			order = append(order, d.PullRequestID)
+		}
+
+		if d.NewReviewerID == "" {
+			pr.Removed = append(pr.Removed, d.OldReviewerID)
+		} else {
+			pr.Replacements = append(pr.Replacements, models.ReviewerReplacement{
+				OldUserId: d.OldReviewerID,
+				NewUserId: d.NewReviewerID,
+			})
+		}
+	}
+
+	sort.Strings(order)
+
+	pullRequests := make([]models.ReassignedPullRequest, 0, len(order))
+	shortOfReviewers := []string{}
+	for _, id := range order {
+		pullRequests = append(pullRequests, *byPR[id])
+		if len(byPR[id].Removed) > 0 {
+			shortOfReviewers = append(shortOfReviewers, id)
+		}
+	}
+
+	return pullRequests, shortOfReviewers
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+// Хранилища, с которыми работают сервисы. Реализации: adapters/postgres и adapters/memory;
+// об ошибках они сообщают значениями из internal/models (ErrTeamNotFound и т.д.)
+
+// UnitOfWork выполняет fn атомарно: вызовы репозиториев с переданным в fn ctx идут в одной транзакции,
+// которая откатывается, если fn вернула ошибку
+type UnitOfWork interface {
+    Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type PullRequestRepository interface {
+    PRExists(ctx context.Context, prId string) (bool, error)
+    // блокирует PR до конца UnitOfWork
+    LockPullRequest(ctx context.Context, prID string) error
+    GetUserTeam(ctx context.Context, userId string) (int, error)
+    GetTeamID(ctx context.Context, teamName string) (int, error)
+    IsTeamMember(ctx context.Context, teamId int, userId string) (bool, error)
+    // команда, из которой назначаются ревьюверы PR (0 - команды нет)
+    GetPullRequestTeam(ctx context.Context, prId string) (int, error)
+    GetTeamAssignmentSettings(ctx context.Context, teamId int) (internal_models.TeamAssignmentSettings, error)
+    GetTeamMembers(ctx context.Context, teamId int) ([]models.TeamMember, error)
+    GetUsersActivity(ctx context.Context, userIds []string) (map[string]bool, error)
+    GetTeamCandidates(ctx context.Context, teamId int, exclude []string) ([]internal_models.ReviewerCandidate, error)
+    GetReviewerPools(ctx context.Context, teamId int) ([]internal_models.ReviewerPool, error)
+
+    // ErrPullRequestExists, если PR с таким id уже есть
+    CreatePR(ctx context.Context, req models.PullRequestCreatePostRequest, teamId int, status string) error
+    AssignReviewers(ctx context.Context, prId string, reviewers []internal_models.SelectedReviewer) error
+    MarkReadyForReview(ctx context.Context, prID string, reviewers []internal_models.SelectedReviewer) error
+    GetByID(ctx context.Context, prID string) (models.PullRequest, error)
+    ListPullRequests(ctx context.Context, filter internal_models.PullRequestFilter) ([]models.PullRequest, error)
+    UpdateStatus(ctx context.Context, prID, from, to string, at time.Time) error
+    SetMerged(ctx context.Context, prID string, mergedAt time.Time, override *models.PullRequestMergeOverride) (models.PullRequest, error)
+    ReplaceReviewer(ctx context.Context, prID, oldReviewer string, newReviewer internal_models.SelectedReviewer) error
+    SubmitReview(ctx context.Context, prID, reviewerID, verdict, comment string, submittedAt time.Time) error
+
+    AddEvents(ctx context.Context, events []models.PullRequestEvent) error
+    GetEvents(ctx context.Context, prID string) ([]models.PullRequestEvent, error)
+}
+
+type TeamRepository interface {
+    IsTeamExists(ctx context.Context, teamName string) (bool, error)
+    CreateTeam(ctx context.Context, team models.Team) (models.Team, error)
+    GetTeamByName(ctx context.Context, teamName string) (models.Team, error)
+    UpdateTeamSettings(ctx context.Context, req models.TeamUpdateSettingsPostRequest) error
+    AddMember(ctx context.Context, teamName string, member models.TeamMember) error
+    // plan == nil - OPEN-ревью уходящих участников не переназначаются
+    RemoveMember(ctx context.Context, teamName string, userId string, actorId string, plan internal_models.ReassignmentPlanner) ([]internal_models.ReviewerReassignment, error)
+    MoveMember(ctx context.Context, userId string, fromTeamName string, toTeamName string, actorId string, plan internal_models.ReassignmentPlanner) (string, []internal_models.ReviewerReassignment, error)
+    RenameTeam(ctx context.Context, teamName string, newTeamName string) error
+    DeleteTeam(ctx context.Context, teamName string, refuse bool, moveToTeamName string, actorId string, plan internal_models.ReassignmentPlanner) (internal_models.TeamDeletion, error)
+}
+
+type UserRepository interface {
+    GetReviews(ctx context.Context, userId string) ([]models.PullRequestShort, error)
+    SetIsActivePost(ctx context.Context, usersSetIsActiveRequest models.UsersSetIsActivePostRequest) (models.User, error)
+    GetUserByID(ctx context.Context, userId string) (models.User, error)
+    ListUsers(ctx context.Context, filter internal_models.UserFilter) ([]models.User, error)
+    UpdateUser(ctx context.Context, req models.UsersUpdatePostRequest) (models.User, error)
+    DeleteUser(ctx context.Context, userId string, closeAuthored bool, actorId string, plan internal_models.ReassignmentPlanner) ([]string, []internal_models.ReviewerReassignment, error)
+    DeactivateUsers(ctx context.Context, userIds []string, teamName string, actorId string, plan internal_models.ReassignmentPlanner) ([]string, []internal_models.ReviewerReassignment, error)
+}
+
+type StatsRepository interface {
+    GetReviewerStats(ctx context.Context, from, to *time.Time) ([]models.ReviewerStats, error)
+    GetTeamStats(ctx context.Context, from, to *time.Time) ([]models.TeamStats, error)
+    GetAuthorStats(ctx context.Context, from, to *time.Time) ([]models.AuthorStats, error)
+}
+
+type WebhookRepository interface {
+    CreateSubscription(ctx context.Context, url, secret string, events []string) (models.WebhookSubscription, error)
+    ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+    // ErrWebhookNotFound; журнал доставок подписки удаляется вместе с ней
+    DeleteSubscription(ctx context.Context, subscriptionId int64) error
+
+    // ставит payload в очередь всем подпискам на event; внутри UnitOfWork - в той же транзакции
+    EnqueueDeliveries(ctx context.Context, event string, payload []byte) error
+    // берёт до limit доставок, время которых пришло, и откладывает их на lease,
+    // чтобы другие реплики не отправили их одновременно
+    ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]internal_models.WebhookTask, error)
+    // записывает попытку, только если доставка всё ещё закреплена до leasedUntil (иначе ErrDeliveryLeaseLost)
+    RecordAttempt(ctx context.Context, deliveryId int64, leasedUntil time.Time, attempt internal_models.WebhookAttempt) error
+    ListDeliveries(ctx context.Context, filter internal_models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error)
+    // ставит в очередь копию доставки; ErrDeliveryNotFound
+    Redeliver(ctx context.Context, deliveryId int64) (models.WebhookDelivery, error)
+}
+
+type IntegrationRepository interface {
+    // ErrUserNotFound; повторная привязка логина переносит его на другого пользователя
+    LinkAccount(ctx context.Context, account models.VcsAccount) error
+    // ErrAccountNotFound
+    UnlinkAccount(ctx context.Context, provider, login string) error
+    // фильтры необязательны (пусто - без ограничения)
+    ListAccounts(ctx context.Context, provider, userId string) ([]models.VcsAccount, error)
+    // user_id, привязанный к логину; ErrAccountNotFound
+    ResolveAccount(ctx context.Context, provider, login string) (string, error)
+
+    // ClaimDelivery отмечает входящую доставку как обрабатываемую. false - доставка уже обработана
+    // или обрабатывается; проваленные и зависшие (начатые до staleBefore) доставки можно взять снова
+    ClaimDelivery(ctx context.Context, provider, deliveryId, event string, staleBefore time.Time) (bool, error)
+    FinishDelivery(ctx context.Context, provider, deliveryId, status, result string) error
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+)
+
+type StatsService struct {
+	statsRepo StatsRepository
+}
+
+func NewStatsService(statsRepo StatsRepository) *StatsService {
+	return &StatsService{statsRepo: statsRepo}
+}
+
+func (s *StatsService) GetStats(ctx context.Context, fromParam, toParam string) (models.StatsGet200Response, models.ErrorResponse) {
+	from, err := parseTimeParam("from", fromParam)
+	if err != nil {
+		return models.StatsGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		}
+	}
+	to, err := parseTimeParam("to", toParam)
+	if err != nil {
+		return models.StatsGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		}
+	}
+
+	reviewers, err := s.statsRepo.GetReviewerStats(ctx, from, to)
+	if err != nil {
+		return models.StatsGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+	}
+
+	teams, err := s.statsRepo.GetTeamStats(ctx, from, to)
+	if err != nil {
+		return models.StatsGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+	}
+
+	authors, err := s.statsRepo.GetAuthorStats(ctx, from, to)
+	if err != nil {
+		return models.StatsGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+	}
+
+	return models.StatsGet200Response{
+		Reviewers: reviewers,
+		Teams:     teams,
+		Authors:   authors,
+	}, models.ErrorResponse{}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kgugunava/avito-tech-internship/internal/adapters/memory"
+	"github.com/kgugunava/avito-tech-internship/internal/adapters/postgres"
+	"github.com/kgugunava/avito-tech-internship/internal/adapters/postgres/pgtest"
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+)
+
+// testStorage - репозитории одного хранилища; тесты сервисов гоняются на обоих адаптерах
+type testStorage struct {
+    pullRequests PullRequestRepository
+    teams        TeamRepository
+    users        UserRepository
+    webhooks     WebhookRepository
+    integrations IntegrationRepository
+    uow          UnitOfWork
+    // countRows считает сохранённых ревьюверов и события PR - чтобы проверить, что откат ничего не оставил
+    countRows func(tb testing.TB, prId string) (reviewers int, events int)
+}
+
+var testStorages = []struct {
+    name string
+    open func(tb testing.TB) testStorage
+}{
+    {"memory", openMemoryStorage},
+    {"postgres", openPostgresStorage},
+}
+
+func openMemoryStorage(tb testing.TB) testStorage {
+    store := memory.NewStore()
+    pullRequests := memory.NewPullRequestRepository(store)
+    return testStorage{
+        pullRequests: pullRequests,
+        teams:        memory.NewTeamRepository(store),
+        users:        memory.NewUserRepository(store),
+        webhooks:     memory.NewWebhookRepository(store),
+        integrations: memory.NewIntegrationRepository(store),
+        uow:          memory.NewUnitOfWork(store),
+        countRows: func(tb testing.TB, prId string) (int, int) {
+            tb.Helper()
+            // в памяти ревьюверы хранятся внутри PR: без PR их не бывает
+            var reviewers int
+            if pr, err := pullRequests.GetByID(context.Background(), prId); err == nil {
+                reviewers = len(pr.AssignedReviewers)
+            }
+            events, err := pullRequests.GetEvents(context.Background(), prId)
+            if err != nil {
+                tb.Fatalf("GetEvents: %v", err)
+            }
+            return reviewers, len(events)
+        },
+    }
+}
+
+func openPostgresStorage(tb testing.TB) testStorage {
+    db := pgtest.Open(tb, "service")
+    return testStorage{
+        pullRequests: postgres.NewPullRequestRepository(db.Pool),
+        teams:        postgres.NewTeamRepository(db.Pool),
+        users:        postgres.NewUserRepository(db.Pool),
+        webhooks:     postgres.NewWebhookRepository(db.Pool),
+        integrations: postgres.NewIntegrationRepository(db.Pool),
+        uow:          postgres.NewUnitOfWork(db.Pool),
+        countRows: func(tb testing.TB, prId string) (int, int) {
+            tb.Helper()
+            var reviewers, events int
+            err := db.Pool.QueryRow(context.Background(), `
+                SELECT (SELECT COUNT(*) FROM pull_request_reviewers WHERE pull_request_id = $1),
+                       (SELECT COUNT(*) FROM pr_events WHERE pull_request_id = $1)
+            `, prId).Scan(&reviewers, &events)
+            if err != nil {
+                tb.Fatalf("count rows: %v", err)
+            }
+            return reviewers, events
+        },
+    }
+}
+
+// nopPublisher - тестам сервисов PR вебхуки не нужны
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(ctx context.Context, events ...models.PullRequestEvent) error {
+    return nil
+}
@@ -4,19 +4,43 @@ import (
 	"context"
 	"errors"
 
-	"github.com/kgugunava/avito-tech-internship/internal/adapters/postgres"
 	api_models "github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
 )
 
 type TeamService struct {
-	teamRepo *postgres.TeamRepository
+	teamRepo  TeamRepository
+	publisher EventPublisher
 }
 
-var ErrTeamExists = errors.New("team already exists")
+var ErrTeamExists = internal_models.ErrTeamExists
+var ErrInvalidReviewWeight = errors.New("review_weight must be positive")
+var ErrInvalidReviewersRange = errors.New("reviewers range must satisfy 0 <= min_reviewers <= max_reviewers, max_reviewers >= 1")
+var ErrInvalidRequiredApprovals = errors.New("required_approvals must be between 0 and max_reviewers")
+var ErrInvalidOpenReviewsMode = errors.New("open_reviews must be keep or reassign")
+var ErrInvalidMembersPolicy = errors.New("members must be refuse, move (with move_to_team_name) or detach")
+var ErrInvalidTeamName = errors.New("team name must not be empty")
 
+const (
+    DefaultMinReviewers = 0
+    DefaultMaxReviewers = 2
+)
+
+// что делать с OPEN-ревью участника, который уходит из команды
+const (
+    OpenReviewsKeep     = "keep"
+    OpenReviewsReassign = "reassign"
+)
 
-func NewTeamService(teamRepo *postgres.TeamRepository) *TeamService {
-	return &TeamService{teamRepo: teamRepo}
+// что делать с участниками удаляемой команды
+const (
+    MembersRefuse = "refuse"
+    MembersMove   = "move"
+    MembersDetach = "detach"
+)
+
+func NewTeamService(teamRepo TeamRepository, publisher EventPublisher) *TeamService {
+	return &TeamService{teamRepo: teamRepo, publisher: publisher}
 }
 
 func (s *TeamService) IsTeamExists(ctx context.Context, teamName string) (bool, error) {
@@ -29,7 +53,43 @@ func (s *TeamService) CreateNewTeam(ctx context.Context, team api_models.Team) (
         return api_models.Team{}, err
     }
     if exists {
-        return api_models.Team{}, postgres.ErrTeamNotFound
+        return api_models.Team{}, internal_models.ErrTeamNotFound
+    }
+
+    if team.AssignmentStrategy == "" {
+        team.AssignmentStrategy = DefaultAssignmentStrategy
+    }
+    if _, err := GetAssignmentStrategy(team.AssignmentStrategy); err != nil {
+        return api_models.Team{}, err
+    }
+
+    if team.MinReviewers == nil {
+        minReviewers := DefaultMinReviewers
+        team.MinReviewers = &minReviewers
+    }
+    if team.MaxReviewers == nil {
+        maxReviewers := DefaultMaxReviewers
+        team.MaxReviewers = &maxReviewers
+    }
+    if err := validateReviewersRange(*team.MinReviewers, *team.MaxReviewers); err != nil {
+        return api_models.Team{}, err
+    }
+
+    if team.RequiredApprovals == nil {
+        requiredApprovals := 0
+        team.RequiredApprovals = &requiredApprovals
+    }
+    if err := validateRequiredApprovals(*team.RequiredApprovals, *team.MaxReviewers); err != nil {
+        return api_models.Team{}, err
+    }
+
+    for i := range team.Members {
+        if team.Members[i].ReviewWeight < 0 {
+            return api_models.Team{}, ErrInvalidReviewWeight
+        }
+        if team.Members[i].ReviewWeight == 0 {
+            team.Members[i].ReviewWeight = 1
+        }
     }
 
     createdTeam, err := s.teamRepo.CreateTeam(ctx, team)
@@ -47,7 +107,7 @@ func (s* TeamService) GetTeamByName(ctx context.Context, teamName string) (api_m
     }
 
     if !exists {
-        return api_models.Team{}, postgres.ErrTeamNotFound
+        return api_models.Team{}, internal_models.ErrTeamNotFound
     }
 
     team, err := s.teamRepo.GetTeamByName(ctx, teamName)
@@ -56,4 +116,211 @@ func (s* TeamService) GetTeamByName(ctx context.Context, teamName string) (api_m
     }
 
     return team, nil
-}
\ No newline at end of file
+}
+
+func (s *TeamService) UpdateTeamSettings(ctx context.Context, req api_models.TeamUpdateSettingsPostRequest) (api_models.Team, error) {
+    if req.AssignmentStrategy != nil {
+        if _, err := GetAssignmentStrategy(*req.AssignmentStrategy); err != nil {
+            return api_models.Team{}, err
+        }
+    }
+
+    if req.MinReviewers != nil || req.MaxReviewers != nil || req.RequiredApprovals != nil {
+        current, err := s.GetTeamByName(ctx, req.TeamName)
+        if err != nil {
+            return api_models.Team{}, err
+        }
+
+        // проверяются значения, которые получатся после обновления
+        minReviewers, maxReviewers, requiredApprovals := *current.MinReviewers, *current.MaxReviewers, *current.RequiredApprovals
+        if req.MinReviewers != nil {
+            minReviewers = *req.MinReviewers
+        }
+        if req.MaxReviewers != nil {
+            maxReviewers = *req.MaxReviewers
+        }
+        if req.RequiredApprovals != nil {
+            requiredApprovals = *req.RequiredApprovals
+        }
+        if err := validateReviewersRange(minReviewers, maxReviewers); err != nil {
+            return api_models.Team{}, err
+        }
+        if err := validateRequiredApprovals(requiredApprovals, maxReviewers); err != nil {
+            return api_models.Team{}, err
+        }
+    }
+
+    if err := s.teamRepo.UpdateTeamSettings(ctx, req); err != nil {
+        return api_models.Team{}, err
+    }
+
+    return s.teamRepo.GetTeamByName(ctx, req.TeamName)
+}
+
+func (s *TeamService) AddMember(ctx context.Context, req api_models.TeamAddMemberPostRequest) (api_models.Team, error) {
+    if req.Member.ReviewWeight < 0 {
+        return api_models.Team{}, ErrInvalidReviewWeight
+    }
+    if req.Member.ReviewWeight == 0 {
+        req.Member.ReviewWeight = 1
+    }
+
+    if err := s.teamRepo.AddMember(ctx, req.TeamName, req.Member); err != nil {
+        return api_models.Team{}, err
+    }
+
+    return s.teamRepo.GetTeamByName(ctx, req.TeamName)
+}
+
+func (s *TeamService) RemoveMember(ctx context.Context, req api_models.TeamRemoveMemberPostRequest) (api_models.TeamMemberChangePost200Response, error) {
+    plan, err := openReviewsPlan(req.OpenReviews)
+    if err != nil {
+        return api_models.TeamMemberChangePost200Response{}, err
+    }
+
+    var planner internal_models.ReassignmentPlanner
+    if plan != nil {
+        planner = plan.Plan
+    }
+
+    decisions, err := s.teamRepo.RemoveMember(ctx, req.TeamName, req.UserId, ActorFromContext(ctx), planner)
+    if err != nil {
+        return api_models.TeamMemberChangePost200Response{}, err
+    }
+    publishReassignments(ctx, s.publisher, decisions, "member removed from team")
+
+    team, err := s.teamRepo.GetTeamByName(ctx, req.TeamName)
+    if err != nil {
+        return api_models.TeamMemberChangePost200Response{}, err
+    }
+
+    response := api_models.TeamMemberChangePost200Response{Team: team}
+    response.PullRequests, response.ShortOfReviewers = reportReassignments(plan, decisions)
+
+    return response, nil
+}
+
+func (s *TeamService) MoveMember(ctx context.Context, req api_models.TeamMoveMemberPostRequest) (api_models.TeamMemberChangePost200Response, error) {
+    plan, err := openReviewsPlan(req.OpenReviews)
+    if err != nil {
+        return api_models.TeamMemberChangePost200Response{}, err
+    }
+
+    var planner internal_models.ReassignmentPlanner
+    if plan != nil {
+        planner = plan.Plan
+    }
+
+    fromTeamName, decisions, err := s.teamRepo.MoveMember(ctx, req.UserId, req.FromTeamName, req.ToTeamName, ActorFromContext(ctx), planner)
+    if err != nil {
+        return api_models.TeamMemberChangePost200Response{}, err
+    }
+    publishReassignments(ctx, s.publisher, decisions, "member moved to another team")
+
+    fromTeam, err := s.teamRepo.GetTeamByName(ctx, fromTeamName)
+    if err != nil {
+        return api_models.TeamMemberChangePost200Response{}, err
+    }
+    team, err := s.teamRepo.GetTeamByName(ctx, req.ToTeamName)
+    if err != nil {
+        return api_models.TeamMemberChangePost200Response{}, err
+    }
+
+    response := api_models.TeamMemberChangePost200Response{FromTeam: &fromTeam, Team: team}
+    response.PullRequests, response.ShortOfReviewers = reportReassignments(plan, decisions)
+
+    return response, nil
+}
+
+func (s *TeamService) RenameTeam(ctx context.Context, req api_models.TeamRenamePostRequest) (api_models.Team, error) {
+    if req.NewTeamName == "" {
+        return api_models.Team{}, ErrInvalidTeamName
+    }
+
+    if err := s.teamRepo.RenameTeam(ctx, req.TeamName, req.NewTeamName); err != nil {
+        return api_models.Team{}, err
+    }
+
+    return s.teamRepo.GetTeamByName(ctx, req.NewTeamName)
+}
+
+func (s *TeamService) DeleteTeam(ctx context.Context, req api_models.TeamDeletePostRequest) (api_models.TeamDeletePost200Response, error) {
+    refuse := false
+    switch req.Members {
+    case "", MembersRefuse:
+        refuse = true
+        if req.MoveToTeamName != "" {
+            return api_models.TeamDeletePost200Response{}, ErrInvalidMembersPolicy
+        }
+    case MembersMove:
+        if req.MoveToTeamName == "" {
+            return api_models.TeamDeletePost200Response{}, ErrInvalidMembersPolicy
+        }
+    case MembersDetach:
+        if req.MoveToTeamName != "" {
+            return api_models.TeamDeletePost200Response{}, ErrInvalidMembersPolicy
+        }
+    default:
+        return api_models.TeamDeletePost200Response{}, ErrInvalidMembersPolicy
+    }
+
+    plan, err := openReviewsPlan(req.OpenReviews)
+    if err != nil {
+        return api_models.TeamDeletePost200Response{}, err
+    }
+
+    var planner internal_models.ReassignmentPlanner
+    if plan != nil {
+        planner = plan.Plan
+    }
+
+    deletion, err := s.teamRepo.DeleteTeam(ctx, req.TeamName, refuse, req.MoveToTeamName, ActorFromContext(ctx), planner)
+    if err != nil {
+        return api_models.TeamDeletePost200Response{}, err
+    }
+    publishReassignments(ctx, s.publisher, deletion.Reassignments, "team deleted")
+
+    response := api_models.TeamDeletePost200Response{
+        TeamName:                 req.TeamName,
+        Members:                  deletion.Members,
+        MovedToTeamName:          req.MoveToTeamName,
+        OpenPullRequests:         deletion.OpenPullRequests,
+    }
+    response.PullRequests, response.ShortOfReviewers = reportReassignments(plan, deletion.Reassignments)
+
+    return response, nil
+}
+
+// openReviewsPlan возвращает планировщик замен для режима reassign и nil для keep
+func openReviewsPlan(mode string) (*reassignmentPlan, error) {
+    switch mode {
+    case "", OpenReviewsKeep:
+        return nil, nil
+    case OpenReviewsReassign:
+        return newReassignmentPlan(), nil
+    }
+    return nil, ErrInvalidOpenReviewsMode
+}
+
+func reportReassignments(plan *reassignmentPlan, decisions []internal_models.ReviewerReassignment) ([]api_models.ReassignedPullRequest, []string) {
+    if plan == nil {
+        return []api_models.ReassignedPullRequest{}, []string{}
+    }
+    return plan.Report(decisions)
+}
+
+func validateReviewersRange(minReviewers, maxReviewers int) error {
+    if minReviewers < 0 || maxReviewers < 1 || minReviewers > maxReviewers {
+        return ErrInvalidReviewersRange
+    }
+    return nil
+}
+
+// validateRequiredApprovals не даёт требовать больше апрувов, чем у PR может быть ревьюверов:
+// такой PR нельзя было бы смержить без принудительного merge
+func validateRequiredApprovals(requiredApprovals, maxReviewers int) error {
+    if requiredApprovals < 0 || requiredApprovals > maxReviewers {
+        return ErrInvalidRequiredApprovals
+    }
+    return nil
+}
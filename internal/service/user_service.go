@@ -2,17 +2,27 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
-	"github.com/kgugunava/avito-tech-internship/internal/adapters/postgres"
 	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+// что делать с DRAFT/OPEN PR удаляемого пользователя
+const (
+    AuthoredPullRequestsRefuse = "refuse"
+    AuthoredPullRequestsClose  = "close"
 )
 
 type UserService struct {
-	userRepo *postgres.UserRepository
+	userRepo  UserRepository
+	publisher EventPublisher
 }
 
-func NewUserService(userRepo *postgres.UserRepository) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(userRepo UserRepository, publisher EventPublisher) *UserService {
+	return &UserService{userRepo: userRepo, publisher: publisher}
 }
 
 func (s *UserService) GetReviews(ctx context.Context, userId string) (models.UsersGetReviewGet200Response, models.ErrorResponse) {
@@ -44,4 +54,217 @@ func (s *UserService) GetReviews(ctx context.Context, userId string) (models.Use
 
 func (s *UserService) SetIsActivePost(ctx context.Context, usersSetIsActiveRequest models.UsersSetIsActivePostRequest) (models.User, error) {
 	return s.userRepo.SetIsActivePost(ctx, usersSetIsActiveRequest)
-}
\ No newline at end of file
+}
+
+func (s *UserService) GetUser(ctx context.Context, userId string) (models.User, models.ErrorResponse) {
+    user, err := s.userRepo.GetUserByID(ctx, userId)
+    if errors.Is(err, internal_models.ErrUserNotFound) {
+        return models.User{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "USER_NOT_FOUND",
+				Message: "user not found",
+			},
+		}
+    }
+    if err != nil {
+        return models.User{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return user, models.ErrorResponse{}
+}
+
+func (s *UserService) List(ctx context.Context, req models.UsersListGetRequest) (models.UsersListGet200Response, models.ErrorResponse) {
+    filter := internal_models.UserFilter{
+        TeamName:   req.TeamName,
+        IsActive:   req.IsActive,
+        NamePrefix: req.NamePrefix,
+        Limit:      req.Limit,
+        After:      req.Cursor,
+    }
+
+    if filter.Limit == 0 {
+        filter.Limit = defaultListLimit
+    }
+    if filter.Limit < 0 || filter.Limit > maxListLimit {
+        return models.UsersListGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: fmt.Sprintf("limit must be between 1 and %d", maxListLimit),
+			},
+		}
+    }
+
+    // запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+    pageSize := filter.Limit
+    filter.Limit++
+
+    users, err := s.userRepo.ListUsers(ctx, filter)
+    if errors.Is(err, internal_models.ErrTeamNotFound) {
+        return models.UsersListGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "TEAM_NOT_FOUND",
+				Message: "team not found",
+			},
+		}
+    }
+    if err != nil {
+        return models.UsersListGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    response := models.UsersListGet200Response{Users: users}
+    if len(users) > pageSize {
+        response.Users = users[:pageSize]
+        response.NextCursor = users[pageSize-1].UserId
+    }
+
+    return response, models.ErrorResponse{}
+}
+
+func (s *UserService) Update(ctx context.Context, req models.UsersUpdatePostRequest) (models.User, models.ErrorResponse) {
+    var invalid string
+    switch {
+    case req.UserId == "":
+        invalid = "user_id is required"
+    case req.Username != nil && strings.TrimSpace(*req.Username) == "":
+        invalid = "username must not be empty"
+    case req.ReviewWeight != nil && *req.ReviewWeight <= 0:
+        invalid = ErrInvalidReviewWeight.Error()
+    case req.TeamName != nil && *req.TeamName == "":
+        invalid = "team_name must not be empty"
+    }
+    if invalid != "" {
+        return models.User{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: invalid,
+			},
+		}
+    }
+
+    user, err := s.userRepo.UpdateUser(ctx, req)
+    if err != nil {
+        code := "INTERNAL_ERROR"
+        switch {
+        case errors.Is(err, internal_models.ErrUserNotFound):
+            code = "USER_NOT_FOUND"
+        case errors.Is(err, internal_models.ErrTeamNotFound):
+            code = "TEAM_NOT_FOUND"
+        case errors.Is(err, internal_models.ErrNotTeamMember):
+            code = "NOT_TEAM_MEMBER"
+        }
+        return models.User{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: code,
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return user, models.ErrorResponse{}
+}
+
+// Delete удаляет пользователя с переназначением его OPEN-ревью;
+// PR, где он автор, закрываются или блокируют удаление в зависимости от authored_pull_requests
+func (s *UserService) Delete(ctx context.Context, req models.UsersDeletePostRequest) (models.UsersDeletePost200Response, models.ErrorResponse) {
+    mode := req.AuthoredPullRequests
+    if mode == "" {
+        mode = AuthoredPullRequestsRefuse
+    }
+    if mode != AuthoredPullRequestsRefuse && mode != AuthoredPullRequestsClose {
+        return models.UsersDeletePost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: "authored_pull_requests must be refuse or close",
+			},
+		}
+    }
+
+    plan := newReassignmentPlan()
+
+    closed, decisions, err := s.userRepo.DeleteUser(ctx, req.UserId, mode == AuthoredPullRequestsClose, ActorFromContext(ctx), plan.Plan)
+    if err != nil {
+        code := "INTERNAL_ERROR"
+        switch {
+        case errors.Is(err, internal_models.ErrUserNotFound):
+            code = "USER_NOT_FOUND"
+        case errors.Is(err, internal_models.ErrUserHasOpenPullRequests):
+            code = "USER_HAS_OPEN_PULL_REQUESTS"
+        }
+        return models.UsersDeletePost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: code,
+				Message: err.Error(),
+			},
+		}
+    }
+
+    publishReassignments(ctx, s.publisher, decisions, "reviewer deleted")
+
+    pullRequests, shortOfReviewers := plan.Report(decisions)
+
+    return models.UsersDeletePost200Response{
+        UserId:             req.UserId,
+        ClosedPullRequests: closed,
+        PullRequests:       pullRequests,
+        ShortOfReviewers:   shortOfReviewers,
+    }, models.ErrorResponse{}
+}
+
+func (s *UserService) BulkDeactivate(ctx context.Context, req models.UsersBulkDeactivatePostRequest) (models.UsersBulkDeactivatePost200Response, models.ErrorResponse) {
+	if (len(req.UserIds) == 0) == (req.TeamName == "") {
+		return models.UsersBulkDeactivatePost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: "either user_ids or team_name is required",
+			},
+		}
+	}
+
+	plan := newReassignmentPlan()
+
+	deactivated, decisions, err := s.userRepo.DeactivateUsers(ctx, req.UserIds, req.TeamName, ActorFromContext(ctx), plan.Plan)
+	if errors.Is(err, internal_models.ErrTeamNotFound) {
+		return models.UsersBulkDeactivatePost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "TEAM_NOT_FOUND",
+				Message: "team not found",
+			},
+		}
+	}
+	if errors.Is(err, internal_models.ErrUserNotFound) {
+		return models.UsersBulkDeactivatePost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "USER_NOT_FOUND",
+				Message: err.Error(),
+			},
+		}
+	}
+	if err != nil {
+		return models.UsersBulkDeactivatePost200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+	}
+
+	publishReassignments(ctx, s.publisher, decisions, "reviewer deactivated")
+
+	pullRequests, shortOfReviewers := plan.Report(decisions)
+
+	return models.UsersBulkDeactivatePost200Response{
+		DeactivatedUsers: deactivated,
+		PullRequests:     pullRequests,
+		ShortOfReviewers: shortOfReviewers,
+	}, models.ErrorResponse{}
+}
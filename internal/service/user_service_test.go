@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+)
+
+const (
+    deactivateTeamSize     = 300
+    deactivatePullRequests = 3000
+    // бюджет на деактивацию половины команды с переназначением всех её OPEN-ревью
+    deactivateBudget = 2 * time.Second
+)
+
+// TestBulkDeactivateWithinBudget деактивирует половину команды из deactivateTeamSize человек, у которой
+// deactivatePullRequests OPEN PR, и проверяет, что это укладывается в deactivateBudget.
+// Postgres: TEST_DATABASE_URL=... go test ./internal/service -run BulkDeactivate
+func TestBulkDeactivateWithinBudget(t *testing.T) {
+    if testing.Short() {
+        t.Skip("seeds thousands of pull requests")
+    }
+
+    for _, storage := range testStorages {
+        t.Run(storage.name, func(t *testing.T) {
+            st := storage.open(t)
+            userIds := seedDeactivationLoad(t, st)
+            users := NewUserService(st.users, nopPublisher{})
+
+            start := time.Now()
+            response, errResponse := users.BulkDeactivate(context.Background(), models.UsersBulkDeactivatePostRequest{UserIds: userIds})
+            elapsed := time.Since(start)
+
+            if errResponse.Error.Code != "" {
+                t.Fatalf("BulkDeactivate: %s: %s", errResponse.Error.Code, errResponse.Error.Message)
+            }
+            if len(response.DeactivatedUsers) != len(userIds) || len(response.PullRequests) == 0 {
+                t.Fatalf("deactivated %d users, touched %d PRs", len(response.DeactivatedUsers), len(response.PullRequests))
+            }
+            if elapsed > deactivateBudget {
+                t.Fatalf("bulk deactivation took %s, budget is %s", elapsed, deactivateBudget)
+            }
+        })
+    }
+}
+
+// seedDeactivationLoad создаёт команду и OPEN PR с ревьюверами из неё; возвращает каждого второго
+// участника - их и деактивирует тест
+func seedDeactivationLoad(tb testing.TB, st testStorage) []string {
+    tb.Helper()
+    ctx := context.Background()
+
+    members := make([]models.TeamMember, 0, deactivateTeamSize)
+    for i := 0; i < deactivateTeamSize; i++ {
+        members = append(members, models.TeamMember{
+            UserId:   fmt.Sprintf("u%03d", i),
+            Username: fmt.Sprintf("user %d", i),
+            IsActive: true,
+        })
+    }
+    teams := NewTeamService(st.teams, nopPublisher{})
+    if _, err := teams.CreateNewTeam(ctx, models.Team{TeamName: "bench", Members: members}); err != nil {
+        tb.Fatalf("CreateNewTeam: %v", err)
+    }
+
+    pullRequests := NewPullRequestService(st.pullRequests, st.uow, nopPublisher{})
+    for i := 0; i < deactivatePullRequests; i++ {
+        _, errResponse := pullRequests.Create(ctx, models.PullRequestCreatePostRequest{
+            PullRequestId:   fmt.Sprintf("pr-%05d", i),
+            PullRequestName: "bench",
+            AuthorId:        members[i%deactivateTeamSize].UserId,
+        })
+        if errResponse.Error.Code != "" {
+            tb.Fatalf("Create: %s: %s", errResponse.Error.Code, errResponse.Error.Message)
+        }
+    }
+
+    userIds := []string{}
+    for i := 0; i < deactivateTeamSize; i += 2 {
+        userIds = append(userIds, members[i].UserId)
+    }
+    return userIds
+}
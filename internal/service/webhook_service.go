@@ -0,0 +1,409 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kgugunava/avito-tech-internship/internal/api/models"
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+// события исходящих вебхуков
+const (
+    WebhookPullRequestCreated = "pr.created"
+    WebhookReviewerAssigned   = "reviewer.assigned"
+    WebhookReviewerReassigned = "reviewer.reassigned"
+    WebhookPullRequestMerged  = "pr.merged"
+)
+
+var webhookEvents = map[string]bool{
+    WebhookPullRequestCreated: true,
+    WebhookReviewerAssigned:   true,
+    WebhookReviewerReassigned: true,
+    WebhookPullRequestMerged:  true,
+}
+
+// статусы доставки
+const (
+    DeliveryPending   = "pending"
+    DeliveryDelivered = "delivered"
+    DeliveryFailed    = "failed"
+)
+
+// заголовки исходящего запроса; подпись - "sha256=" + hex(HMAC-SHA256(secret, тело))
+const (
+    WebhookEventHeader     = "X-Webhook-Event"
+    WebhookDeliveryHeader  = "X-Webhook-Delivery"
+    WebhookSignatureHeader = "X-Webhook-Signature-256"
+)
+
+// параметры доставки: попытка через 10s, 20s, 40s ... (не больше часа), всего до 8 попыток
+const (
+    webhookMaxAttempts  = 8
+    webhookBaseBackoff  = 10 * time.Second
+    webhookMaxBackoff   = time.Hour
+    webhookTimeout      = 10 * time.Second
+    webhookPollInterval = time.Second
+    webhookBatchSize    = 20
+    // доставка, взятая репликой, не выдаётся другим, пока та отправляет всю пачку: пачка уходит
+    // последовательно, и каждая отправка ограничена webhookTimeout. Запас в одну отправку - на запись попыток
+    webhookLease = (webhookBatchSize + 1) * webhookTimeout
+)
+
+// EventPublisher получает события истории PR, которые нужно разослать подписчикам
+type EventPublisher interface {
+    Publish(ctx context.Context, events ...models.PullRequestEvent) error
+}
+
+type WebhookService struct {
+	webhookRepo WebhookRepository
+	client      *http.Client
+}
+
+func NewWebhookService(webhookRepo WebhookRepository) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		client:      &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (s *WebhookService) Add(ctx context.Context, req models.WebhooksAddPostRequest) (models.WebhookSubscription, models.ErrorResponse) {
+    if errResponse := validateSubscription(req); errResponse.Error.Code != "" {
+        return models.WebhookSubscription{}, errResponse
+    }
+
+    events := []string{}
+    seen := map[string]bool{}
+    for _, event := range req.Events {
+        if !seen[event] {
+            seen[event] = true
+            events = append(events, event)
+        }
+    }
+
+    subscription, err := s.webhookRepo.CreateSubscription(ctx, req.Url, req.Secret, events)
+    if err != nil {
+        return models.WebhookSubscription{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return subscription, models.ErrorResponse{}
+}
+
+func validateSubscription(req models.WebhooksAddPostRequest) models.ErrorResponse {
+    invalid := func(message string) models.ErrorResponse {
+        return models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: message,
+			},
+		}
+    }
+
+    target, err := url.Parse(req.Url)
+    if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+        return invalid("url must be an absolute http(s) URL")
+    }
+    if req.Secret == "" {
+        return invalid("secret is required")
+    }
+    if len(req.Events) == 0 {
+        return invalid("events must not be empty")
+    }
+    for _, event := range req.Events {
+        if !webhookEvents[event] {
+            return invalid(fmt.Sprintf("unknown event %q, expected pr.created, reviewer.assigned, reviewer.reassigned or pr.merged", event))
+        }
+    }
+
+    return models.ErrorResponse{}
+}
+
+func (s *WebhookService) List(ctx context.Context) (models.WebhooksListGet200Response, models.ErrorResponse) {
+    subscriptions, err := s.webhookRepo.ListSubscriptions(ctx)
+    if err != nil {
+        return models.WebhooksListGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return models.WebhooksListGet200Response{Subscriptions: subscriptions}, models.ErrorResponse{}
+}
+
+func (s *WebhookService) Delete(ctx context.Context, req models.WebhooksDeletePostRequest) models.ErrorResponse {
+    err := s.webhookRepo.DeleteSubscription(ctx, req.SubscriptionId)
+    if errors.Is(err, internal_models.ErrWebhookNotFound) {
+        return models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_FOUND",
+				Message: err.Error(),
+			},
+		}
+    }
+    if err != nil {
+        return models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return models.ErrorResponse{}
+}
+
+func (s *WebhookService) Deliveries(ctx context.Context, req models.WebhooksDeliveriesGetRequest) (models.WebhooksDeliveriesGet200Response, models.ErrorResponse) {
+    if req.Status != "" && req.Status != DeliveryPending && req.Status != DeliveryDelivered && req.Status != DeliveryFailed {
+        return models.WebhooksDeliveriesGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: "status must be pending, delivered or failed",
+			},
+		}
+    }
+
+    limit := req.Limit
+    if limit == 0 {
+        limit = defaultListLimit
+    }
+    if limit < 0 || limit > maxListLimit {
+        return models.WebhooksDeliveriesGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INVALID_REQUEST",
+				Message: fmt.Sprintf("limit must be between 1 and %d", maxListLimit),
+			},
+		}
+    }
+
+    deliveries, err := s.webhookRepo.ListDeliveries(ctx, internal_models.WebhookDeliveryFilter{
+        SubscriptionID: req.SubscriptionId,
+        Status:         req.Status,
+        Limit:          limit,
+    })
+    if err != nil {
+        return models.WebhooksDeliveriesGet200Response{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return models.WebhooksDeliveriesGet200Response{Deliveries: deliveries}, models.ErrorResponse{}
+}
+
+// Redeliver ставит в очередь копию доставки с тем же телом; исходная запись журнала не меняется
+func (s *WebhookService) Redeliver(ctx context.Context, req models.WebhooksRedeliverPostRequest) (models.WebhookDelivery, models.ErrorResponse) {
+    delivery, err := s.webhookRepo.Redeliver(ctx, req.DeliveryId)
+    if errors.Is(err, internal_models.ErrDeliveryNotFound) {
+        return models.WebhookDelivery{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "NOT_FOUND",
+				Message: err.Error(),
+			},
+		}
+    }
+    if err != nil {
+        return models.WebhookDelivery{}, models.ErrorResponse{
+			Error: models.ErrorResponseError{
+				Code: "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}
+    }
+
+    return delivery, models.ErrorResponse{}
+}
+
+// Publish ставит в очередь вебхуки для событий истории PR. Вызывается внутри транзакции события,
+// поэтому доставка появляется только вместе с ним
+func (s *WebhookService) Publish(ctx context.Context, events ...models.PullRequestEvent) error {
+    for _, event := range events {
+        payload := models.WebhookPayload{
+            PullRequestId: event.PullRequestId,
+            ActorId:       event.ActorId,
+            Reason:        event.Reason,
+            OccurredAt:    time.Now().UTC(),
+        }
+        if event.CreatedAt != nil {
+            payload.OccurredAt = *event.CreatedAt
+        }
+
+        switch event.Type {
+        case EventCreated:
+            payload.Event = WebhookPullRequestCreated
+        case EventAssigned:
+            payload.Event = WebhookReviewerAssigned
+            payload.ReviewerId = event.ReviewerId
+        case EventReassigned:
+            payload.Event = WebhookReviewerReassigned
+            payload.OldReviewerId = event.OldReviewerId
+            payload.NewReviewerId = event.NewReviewerId
+        case EventMerged:
+            payload.Event = WebhookPullRequestMerged
+        default:
+            continue
+        }
+
+        body, err := json.Marshal(payload)
+        if err != nil {
+            return err
+        }
+        if err := s.webhookRepo.EnqueueDeliveries(ctx, payload.Event, body); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// publishReassignments сообщает подписчикам о заменах ревьюверов, сделанных пакетно
+// (уход из команды, деактивация, удаление). Изменения к этому моменту уже зафиксированы,
+// поэтому ошибка постановки в очередь только логируется
+func publishReassignments(ctx context.Context, publisher EventPublisher, decisions []internal_models.ReviewerReassignment, reason string) {
+    events := []models.PullRequestEvent{}
+    for _, d := range decisions {
+        if d.NewReviewerID == "" {
+            continue
+        }
+        events = append(events, models.PullRequestEvent{
+            PullRequestId: d.PullRequestID,
+            Type:          EventReassigned,
+            ActorId:       ActorFromContext(ctx),
+            OldReviewerId: d.OldReviewerID,
+            NewReviewerId: d.NewReviewerID,
+            Reason:        reason,
+        })
+    }
+    if len(events) == 0 {
+        return
+    }
+
+    if err := publisher.Publish(ctx, events...); err != nil {
+        log.Printf("webhooks: failed to enqueue %d reassignments: %v", len(events), err)
+    }
+}
+
+// Run рассылает доставки из очереди, пока не отменён ctx. Несколько реплик могут работать
+// одновременно: каждая доставка на время отправки закрепляется за одной из них
+func (s *WebhookService) Run(ctx context.Context) {
+    ticker := time.NewTicker(webhookPollInterval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+
+        // очередь разбирается пачками, пока в ней есть доставки, время которых пришло
+        for ctx.Err() == nil {
+            tasks, err := s.webhookRepo.ClaimDueDeliveries(ctx, time.Now().UTC(), webhookLease, webhookBatchSize)
+            if err != nil {
+                log.Printf("webhooks: failed to claim deliveries: %v", err)
+                break
+            }
+            for _, task := range tasks {
+                s.deliver(ctx, task)
+            }
+            if len(tasks) < webhookBatchSize {
+                break
+            }
+        }
+    }
+}
+
+// deliver отправляет одну доставку и записывает результат попытки; неудачная попытка
+// откладывает следующую по экспоненте, после webhookMaxAttempts доставка считается проваленной
+func (s *WebhookService) deliver(ctx context.Context, task internal_models.WebhookTask) {
+    attempt := internal_models.WebhookAttempt{At: time.Now().UTC()}
+
+    status, err := s.send(ctx, task)
+    if status != 0 {
+        attempt.ResponseStatus = &status
+    }
+    switch {
+    case err != nil:
+        attempt.Error = err.Error()
+    case status < 200 || status >= 300:
+        attempt.Error = fmt.Sprintf("subscriber responded with %d", status)
+    default:
+        attempt.Delivered = true
+    }
+
+    if !attempt.Delivered && task.Attempts+1 < webhookMaxAttempts {
+        next := attempt.At.Add(webhookBackoff(task.Attempts + 1))
+        attempt.NextAttemptAt = &next
+    }
+
+    // если аренда всё же истекла, доставку уже взяла другая реплика, и результат запишет она
+    err = s.webhookRepo.RecordAttempt(ctx, task.DeliveryID, task.LeasedUntil, attempt)
+    if errors.Is(err, internal_models.ErrDeliveryLeaseLost) {
+        log.Printf("webhooks: lease of delivery %d expired before the attempt was recorded, result discarded", task.DeliveryID)
+        return
+    }
+    if err != nil {
+        log.Printf("webhooks: failed to record attempt of delivery %d: %v", task.DeliveryID, err)
+    }
+}
+
+func (s *WebhookService) send(ctx context.Context, task internal_models.WebhookTask) (int, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, task.URL, bytes.NewReader(task.Payload))
+    if err != nil {
+        return 0, err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("User-Agent", "pr-reviewer-service-webhooks")
+    req.Header.Set(WebhookEventHeader, task.Event)
+    req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(task.DeliveryID, 10))
+    req.Header.Set(WebhookSignatureHeader, SignWebhook(task.Secret, task.Payload))
+
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return 0, err
+    }
+    defer resp.Body.Close()
+    // тело ответа не нужно, но его дочитывают, чтобы соединение вернулось в пул
+    io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+    return resp.StatusCode, nil
+}
+
+// SignWebhook - значение заголовка X-Webhook-Signature-256 для тела body
+func SignWebhook(secret string, body []byte) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write(body)
+    return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff - пауза перед попыткой номер attempt+1
+func webhookBackoff(attempt int) time.Duration {
+    delay := webhookBaseBackoff
+    for i := 1; i < attempt; i++ {
+        delay *= 2
+        if delay >= webhookMaxBackoff {
+            return webhookMaxBackoff
+        }
+    }
+    return delay
+}
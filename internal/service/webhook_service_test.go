@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	internal_models "github.com/kgugunava/avito-tech-internship/internal/models"
+)
+
+// TestWebhookLeaseCoversBatch: реплика отправляет пачку последовательно, и каждая отправка может занять
+// webhookTimeout. Пока не записана последняя попытка, другая реплика не должна забрать ни одну доставку пачки
+func TestWebhookLeaseCoversBatch(t *testing.T) {
+    for _, storage := range testStorages {
+        t.Run(storage.name, func(t *testing.T) {
+            ctx := context.Background()
+            webhooks := storage.open(t).webhooks
+
+            if _, err := webhooks.CreateSubscription(ctx, "https://hooks.example.com/reviews", "secret", []string{WebhookPullRequestMerged}); err != nil {
+                t.Fatalf("CreateSubscription: %v", err)
+            }
+            for i := 0; i < webhookBatchSize; i++ {
+                if err := webhooks.EnqueueDeliveries(ctx, WebhookPullRequestMerged, []byte(`{}`)); err != nil {
+                    t.Fatalf("EnqueueDeliveries: %v", err)
+                }
+            }
+
+            now := time.Now().UTC()
+            batch, err := webhooks.ClaimDueDeliveries(ctx, now, webhookLease, webhookBatchSize)
+            if err != nil || len(batch) != webhookBatchSize {
+                t.Fatalf("claim batch: %d tasks, %v", len(batch), err)
+            }
+
+            for i, task := range batch {
+                // худший случай: каждая предыдущая отправка и эта упёрлись в таймаут
+                sentAt := now.Add(time.Duration(i+1) * webhookTimeout)
+                if reclaimed, err := webhooks.ClaimDueDeliveries(ctx, sentAt, webhookLease, webhookBatchSize); err != nil || len(reclaimed) != 0 {
+                    t.Fatalf("claim at send %d of %d: %d tasks reclaimed, %v", i+1, len(batch), len(reclaimed), err)
+                }
+                if err := webhooks.RecordAttempt(ctx, task.DeliveryID, task.LeasedUntil, internal_models.WebhookAttempt{At: sentAt, Delivered: true}); err != nil {
+                    t.Fatalf("RecordAttempt %d of %d: %v", i+1, len(batch), err)
+                }
+            }
+        })
+    }
+}
+
+// TestRecordAttemptAfterLeaseExpired: доставку, аренда которой истекла, забирает другая реплика,
+// и опоздавший результат первой не перезаписывает её попытку
+func TestRecordAttemptAfterLeaseExpired(t *testing.T) {
+    for _, storage := range testStorages {
+        t.Run(storage.name, func(t *testing.T) {
+            ctx := context.Background()
+            webhooks := storage.open(t).webhooks
+
+            if _, err := webhooks.CreateSubscription(ctx, "https://hooks.example.com/reviews", "secret", []string{WebhookPullRequestMerged}); err != nil {
+                t.Fatalf("CreateSubscription: %v", err)
+            }
+            if err := webhooks.EnqueueDeliveries(ctx, WebhookPullRequestMerged, []byte(`{}`)); err != nil {
+                t.Fatalf("EnqueueDeliveries: %v", err)
+            }
+
+            now := time.Now().UTC()
+            first, err := webhooks.ClaimDueDeliveries(ctx, now, webhookLease, 1)
+            if err != nil || len(first) != 1 {
+                t.Fatalf("first claim: %d tasks, %v", len(first), err)
+            }
+            if leased, err := webhooks.ClaimDueDeliveries(ctx, now.Add(webhookLease/2), webhookLease, 1); err != nil || len(leased) != 0 {
+                t.Fatalf("claim during lease: %d tasks, %v", len(leased), err)
+            }
+            second, err := webhooks.ClaimDueDeliveries(ctx, first[0].LeasedUntil.Add(time.Second), webhookLease, 1)
+            if err != nil || len(second) != 1 {
+                t.Fatalf("claim after lease: %d tasks, %v", len(second), err)
+            }
+
+            attempt := internal_models.WebhookAttempt{At: now, Delivered: true}
+            err = webhooks.RecordAttempt(ctx, first[0].DeliveryID, first[0].LeasedUntil, attempt)
+            if !errors.Is(err, internal_models.ErrDeliveryLeaseLost) {
+                t.Fatalf("late RecordAttempt: %v, want ErrDeliveryLeaseLost", err)
+            }
+            if err := webhooks.RecordAttempt(ctx, second[0].DeliveryID, second[0].LeasedUntil, attempt); err != nil {
+                t.Fatalf("RecordAttempt: %v", err)
+            }
+
+            deliveries, err := webhooks.ListDeliveries(ctx, internal_models.WebhookDeliveryFilter{Limit: 10})
+            if err != nil {
+                t.Fatalf("ListDeliveries: %v", err)
+            }
+            if len(deliveries) != 1 || deliveries[0].Attempts != 1 || deliveries[0].Status != DeliveryDelivered {
+                t.Fatalf("deliveries %+v, want one delivered after a single attempt", deliveries)
+            }
+        })
+    }
+}